@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/noperator/curlmin/pkg/curlmin"
+)
+
+// batchResult holds the outcome of minimizing one file in batch mode
+type batchResult struct {
+	path      string
+	original  string
+	minimized string
+	err       error
+}
+
+// batchIgnoredFlags are the output and per-run flags that batch mode has no
+// way to apply, since it prints each minimized command as is
+var batchIgnoredFlags = []string{
+	"annotate", "parameterize", "removed-only", "to", "report", "diff",
+	"history", "compare-history", "checkpoint", "start-from",
+}
+
+// runBatch minimizes every regular file in dir, running up to jobs
+// minimizations at once, each with its own Minimizer (sharing one comparison
+// cache, with --comparison-cache). Results are printed in
+// file name order followed by aggregate stats, and the returned exit code is
+// exitError if any command failed.
+func runBatch(dir string, jobs int, options curlmin.Options) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading directory %s: %v\n", dir, err)
+		return exitError
+	}
+
+	var results []batchResult
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			results = append(results, batchResult{path: filepath.Join(dir, entry.Name())})
+		}
+	}
+
+	if jobs < 1 {
+		jobs = 1
+	}
+	if options.ComparisonCache {
+		options.SharedComparisonCache = curlmin.NewComparisonCache()
+	}
+
+	// Bound the number of concurrent minimizations with a semaphore
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(result *batchResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			fileBytes, err := os.ReadFile(result.path)
+			if err != nil {
+				result.err = err
+				return
+			}
+			result.original = string(fileBytes)
+
+			// Keep each command's reports apart
+			opts := options
+			if opts.ReportDir != "" {
+				opts.ReportDir = filepath.Join(opts.ReportDir, filepath.Base(result.path))
+			}
+			if opts.HARFile != "" {
+				ext := filepath.Ext(opts.HARFile)
+				opts.HARFile = strings.TrimSuffix(opts.HARFile, ext) + "-" + filepath.Base(result.path) + ext
+			}
+			result.minimized, result.err = curlmin.New(opts).MinimizeCurlCommand(result.original)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	// Print results in a stable order and tally the aggregate stats
+	var failed, changed, originalBytes, minimizedBytes int
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "Error minimizing %s: %v\n", result.path, result.err)
+			continue
+		}
+
+		if commandChanged(result.original, result.minimized) {
+			changed++
+		}
+		originalBytes += len(result.original)
+		minimizedBytes += len(result.minimized)
+
+		fmt.Printf("# %s\n%s\n", result.path, result.minimized)
+	}
+
+	fmt.Fprintf(os.Stderr, "Minimized %d commands (%d reduced, %d failed), %d bytes down to %d\n",
+		len(results)-failed, changed, failed, originalBytes, minimizedBytes)
+
+	if failed > 0 {
+		return exitError
+	}
+	return exitOK
+}
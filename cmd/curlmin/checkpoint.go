@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/noperator/curlmin/pkg/curlmin"
+)
+
+// readCheckpoint loads a checkpoint written by an interrupted run
+func readCheckpoint(path string) (curlmin.Checkpoint, error) {
+	var checkpoint curlmin.Checkpoint
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return checkpoint, err
+	}
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return checkpoint, fmt.Errorf("invalid checkpoint: %w", err)
+	}
+	if checkpoint.Command == "" {
+		return checkpoint, fmt.Errorf("invalid checkpoint: no command")
+	}
+
+	return checkpoint, nil
+}
+
+// writeCheckpoint saves a checkpoint as JSON
+func writeCheckpoint(path string, checkpoint curlmin.Checkpoint) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // Keep '&' in URLs readable
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(checkpoint); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// checkpointOnInterrupt writes min's progress to path and exits when the
+// process is interrupted, so the run can be resumed with --start-from
+func checkpointOnInterrupt(min *curlmin.Minimizer, path string) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		if err := writeCheckpoint(path, min.Checkpoint()); err != nil {
+			fmt.Fprintf(os.Stderr, "\nError writing checkpoint: %v\n", err)
+			os.Exit(exitError)
+		}
+		fmt.Fprintf(os.Stderr, "\nInterrupted; resume with --start-from %s\n", path)
+		os.Exit(exitInterrupted)
+	}()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/noperator/curlmin/pkg/curlmin"
+	"github.com/spf13/cobra"
+)
+
+// Flags for the convert subcommand
+var (
+	convertTo      string
+	convertCommand string
+	convertFile    string
+)
+
+// convertCmd translates a (usually already minimized) curl command into
+// code or text for another tool
+var convertCmd = &cobra.Command{
+	Use:                   "convert",
+	Short:                 "Convert a curl command into code for another tool",
+	Long:                  `Convert a curl command, such as the output of curlmin, into equivalent code for another tool.`,
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !slices.Contains(curlmin.ConvertTargets(), convertTo) {
+			fmt.Fprintf(os.Stderr, "Error: invalid --to %q, expected one of %s\n", convertTo, strings.Join(curlmin.ConvertTargets(), ", "))
+			os.Exit(1)
+		}
+
+		// Read the command the same ways the root command does
+		var curlCmd string
+		switch {
+		case convertCommand != "":
+			curlCmd = convertCommand
+		case convertFile != "" && convertFile != "-":
+			fileBytes, err := os.ReadFile(convertFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading from file %s: %v\n", convertFile, err)
+				os.Exit(1)
+			}
+			curlCmd = string(fileBytes)
+		case convertFile == "-" || stdinAvailable():
+			fileBytes, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading from stdin: %v\n", err)
+				os.Exit(1)
+			}
+			curlCmd = string(fileBytes)
+		default:
+			fmt.Fprintf(os.Stderr, "Error: either --command/-c or --file/-f is required, or pipe input via stdin\n\n")
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		converted, err := curlmin.ConvertCurlCommand(curlCmd, convertTo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error converting curl command: %v\n", err)
+			os.Exit(exitParseError)
+		}
+		// End commands with a newline, but leave a raw request's bytes as is
+		if !strings.HasSuffix(converted, "\n") && convertTo != "raw" {
+			converted += "\n"
+		}
+		fmt.Print(converted)
+	},
+}
+
+func init() {
+	convertCmd.Flags().StringVar(&convertTo, "to", "", "What to convert to: "+strings.Join(curlmin.ConvertTargets(), ", "))
+	convertCmd.Flags().StringVarP(&convertCommand, "command", "c", "", "Curl command to convert")
+	convertCmd.Flags().StringVarP(&convertFile, "file", "f", "", "File containing the curl command to convert (- for stdin)")
+	convertCmd.MarkFlagRequired("to")
+	rootCmd.AddCommand(convertCmd)
+}
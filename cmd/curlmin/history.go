@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/noperator/curlmin/pkg/curlmin"
+)
+
+// lastHistoryEntry returns the most recent entry for id in a history file of
+// JSON lines, if there is one
+func lastHistoryEntry(path, id string) (curlmin.HistoryEntry, bool, error) {
+	var last curlmin.HistoryEntry
+	found := false
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return last, false, nil
+	} else if err != nil {
+		return last, false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry curlmin.HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return last, false, fmt.Errorf("invalid history entry on line %d: %w", line, err)
+		}
+		if entry.ID == id {
+			last, found = entry, true
+		}
+	}
+	return last, found, scanner.Err()
+}
+
+// appendHistory adds an entry to a history file as a JSON line
+func appendHistory(path string, entry curlmin.HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// recordHistory appends the result that minimized to curlCmd to the history file
+// and, if compare is set, reports how the required elements changed since
+// the previous run against the same endpoint. It returns whether they did.
+func recordHistory(path, curlCmd string, decisions []curlmin.Decision, compare bool) (bool, error) {
+	entry, err := curlmin.NewHistoryEntry(curlCmd, decisions, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	if compare {
+		prev, ok, err := lastHistoryEntry(path, entry.ID)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			added, removed := entry.Changes(prev)
+			changed = len(added) > 0 || len(removed) > 0
+			if changed {
+				fmt.Fprintf(os.Stderr, "Required elements for %s changed since %s:\n", entry.ID, prev.Time.Format(time.RFC3339))
+				for _, element := range added {
+					fmt.Fprintf(os.Stderr, "  + %s\n", element)
+				}
+				for _, element := range removed {
+					fmt.Fprintf(os.Stderr, "  - %s\n", element)
+				}
+			}
+		}
+	}
+
+	return changed, appendHistory(path, entry)
+}
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/noperator/curlmin/pkg/curlmin"
 	"github.com/spf13/cobra"
@@ -11,23 +18,130 @@ import (
 	"golang.org/x/term"
 )
 
+// Exit codes, stable for use in scripts
+const (
+	exitOK             = 0   // Minimized, removing at least one element
+	exitError          = 1   // Any other error
+	exitParseError     = 2   // The curl command couldn't be parsed (or uses unsupported constructs with --strict)
+	exitBaselineError  = 3   // The baseline request couldn't be executed
+	exitCurlNotFound   = 4   // No curl binary is available
+	exitNothingRemoved = 5   // Nothing could be removed
+	exitHistoryChanged = 6   // The required elements changed since the last run (with --compare-history)
+	exitInterrupted    = 130 // Interrupted (after writing --checkpoint)
+)
+
+// robustCountTolerance is the byte count tolerance (in percent) used by --robust
+const robustCountTolerance = 5
+
 var (
 	// Input options
 	commandStr  string
 	commandFile string
+	batchDir    string
+	jobs        int
+	startFrom   string
+	rawFile     string
+	rawScheme   string
+	burpFile    string
+	burpIndex   int
+	burpFilter  string
+	fetchFile   string
+	psFile      string
 
 	// Minimization options
 	minimizeHeaders bool
 	minimizeCookies bool
 	minimizeParams  bool
+	preferShortest  bool
+	minimizeValues  bool
+	keepBody        bool
+	failFast        bool
+	reproduce       bool
+	requireSuccess  bool
+	confirmRuns     int
+	strict          bool
+	historyFile     string
+	compareHistory  bool
+	timeoutIsMatch  bool
+	preRequestHook  string
+	traceRequests   bool
+	native          bool
+	replayFile      string
+	concurrency     int
+	requestDelay    time.Duration
+	requestRate     string
+	requestJitter   time.Duration
+	maxRequests     int
+	maxDuration     time.Duration
+	shell           string
+	logFilter       string
+	pairs           []string
+	signedFamilies  []string
+	minimizeSigned  bool
+	minimizeTLS     bool
+	irrelevantFile  string
+	greedyPass      bool
+	strategy        string
+	cosmeticFirst   bool
+	cosmeticNames   []string
+	keepHeaders     []string
+	keepCookies     []string
+	keepParams      []string
+	keepPatterns    []string
+	onlyHeaders     []string
+	onlyCookies     []string
+	onlyParams      []string
+	minimizeBody    bool
+	minimizeGraphQL bool
 	verbose         bool
 
 	// Response comparison options
 	compareStatusCode  bool
+	compareStatusText  bool
 	compareBodyContent bool
 	compareWordCount   bool
 	compareLineCount   bool
 	compareByteCount   bool
+	compareBodyLines   bool
+	ignoreLines        []string
+	compareJSON        bool
+	ignoreJSONPaths    []string
+	jqExpression       string
+	compareContentType bool
+	compareTrailers    bool
+	compareHeaders     []string
+	countTolerance     float64
+	robust             bool
+	maxDiffScore       float64
+	minSimilarity      float64
+	autoCompare        bool
+	autoSamples        int
+	compareRedirects   bool
+	stripHTMLNoise     bool
+	comparisonCache    bool
+	responseCache      bool
+	matchPattern       string
+	matchRegex         string
+	notMatch           string
+
+	// Output options
+	copyToClipboard bool
+	exitUnchanged   bool
+	reportDir       string
+	recordFile      string
+	checkpointFile  string
+	annotate        bool
+	parameterize    bool
+	removedOnly     bool
+	printReport     bool
+	dryRun          bool
+	showDiff        bool
+	outputFormat    string
+	outputTo        string
+
+	// Profiling options
+	cpuProfile string
+	memProfile string
 )
 
 func main() {
@@ -42,9 +156,85 @@ var rootCmd = &cobra.Command{
 	Short:                 "Minimize curl commands by removing unnecessary options",
 	Long:                  `curlmin is a tool that minimizes curl commands by removing unnecessary options while preserving the same behavior.`,
 	DisableFlagsInUseLine: true,
+	CompletionOptions:     cobra.CompletionOptions{DisableDefaultCmd: true},
 	Run: func(cmd *cobra.Command, args []string) {
+		// Ignoring lines implies line-by-line body comparison
+		if len(ignoreLines) > 0 {
+			compareBodyLines = true
+		}
+		// Ignoring JSON paths implies JSON comparison
+		if len(ignoreJSONPaths) > 0 {
+			compareJSON = true
+		}
+
+		// A rate is just another way to give the delay between requests
+		if requestRate != "" {
+			if requestDelay > 0 {
+				fmt.Fprintf(os.Stderr, "Error: --delay and --rate can't be used together\n")
+				os.Exit(1)
+			}
+			var err error
+			requestDelay, err = parseRate(requestRate)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if outputFormat != "text" && outputFormat != "json" {
+			fmt.Fprintf(os.Stderr, "Error: invalid --output %q, expected text or json\n", outputFormat)
+			os.Exit(1)
+		}
+		if outputTo != "" && !slices.Contains(curlmin.ConvertTargets(), outputTo) {
+			fmt.Fprintf(os.Stderr, "Error: invalid --to %q, expected one of %s\n", outputTo, strings.Join(curlmin.ConvertTargets(), ", "))
+			os.Exit(1)
+		}
+		if outputTo != "" && (annotate || parameterize || removedOnly || outputFormat == "json") {
+			fmt.Fprintf(os.Stderr, "Error: --to can't be used with --annotate, --parameterize, --removed-only, or --output json\n")
+			os.Exit(1)
+		}
+
+		if matchPattern != "" && matchRegex != "" {
+			fmt.Fprintf(os.Stderr, "Error: --match and --match-regex can't be used together\n")
+			os.Exit(1)
+		}
+		matchIsRegex := matchRegex != ""
+		if matchIsRegex {
+			matchPattern = matchRegex
+		}
+
+		if batchDir != "" {
+			var ignored []string
+			for _, name := range batchIgnoredFlags {
+				if cmd.Flags().Changed(name) {
+					ignored = append(ignored, "--"+name)
+				}
+			}
+			if outputFormat == "json" {
+				ignored = append(ignored, "--output json")
+			}
+			if len(ignored) > 0 {
+				fmt.Fprintf(os.Stderr, "Error: --dir can't be used with %s\n", strings.Join(ignored, ", "))
+				os.Exit(1)
+			}
+		}
+
+		if compareHistory && historyFile == "" {
+			fmt.Fprintf(os.Stderr, "Error: --compare-history requires --history\n")
+			os.Exit(1)
+		}
+
+		// The robust preset compares the status and roughly the body size
+		if robust {
+			compareStatusCode = true
+			compareByteCount = true
+			if !cmd.Flags().Changed("count-tolerance") {
+				countTolerance = robustCountTolerance
+			}
+		}
+
 		// If any other comparison option is set, disable the default body comparison
-		if compareStatusCode || compareWordCount || compareLineCount || compareByteCount {
+		if compareStatusCode || compareStatusText || compareWordCount || compareLineCount || compareByteCount || compareBodyLines || compareJSON || compareRedirects || compareContentType || compareTrailers || len(compareHeaders) > 0 || jqExpression != "" || maxDiffScore > 0 || minSimilarity > 0 {
 			// Check if body flag was explicitly set
 			bodyFlagExplicitlySet := false
 			cmd.Flags().Visit(func(f *pflag.Flag) {
@@ -58,10 +248,249 @@ var rootCmd = &cobra.Command{
 			}
 		}
 
+		// Parse param:cookie pairs
+		var pairedParamCookie [][2]string
+		for _, pair := range pairs {
+			param, cookie, ok := strings.Cut(pair, ":")
+			if !ok || param == "" || cookie == "" {
+				fmt.Fprintf(os.Stderr, "Error: invalid --pair %q, expected param:cookie\n", pair)
+				os.Exit(1)
+			}
+			pairedParamCookie = append(pairedParamCookie, [2]string{param, cookie})
+		}
+
+		// Additional cosmetic headers extend the default list
+		var cosmeticHeaders []string
+		if len(cosmeticNames) > 0 {
+			cosmeticHeaders = append(cosmeticHeaders, curlmin.DefaultCosmeticHeaders...)
+			cosmeticHeaders = append(cosmeticHeaders, cosmeticNames...)
+		}
+
+		// Parse additional signed URL families
+		var signedURLFamilies []curlmin.SignedURLFamily
+		if len(signedFamilies) > 0 {
+			signedURLFamilies = append(signedURLFamilies, curlmin.DefaultSignedURLFamilies...)
+			for _, family := range signedFamilies {
+				signature, params, ok := strings.Cut(family, ":")
+				if !ok || signature == "" {
+					fmt.Fprintf(os.Stderr, "Error: invalid --signed-family %q, expected signature:param,param,...\n", family)
+					os.Exit(1)
+				}
+				signedURLFamilies = append(signedURLFamilies, curlmin.SignedURLFamily{
+					Name:      signature,
+					Signature: signature,
+					Params:    strings.Split(params, ","),
+				})
+			}
+		}
+
+		// Load the params to strip without testing
+		var irrelevantParams []string
+		if irrelevantFile != "" {
+			var err error
+			irrelevantParams, err = readPatterns(irrelevantFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading irrelevant params from %s: %v\n", irrelevantFile, err)
+				os.Exit(1)
+			}
+		}
+
+		// Answer requests from a recording instead of the network
+		var executor curlmin.Executor
+		if replayFile != "" {
+			if native {
+				fmt.Fprintf(os.Stderr, "Error: --native and --replay can't be used together\n")
+				os.Exit(1)
+			}
+			data, err := os.ReadFile(replayFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading from file %s: %v\n", replayFile, err)
+				os.Exit(1)
+			}
+			replay, err := curlmin.NewHARReplay(data)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading recording %s: %v\n", replayFile, err)
+				os.Exit(1)
+			}
+			executor = replay
+		}
+
+		var searchStrategy curlmin.Strategy
+		switch strategy {
+		case "linear":
+			searchStrategy = curlmin.StrategyLinear
+		case "ddmin":
+			searchStrategy = curlmin.StrategyDDMin
+		default:
+			fmt.Fprintf(os.Stderr, "Error: invalid --strategy %q, expected linear or ddmin\n", strategy)
+			os.Exit(1)
+		}
+
+		timeoutComparison := curlmin.TimeoutDiffers
+		if timeoutIsMatch {
+			timeoutComparison = curlmin.TimeoutMatchesTimeout
+		}
+
+		options := curlmin.Options{
+			MinimizeHeaders:      minimizeHeaders,
+			MinimizeCookies:      minimizeCookies,
+			MinimizeParams:       minimizeParams,
+			PreferShortest:       preferShortest,
+			MinimizeValues:       minimizeValues,
+			KeepBody:             keepBody,
+			Verbose:              verbose,
+			StopAtFirstRequired:  failFast,
+			Reproduce:            reproduce,
+			RequireSuccess:       requireSuccess,
+			ConfirmRuns:          confirmRuns,
+			Strict:               strict,
+			TraceRequests:        traceRequests,
+			TimeoutComparison:    timeoutComparison,
+			Strategy:             searchStrategy,
+			PreRequestHook:       preRequestHook,
+			Native:               native,
+			Executor:             executor,
+			Concurrency:          concurrency,
+			RequestDelay:         requestDelay,
+			RequestJitter:        requestJitter,
+			MaxRequests:          maxRequests,
+			MaxDuration:          maxDuration,
+			Shell:                shell,
+			LogFilter:            logFilter,
+			PairedParamCookie:    pairedParamCookie,
+			SignedURLFamilies:    signedURLFamilies,
+			MinimizeSignedParams: minimizeSigned,
+			MinimizeTLS:          minimizeTLS,
+			IrrelevantParams:     irrelevantParams,
+			GreedyPass:           greedyPass,
+			CosmeticHeadersFirst: cosmeticFirst,
+			CosmeticHeaders:      cosmeticHeaders,
+			KeepHeaders:          keepHeaders,
+			KeepCookies:          keepCookies,
+			KeepParams:           keepParams,
+			KeepPatterns:         keepPatterns,
+			OnlyHeaders:          onlyHeaders,
+			OnlyCookies:          onlyCookies,
+			OnlyParams:           onlyParams,
+			MinimizeBody:         minimizeBody,
+			MinimizeGraphQL:      minimizeGraphQL,
+			ReportDir:            reportDir,
+			HARFile:              recordFile,
+			// Response comparison options
+			CompareStatusCode:     compareStatusCode,
+			CompareStatusText:     compareStatusText,
+			CompareBodyContent:    compareBodyContent,
+			CompareWordCount:      compareWordCount,
+			CompareLineCount:      compareLineCount,
+			CompareByteCount:      compareByteCount,
+			CompareBodyLines:      compareBodyLines,
+			IgnoreLinePatterns:    ignoreLines,
+			CompareJSON:           compareJSON,
+			IgnoreJSONPaths:       ignoreJSONPaths,
+			JQExpression:          jqExpression,
+			CompareContentType:    compareContentType,
+			CompareTrailers:       compareTrailers,
+			CompareHeaders:        compareHeaders,
+			CountTolerancePercent: countTolerance,
+			MaxDiffScore:          maxDiffScore,
+			MinSimilarity:         minSimilarity,
+			AutoCompare:           autoCompare,
+			AutoSamples:           autoSamples,
+			CompareRedirectChain:  compareRedirects,
+			StripHTMLNoise:        stripHTMLNoise,
+			ComparisonCache:       comparisonCache,
+			ResponseCache:         responseCache,
+			MatchPattern:          matchPattern,
+			MatchIsRegex:          matchIsRegex,
+			ForbidRegex:           notMatch,
+		}
+
+		// Profile the minimization if requested
+		stopProfiling, err := startProfiling(cpuProfile, memProfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting profile: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Minimize every command in a directory in batch mode
+		if batchDir != "" {
+			code := runBatch(batchDir, jobs, options)
+			stopProfiling()
+			os.Exit(code)
+		}
+
 		var curlCmd string
 
 		// Determine the source of the curl command
-		if commandStr != "" {
+		if startFrom != "" {
+			// Resume from the command and passes saved in a checkpoint
+			checkpoint, err := readCheckpoint(startFrom)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading checkpoint %s: %v\n", startFrom, err)
+				os.Exit(1)
+			}
+			curlCmd = checkpoint.Command
+			options.CompletedPasses = checkpoint.Completed
+		} else if rawFile != "" {
+			// Convert a raw HTTP request captured by a proxy into a curl command
+			rawBytes, err := os.ReadFile(rawFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading from file %s: %v\n", rawFile, err)
+				os.Exit(1)
+			}
+			curlCmd, err = curlmin.RawRequestToCurl(rawBytes, rawScheme)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error parsing raw request %s: %v\n", rawFile, err)
+				os.Exit(exitParseError)
+			}
+		} else if burpFile != "" {
+			// Convert a request picked from a Burp Suite export into a curl command
+			burpBytes, err := os.ReadFile(burpFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading from file %s: %v\n", burpFile, err)
+				os.Exit(1)
+			}
+			items, err := curlmin.ParseBurpItems(burpBytes)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading Burp export %s: %v\n", burpFile, err)
+				os.Exit(exitParseError)
+			}
+			item, err := curlmin.SelectBurpItem(items, burpIndex, burpFilter)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			curlCmd, err = item.ToCurl()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error converting %s %s: %v\n", item.Method, item.URL, err)
+				os.Exit(exitParseError)
+			}
+		} else if fetchFile != "" {
+			// Convert a fetch() call copied from a browser into a curl command
+			fetchBytes, err := os.ReadFile(fetchFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading from file %s: %v\n", fetchFile, err)
+				os.Exit(1)
+			}
+			curlCmd, err = curlmin.FetchToCurl(string(fetchBytes))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error parsing fetch() call %s: %v\n", fetchFile, err)
+				os.Exit(exitParseError)
+			}
+		} else if psFile != "" {
+			// Convert an Invoke-WebRequest command copied from a browser into
+			// a curl command
+			psBytes, err := os.ReadFile(psFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading from file %s: %v\n", psFile, err)
+				os.Exit(1)
+			}
+			curlCmd, err = curlmin.PowerShellToCurl(string(psBytes))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error parsing PowerShell command %s: %v\n", psFile, err)
+				os.Exit(exitParseError)
+			}
+		} else if commandStr != "" {
 			// Use the command string provided via -command/-c flag
 			curlCmd = commandStr
 		} else if commandFile != "" {
@@ -95,44 +524,185 @@ var rootCmd = &cobra.Command{
 			curlCmd = string(fileBytes)
 		} else {
 			// If no command source is specified and stdin is not available, show usage and exit
-			fmt.Fprintf(os.Stderr, "Error: either --command/-c or --file/-f is required, or pipe input via stdin\n\n")
+			fmt.Fprintf(os.Stderr, "Error: either --command/-c, --file/-f, --raw, or --burp is required, or pipe input via stdin\n\n")
 			cmd.Help()
 			os.Exit(1)
 		}
 
-		// Print the original curl command if verbose
+		// Print the original curl command if verbose, leaving stdout to the
+		// minimized command
 		if verbose {
-			fmt.Println("Original curl command:")
-			fmt.Println(curlCmd)
-			fmt.Println()
+			fmt.Fprintln(os.Stderr, "Original curl command:")
+			fmt.Fprintln(os.Stderr, curlCmd)
+			fmt.Fprintln(os.Stderr)
 		}
 
-		options := curlmin.Options{
-			MinimizeHeaders: minimizeHeaders,
-			MinimizeCookies: minimizeCookies,
-			MinimizeParams:  minimizeParams,
-			Verbose:         verbose,
-			// Response comparison options
-			CompareStatusCode:  compareStatusCode,
-			CompareBodyContent: compareBodyContent,
-			CompareWordCount:   compareWordCount,
-			CompareLineCount:   compareLineCount,
-			CompareByteCount:   compareByteCount,
+		min := curlmin.New(options)
+
+		// List what would be tested instead of sending anything
+		if dryRun {
+			plan, err := min.Plan(curlCmd)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error planning minimization: %v\n", err)
+				if errors.Is(err, curlmin.ErrParse) || errors.Is(err, curlmin.ErrUnsupported) {
+					os.Exit(exitParseError)
+				}
+				os.Exit(exitError)
+			}
+			printPlan(plan)
+			os.Exit(exitOK)
 		}
 
-		min := curlmin.New(options)
+		// Save progress if interrupted so the run can be resumed
+		if checkpointFile != "" {
+			checkpointOnInterrupt(min, checkpointFile)
+		}
 
-		minimizedCmd, err := min.MinimizeCurlCommand(curlCmd)
+		start := time.Now()
+		minimizedCmd, report, err := min.MinimizeCurlCommandWithReport(curlCmd)
+		stopProfiling()
+		// Verbose mode already printed warnings as they came up
+		if !verbose {
+			if comparison := min.Comparison(); comparison != "" {
+				fmt.Fprintf(os.Stderr, "Comparing by %s\n", comparison)
+			}
+			for _, warning := range min.Warnings() {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			}
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error minimizing curl command: %v\n", err)
-			os.Exit(1)
+			switch {
+			case errors.Is(err, curlmin.ErrParse), errors.Is(err, curlmin.ErrUnsupported):
+				os.Exit(exitParseError)
+			case errors.Is(err, curlmin.ErrCurlNotFound):
+				os.Exit(exitCurlNotFound)
+			case errors.Is(err, curlmin.ErrBaseline):
+				os.Exit(exitBaselineError)
+			default:
+				os.Exit(exitError)
+			}
 		}
 
 		// Print the minimized curl command
-		if verbose {
-			fmt.Println("Minimized curl command:")
+		output := minimizedCmd
+		if outputFormat == "json" {
+			// Describe the whole run as JSON instead
+			if err := printJSONOutput(min, curlCmd, minimizedCmd, report, time.Since(start)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
+				os.Exit(exitError)
+			}
+		} else {
+			if removedOnly {
+				// List what was removed instead, one element per line
+				var removed []string
+				for _, d := range min.Decisions() {
+					if d.Removed {
+						removed = append(removed, d.Kind+": "+d.Name)
+					}
+				}
+				output = strings.Join(removed, "\n")
+			}
+			if parameterize && !removedOnly {
+				// Move secrets into environment variables, exported first
+				var vars []curlmin.EnvVar
+				output, vars, err = curlmin.ParameterizeCurlCommand(output)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error parameterizing curl command: %v\n", err)
+					os.Exit(exitError)
+				}
+				for _, v := range vars {
+					fmt.Printf("export %s=%s\n", v.Name, shellQuote(v.Value))
+				}
+			}
+			if annotate && !removedOnly {
+				// Explain why each remaining element is required
+				output, err = curlmin.AnnotateCurlCommand(output, min.Decisions())
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error annotating curl command: %v\n", err)
+					os.Exit(exitError)
+				}
+			}
+			if outputTo != "" {
+				// Port the command to another tool
+				output, err = curlmin.ConvertCurlCommand(output, outputTo)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error converting curl command: %v\n", err)
+					os.Exit(exitError)
+				}
+				if outputTo != "raw" {
+					output = strings.TrimSuffix(output, "\n")
+				}
+			}
+			if verbose && removedOnly {
+				fmt.Fprintln(os.Stderr, "Removed elements:")
+			} else if verbose {
+				fmt.Fprintln(os.Stderr, "Minimized curl command:")
+			}
+			if outputTo == "raw" {
+				// A newline after the body wouldn't match its Content-Length
+				fmt.Print(output)
+			} else if output != "" {
+				fmt.Println(output)
+			}
+
+			// Show which arguments disappeared, for review
+			if showDiff {
+				parts, err := curlmin.DiffCurlCommands(curlCmd, minimizedCmd)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error diffing curl commands: %v\n", err)
+					os.Exit(exitError)
+				}
+				if verbose {
+					fmt.Fprintln(os.Stderr, "Diff:")
+				}
+				fmt.Println(renderDiff(parts, colorOutput()))
+			}
+		}
+
+		// Summarize what was removed and kept for other tools
+		if printReport {
+			reportJSON, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding report: %v\n", err)
+				os.Exit(exitError)
+			}
+			fmt.Fprintln(os.Stderr, string(reportJSON))
+		}
+
+		// Report the first required element when stopping early
+		if failFast {
+			if first := min.FirstRequired(); first != "" {
+				fmt.Fprintf(os.Stderr, "First required element: %s\n", first)
+			} else {
+				fmt.Fprintf(os.Stderr, "No required element found\n")
+			}
 		}
-		fmt.Println(minimizedCmd)
+
+		// Track the required elements over time
+		historyChanged := false
+		if historyFile != "" {
+			historyChanged, err = recordHistory(historyFile, minimizedCmd, min.Decisions(), compareHistory)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to update history: %v\n", err)
+			}
+		}
+
+		// Also copy the minimized curl command to the clipboard if requested
+		if copyToClipboard {
+			if err := writeClipboard(strings.TrimSpace(output)); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to copy to clipboard: %v\n", err)
+			}
+		}
+
+		// Signal that nothing could be removed if requested
+		if !commandChanged(curlCmd, minimizedCmd) {
+			os.Exit(exitNothingRemoved)
+		}
+		if historyChanged {
+			os.Exit(exitHistoryChanged)
+		}
+		os.Exit(exitOK)
 	},
 }
 
@@ -140,9 +710,19 @@ func init() {
 	// Input options group
 	rootCmd.Flags().StringVarP(&commandStr, "command", "c", "", "Curl command as a string")
 	rootCmd.Flags().StringVarP(&commandFile, "file", "f", "", "File containing the curl command")
+	rootCmd.Flags().StringVar(&batchDir, "dir", "", "Directory of files, each containing a curl command to minimize")
+	rootCmd.Flags().IntVar(&jobs, "jobs", 1, "Number of commands to minimize concurrently with --dir")
+	rootCmd.Flags().StringVar(&startFrom, "start-from", "", "Resume from a checkpoint file written by --checkpoint")
+	rootCmd.Flags().StringVar(&rawFile, "raw", "", "File containing a raw HTTP request (as saved by Burp, ZAP, or mitmproxy)")
+	rootCmd.Flags().StringVar(&rawScheme, "raw-scheme", "https", "Scheme to use with the Host header of a --raw request")
+	rootCmd.Flags().StringVar(&burpFile, "burp", "", "Burp Suite XML export (\"Save items\") to read the request from")
+	rootCmd.Flags().IntVar(&burpIndex, "burp-index", 0, "Pick the request at this position (from 1) in the --burp export")
+	rootCmd.Flags().StringVar(&burpFilter, "burp-filter", "", "Pick the first request in the --burp export whose URL contains this string")
+	rootCmd.Flags().StringVar(&fetchFile, "fetch", "", "File containing a JavaScript fetch() call (as copied with \"Copy as fetch\")")
+	rootCmd.Flags().StringVar(&psFile, "powershell", "", "File containing a PowerShell Invoke-WebRequest command (as copied with \"Copy as PowerShell\")")
 
 	// Mark flags with their group
-	for _, name := range []string{"command", "file"} {
+	for _, name := range []string{"command", "file", "dir", "jobs", "start-from", "raw", "raw-scheme", "burp", "burp-index", "burp-filter", "fetch", "powershell"} {
 		flag := rootCmd.Flags().Lookup(name)
 		if flag != nil {
 			flag.Annotations = make(map[string][]string)
@@ -152,13 +732,35 @@ func init() {
 
 	// Comparison options group
 	rootCmd.Flags().BoolVar(&compareStatusCode, "status", false, "Compare status code")
+	rootCmd.Flags().BoolVar(&compareStatusText, "status-text", false, "Compare status line (code and reason phrase)")
 	rootCmd.Flags().BoolVar(&compareBodyContent, "body", true, "Compare body content")
 	rootCmd.Flags().BoolVar(&compareWordCount, "words", false, "Compare word count")
 	rootCmd.Flags().BoolVar(&compareLineCount, "lines", false, "Compare line count")
 	rootCmd.Flags().BoolVar(&compareByteCount, "bytes", false, "Compare byte count")
+	rootCmd.Flags().Float64Var(&countTolerance, "count-tolerance", 0, "Let word, line, and byte counts differ by up to this percent")
+	rootCmd.Flags().BoolVar(&robust, "robust", false, fmt.Sprintf("Compare status and byte count within %d%% (a good starting point for jittery responses)", robustCountTolerance))
+	rootCmd.Flags().BoolVar(&compareBodyLines, "body-lines", false, "Compare body line by line, skipping ignored lines")
+	rootCmd.Flags().StringArrayVar(&ignoreLines, "ignore-line", nil, "Ignore body lines matching this regex (repeatable, implies --body-lines)")
+	rootCmd.Flags().BoolVar(&compareJSON, "json", false, "Compare body as JSON, ignoring formatting, key order, and number format")
+	rootCmd.Flags().StringArrayVar(&ignoreJSONPaths, "ignore-json-path", nil, "Ignore the value at this JSON path, e.g. .meta.requestId (repeatable, implies --json)")
+	rootCmd.Flags().StringVar(&jqExpression, "jq", "", "Compare the values this jq expression extracts from the JSON body (e.g. '.data.id')")
+	rootCmd.Flags().BoolVar(&compareContentType, "content-type", false, "Compare the Content-Type media type, ignoring parameters like charset")
+	rootCmd.Flags().BoolVar(&compareTrailers, "trailers", false, "Compare the trailer fields sent after a chunked body, which are otherwise ignored")
+	rootCmd.Flags().StringArrayVar(&compareHeaders, "compare-header", nil, "Compare the values of this response header (repeatable)")
+	rootCmd.Flags().Float64Var(&maxDiffScore, "max-diff-score", 0, "Treat bodies as equivalent if the fraction of lines that differ is at most this (e.g. 0.05)")
+	rootCmd.Flags().Float64Var(&minSimilarity, "similarity", 0, "Treat bodies as equivalent if they're at least this similar, word by word (e.g. 0.95)")
+	rootCmd.Flags().BoolVar(&autoCompare, "auto", false, "Pick the comparison from a few baseline responses, ignoring other comparison flags")
+	rootCmd.Flags().IntVar(&autoSamples, "auto-samples", 3, "Number of baseline responses --auto inspects")
+	rootCmd.Flags().BoolVar(&compareRedirects, "compare-redirect-chain", false, "Compare the sequence of redirect Locations")
+	rootCmd.Flags().BoolVar(&stripHTMLNoise, "strip-html-noise", false, "Ignore HTML comments, inline scripts, and nonces when comparing bodies")
+	rootCmd.Flags().BoolVar(&comparisonCache, "comparison-cache", false, "Reuse results when the same pair of responses is compared again")
+	rootCmd.Flags().BoolVar(&responseCache, "response-cache", false, "Reuse the response to a candidate identical to one already tested instead of sending it again")
+	rootCmd.Flags().StringVar(&matchPattern, "match", "", "Treat a response as equivalent if its body contains this string, ignoring other comparison flags")
+	rootCmd.Flags().StringVar(&matchRegex, "match-regex", "", "Treat a response as equivalent if its body matches this regex, ignoring other comparison flags")
+	rootCmd.Flags().StringVar(&notMatch, "not-match", "", "Reject a candidate whose body matches this regex when the baseline's doesn't")
 
 	// Mark flags with their group
-	for _, name := range []string{"status", "body", "words", "lines", "bytes"} {
+	for _, name := range []string{"auto", "auto-samples", "status", "status-text", "body", "words", "lines", "bytes", "count-tolerance", "robust", "body-lines", "ignore-line", "json", "ignore-json-path", "jq", "content-type", "trailers", "compare-header", "max-diff-score", "similarity", "compare-redirect-chain", "strip-html-noise", "comparison-cache", "response-cache", "match", "match-regex", "not-match"} {
 		flag := rootCmd.Flags().Lookup(name)
 		if flag != nil {
 			flag.Annotations = make(map[string][]string)
@@ -170,9 +772,30 @@ func init() {
 	rootCmd.Flags().BoolVar(&minimizeHeaders, "headers", true, "Minimize headers")
 	rootCmd.Flags().BoolVar(&minimizeCookies, "cookies", true, "Minimize cookies")
 	rootCmd.Flags().BoolVar(&minimizeParams, "params", true, "Minimize query parameters")
+	rootCmd.Flags().BoolVar(&minimizeValues, "values", false, "Try shortening the values of required elements (e.g. Referer to its origin, User-Agent to its first product, Basic auth to an empty password, numeric params to 0)")
+	rootCmd.Flags().BoolVar(&keepBody, "keep-body", false, "Never remove or modify the request body")
+	rootCmd.Flags().BoolVar(&preferShortest, "prefer-shortest", false, "Try removing the longest elements first")
+	rootCmd.Flags().BoolVarP(&minimizeBody, "data", "d", false, "Minimize the fields of a JSON, form-urlencoded, or multipart (-F) request body")
+	rootCmd.Flags().BoolVar(&minimizeGraphQL, "graphql", false, "Minimize a GraphQL request body field by field, then its variables")
+	rootCmd.Flags().BoolVar(&greedyPass, "greedy", false, "Remove all independently removable headers or cookies at once, verifying them together")
+	rootCmd.Flags().StringVar(&strategy, "strategy", "linear", "How to search for removable elements: linear (one at a time) or ddmin (groups first, for large commands)")
+	rootCmd.Flags().BoolVar(&cosmeticFirst, "cosmetic-first", false, "Try removing all cosmetic headers (Accept*, Sec-Fetch-*, ...) at once before testing headers individually")
+	rootCmd.Flags().StringArrayVar(&cosmeticNames, "cosmetic-header", nil, "Also treat this header name or glob as cosmetic (repeatable)")
+	rootCmd.Flags().StringArrayVar(&keepHeaders, "keep-header", nil, "Always keep this header without testing it (repeatable)")
+	rootCmd.Flags().StringArrayVar(&keepCookies, "keep-cookie", nil, "Always keep this cookie without testing it (repeatable)")
+	rootCmd.Flags().StringArrayVar(&keepParams, "keep-param", nil, "Always keep this query param without testing it (repeatable)")
+	rootCmd.Flags().StringArrayVar(&keepPatterns, "keep", nil, "Always keep headers, cookies, and params whose whole name matches this regex, without testing them (repeatable)")
+	rootCmd.Flags().StringSliceVar(&onlyHeaders, "only-headers", nil, "Only test headers whose whole name matches one of these comma-separated regexes, keeping the rest")
+	rootCmd.Flags().StringSliceVar(&onlyCookies, "only-cookies", nil, "Only test cookies whose whole name matches one of these comma-separated regexes, keeping the rest")
+	rootCmd.Flags().StringSliceVar(&onlyParams, "only-params", nil, "Only test query params whose whole name matches one of these comma-separated regexes, keeping the rest")
+	rootCmd.Flags().StringArrayVar(&pairs, "pair", nil, "Keep or remove a query param and cookie together, as param:cookie (repeatable)")
+	rootCmd.Flags().StringArrayVar(&signedFamilies, "signed-family", nil, "Also preserve a signed URL param family, as signature:param,param,... (repeatable)")
+	rootCmd.Flags().BoolVar(&minimizeSigned, "minimize-signed", false, "Test signed URL params individually instead of preserving them")
+	rootCmd.Flags().BoolVar(&minimizeTLS, "minimize-tls", false, "Test removing TLS version and cipher flags instead of preserving them")
+	rootCmd.Flags().StringVar(&irrelevantFile, "irrelevant-params-file", "", "Remove the params named in this file (one name or glob per line) without testing them")
 
 	// Mark flags with their group
-	for _, name := range []string{"headers", "cookies", "params"} {
+	for _, name := range []string{"headers", "cookies", "params", "values", "keep-body", "prefer-shortest", "greedy", "strategy", "cosmetic-first", "cosmetic-header", "keep-header", "keep-cookie", "keep-param", "keep", "only-headers", "only-cookies", "only-params", "data", "graphql", "pair", "signed-family", "minimize-signed", "minimize-tls", "irrelevant-params-file"} {
 		flag := rootCmd.Flags().Lookup(name)
 		if flag != nil {
 			flag.Annotations = make(map[string][]string)
@@ -182,6 +805,43 @@ func init() {
 
 	// Flags group (for flags that don't fit in other categories)
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+	rootCmd.Flags().BoolVar(&failFast, "fail-fast", false, "Stop at the first required element and report it")
+	rootCmd.Flags().BoolVar(&reproduce, "reproduce", false, "Minimize toward reproducing an error baseline, status code included")
+	rootCmd.Flags().BoolVar(&requireSuccess, "require-success", false, "Refuse to minimize a command whose baseline response is an error (status 400 or above)")
+	rootCmd.Flags().IntVar(&confirmRuns, "confirm-runs", 0, "Re-send each matching candidate this many more times before removing the element")
+	rootCmd.Flags().BoolVar(&strict, "strict", false, "Refuse commands using constructs curlmin can't model (pipelines, redirections, --next, config files)")
+	rootCmd.Flags().StringVar(&preRequestHook, "pre-request-hook", "", "Shell command run before each request whose output updates values (see README)")
+	rootCmd.Flags().BoolVar(&timeoutIsMatch, "timeout-is-match", false, "Treat a timed-out request as matching a baseline that also timed out")
+	rootCmd.Flags().BoolVar(&native, "native", false, "Send requests with Go's net/http instead of the curl binary (no -F, -T, proxies, or cookie files)")
+	rootCmd.Flags().StringVar(&replayFile, "replay", "", "Answer requests with the responses recorded in this HAR file (e.g. from --record) instead of sending them")
+	rootCmd.Flags().IntVarP(&concurrency, "concurrency", "j", 1, "Number of candidate requests to send at once")
+	rootCmd.Flags().DurationVar(&requestDelay, "delay", 0, "Minimum time between the starts of two requests (e.g. 500ms)")
+	rootCmd.Flags().StringVar(&requestRate, "rate", "", "Maximum request rate, as requests per second, minute, or hour (e.g. 2/s or 30/m)")
+	rootCmd.Flags().DurationVar(&requestJitter, "jitter", 0, "Add a random delay of up to this much before each request (e.g. 200ms)")
+	rootCmd.Flags().IntVar(&maxRequests, "max-requests", 0, "Stop testing after sending this many requests and print the partially minimized command")
+	rootCmd.Flags().DurationVar(&maxDuration, "max-duration", 0, "Stop testing after this long (e.g. 5m) and print the partially minimized command")
+	rootCmd.Flags().StringVar(&shell, "shell", "sh", "Shell used to run curl commands (e.g. bash for $'...' quoting)")
+	rootCmd.Flags().StringVar(&logFilter, "log-filter", "", "Only log header decisions for header names matching this regex (verbose)")
+	rootCmd.Flags().BoolVar(&traceRequests, "trace-requests", false, "Show the request headers curl actually sent (verbose)")
+	rootCmd.Flags().BoolVar(&copyToClipboard, "copy", false, "Also copy the minimized command to the clipboard")
+	rootCmd.Flags().BoolVar(&parameterize, "parameterize", false, "Replace secret-looking values with $VAR placeholders and print their exports")
+	rootCmd.Flags().BoolVar(&annotate, "annotate", false, "Print the minimized command one option per line, commenting why each element is required")
+	rootCmd.Flags().BoolVar(&showDiff, "diff", false, "Also print a word-level diff between the original and minimized commands (colored on a terminal)")
+	rootCmd.Flags().BoolVar(&removedOnly, "removed-only", false, "Print only the removed headers, cookies, and params, one per line, instead of the command")
+	rootCmd.Flags().StringVar(&outputTo, "to", "", "Print the minimized command converted for another tool: "+strings.Join(curlmin.ConvertTargets(), ", "))
+	rootCmd.Flags().StringVar(&outputFormat, "output", "text", "Output format: text (the minimized command) or json (the original and minimized commands, decisions, and stats)")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the elements that would be tested and the number of requests needed, without sending any")
+	rootCmd.Flags().BoolVar(&printReport, "report", false, "Print the removed and kept elements and the number of requests as JSON to stderr")
+	rootCmd.Flags().StringVar(&reportDir, "report-dir", "", "Write the baseline and final responses to this directory")
+	rootCmd.Flags().StringVar(&recordFile, "record", "", "Save every request sent and its response to this HAR file")
+	rootCmd.Flags().StringVar(&checkpointFile, "checkpoint", "", "On interrupt, save progress to this file for --start-from")
+	rootCmd.Flags().StringVar(&historyFile, "history", "", "Append the required elements of each run to this file (JSON lines)")
+	rootCmd.Flags().BoolVar(&compareHistory, "compare-history", false, "Report (and exit with code 6) when the required elements differ from the last --history entry")
+	rootCmd.Flags().BoolVar(&exitUnchanged, "exit-unchanged", false, "Exit with code 5 if nothing could be removed")
+	// Kept so that scripts passing it don't break
+	rootCmd.Flags().MarkDeprecated("exit-unchanged", "curlmin always exits with code 5 when nothing could be removed")
+	rootCmd.Flags().StringVar(&cpuProfile, "cpuprofile", "", "Write a CPU profile of the minimization to this file")
+	rootCmd.Flags().StringVar(&memProfile, "memprofile", "", "Write an allocation profile of the minimization to this file")
 
 	// Set up custom help template to display grouped flags
 	cobra.AddTemplateFunc("FlagsInGroup", FlagsInGroup)
@@ -229,6 +889,44 @@ func FilterFlags(cmd *cobra.Command) *pflag.FlagSet {
 	return fs
 }
 
+// rateUnits maps the units --rate accepts to their length
+var rateUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// parseRate converts a rate like 2/s or 30/m into the delay between the
+// starts of two requests
+func parseRate(rate string) (time.Duration, error) {
+	count, unit, _ := strings.Cut(rate, "/")
+	if unit == "" {
+		unit = "s"
+	}
+	n, err := strconv.ParseFloat(count, 64)
+	length, ok := rateUnits[unit]
+	if err != nil || !ok || n <= 0 {
+		return 0, fmt.Errorf("invalid rate %q: want requests per second, minute, or hour, like 2/s or 30/m", rate)
+	}
+	return time.Duration(float64(length) / n), nil
+}
+
+// printPlan prints the elements a run would test, those it would skip, and
+// how many requests it would send
+func printPlan(plan *curlmin.Plan) {
+	fmt.Println("Candidates for removal:")
+	for _, c := range plan.Candidates {
+		fmt.Printf("  %s: %s\n", c.Kind, c.Name)
+	}
+	if len(plan.Untested) > 0 {
+		fmt.Println("Not tested:")
+		for _, d := range plan.Untested {
+			fmt.Printf("  %s: %s (%s)\n", d.Kind, d.Name, d.Reason)
+		}
+	}
+	fmt.Printf("Requests: %d if every candidate is removable, %d if every one is required\n", plan.RequestsIfRemovable, plan.RequestsIfRequired)
+}
+
 // stdinAvailable checks if stdin is available (not a terminal and has data to read)
 func stdinAvailable() bool {
 	// Check if stdin is a terminal
@@ -245,17 +943,88 @@ func stdinAvailable() bool {
 	return (stat.Mode() & os.ModeCharDevice) == 0
 }
 
+// commandChanged reports whether the minimized command differs from the
+// original once both are normalized the same way
+func commandChanged(original, minimized string) bool {
+	if preprocessed, err := curlmin.PreprocessCurlCommand(original); err == nil {
+		original = preprocessed
+	}
+
+	curl, err := curlmin.ParseCurlCommand(original)
+	if err != nil {
+		return true
+	}
+
+	normalized, err := curl.ToString()
+	if err != nil {
+		return true
+	}
+	return normalized != minimized
+}
+
+// readPatterns reads one pattern per line, skipping blank lines and # comments
+func readPatterns(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
+// shellQuote single-quotes a value for the shell
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// clipboardCommands lists the clipboard utilities to try, in order of preference
+var clipboardCommands = [][]string{
+	{"pbcopy"},
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"clip.exe"},
+}
+
+// writeClipboard copies text to the system clipboard using the first available utility
+func writeClipboard(text string) error {
+	for _, args := range clipboardCommands {
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			continue
+		}
+
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		return cmd.Run()
+	}
+
+	return fmt.Errorf("no clipboard utility found (tried pbcopy, wl-copy, xclip, xsel, clip.exe)")
+}
+
 // Custom usage template with grouped flags
 const usageTemplate = `Usage:
-  {{.UseLine}}
+  {{.UseLine}}{{if .HasAvailableSubCommands}}
+  {{.CommandPath}} [command]{{end}}
+
+{{if .HasAvailableSubCommands}}Commands:{{range .Commands}}{{if .IsAvailableCommand}}
+  {{rpad .Name .NamePadding}} {{.Short}}{{end}}{{end}}
 
-{{if FlagsInGroup . "Input"}}Input:
+{{end}}{{if (FlagsInGroup . "Input").HasFlags}}Input:
 {{(FlagsInGroup . "Input").FlagUsages | trimTrailingWhitespaces}}
 
-{{end}}{{if FlagsInGroup . "Comparison"}}Comparison:
+{{end}}{{if (FlagsInGroup . "Comparison").HasFlags}}Comparison:
 {{(FlagsInGroup . "Comparison").FlagUsages | trimTrailingWhitespaces}}
 
-{{end}}{{if FlagsInGroup . "Minimization"}}Minimization:
+{{end}}{{if (FlagsInGroup . "Minimization").HasFlags}}Minimization:
 {{(FlagsInGroup . "Minimization").FlagUsages | trimTrailingWhitespaces}}
 
 {{end}}Flags:
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/noperator/curlmin/pkg/curlmin"
+	"golang.org/x/term"
+)
+
+// jsonOutput is what --output json prints: everything about a run that
+// other tools might want
+type jsonOutput struct {
+	Original   string                   `json:"original"`
+	Minimized  string                   `json:"minimized"`
+	Decisions  []jsonDecision           `json:"decisions"`
+	Requests   int                      `json:"requests"`
+	DurationMS int64                    `json:"duration_ms"`
+	Partial    bool                     `json:"partial"`
+	Comparison curlmin.ComparisonConfig `json:"comparison"`
+	Warnings   []string                 `json:"warnings"`
+}
+
+// jsonDecision is a curlmin.Decision in --output json
+type jsonDecision struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Removed  bool   `json:"removed"`
+	Reason   string `json:"reason,omitempty"`
+	AnyValue bool   `json:"any_value,omitempty"`
+	// DurationMS is the time spent on the tests that decided it
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// ANSI escapes for coloring diffs
+const (
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorReset = "\033[0m"
+)
+
+// colorOutput reports whether stdout is a terminal that colors should be
+// written to (see https://no-color.org)
+func colorOutput() bool {
+	return os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// renderDiff writes a command diff with removed text in red and added text
+// in green, or without colors, marked like git's word diff: [-removed-] and
+// {+added+}
+func renderDiff(parts []curlmin.DiffPart, color bool) string {
+	var b strings.Builder
+	for _, part := range parts {
+		switch {
+		case part.Op == curlmin.DiffDelete && color:
+			b.WriteString(colorRed + part.Text + colorReset)
+		case part.Op == curlmin.DiffInsert && color:
+			b.WriteString(colorGreen + part.Text + colorReset)
+		case part.Op == curlmin.DiffDelete:
+			b.WriteString("[-" + part.Text + "-]")
+		case part.Op == curlmin.DiffInsert:
+			b.WriteString("{+" + part.Text + "+}")
+		default:
+			b.WriteString(part.Text)
+		}
+	}
+	return b.String()
+}
+
+// printJSONOutput prints the result of a run as JSON
+func printJSONOutput(min *curlmin.Minimizer, original, minimized string, report *curlmin.Report, elapsed time.Duration) error {
+	output := jsonOutput{
+		Original:   strings.TrimSpace(original),
+		Minimized:  strings.TrimSpace(minimized),
+		Decisions:  []jsonDecision{},
+		Requests:   report.Requests,
+		DurationMS: elapsed.Milliseconds(),
+		Partial:    report.Partial,
+		Comparison: min.ComparisonConfig(),
+		Warnings:   min.Warnings(),
+	}
+	if output.Warnings == nil {
+		output.Warnings = []string{}
+	}
+	for _, d := range min.Decisions() {
+		output.Decisions = append(output.Decisions, jsonDecision{
+			Kind:       d.Kind,
+			Name:       d.Name,
+			Removed:    d.Removed,
+			Reason:     d.Reason,
+			AnyValue:   d.AnyValue,
+			DurationMS: d.Duration.Milliseconds(),
+		})
+	}
+
+	// Keep URLs and reasons readable instead of escaping &, <, and >
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
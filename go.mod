@@ -4,11 +4,16 @@ go 1.23.0
 
 toolchain go1.24.2
 
+require (
+	github.com/itchyny/gojq v0.12.17
+	github.com/spf13/cobra v1.9.1
+	github.com/spf13/pflag v1.0.6
+	golang.org/x/term v0.32.0
+	mvdan.cc/sh/v3 v3.11.0
+)
+
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/cobra v1.9.1 // indirect
-	github.com/spf13/pflag v1.0.6 // indirect
+	github.com/itchyny/timefmt-go v0.1.6 // indirect
 	golang.org/x/sys v0.33.0 // indirect
-	golang.org/x/term v0.32.0 // indirect
-	mvdan.cc/sh/v3 v3.11.0 // indirect
 )
@@ -0,0 +1,118 @@
+package curlmin
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// AnnotateCurlCommand renders a (minimized) curl command one option per line,
+// with a trailing comment on each kept header, cookie, and query parameter
+// explaining why it's required. Comments use the `# ...` form so the result
+// still runs as a shell command.
+func AnnotateCurlCommand(curlCmd string, decisions []Decision) (string, error) {
+	curl, err := ParseCurlCommand(curlCmd)
+	if err != nil {
+		return "", err
+	}
+
+	// Index the reasons for kept elements
+	reasons := make(map[string]string)
+	for _, d := range decisions {
+		if !d.Removed {
+			reason := d.Reason
+			if d.AnyValue {
+				reason += ", but any value works"
+			}
+			reasons[annotationKey(d.Kind, d.Name)] = reason
+		}
+	}
+
+	headerIndices := make(map[int]bool)
+	for _, i := range slices.Concat(curl.FindHeaderArgs(), curl.FindHeaderFlagArgs(), curl.FindAuthArgs(), curl.FindTLSArgs()) {
+		headerIndices[i] = true
+	}
+	cookieIndices := make(map[int]bool)
+	for _, i := range curl.FindCookieArgs() {
+		cookieIndices[i] = true
+	}
+	urlIndex, _ := curl.FindURLArg()
+
+	var lines []string
+	for i := 1; i < len(curl.Command.Args); i++ {
+		start := i
+		if strings.HasPrefix(curl.argString(i), "-") && takesValue(curl.argString(i)) && i+1 < len(curl.Command.Args) {
+			i++
+		}
+
+		// Collect the kept elements this option carries
+		var kind string
+		var names []string
+		switch {
+		case cookieIndices[start]:
+			kind, names = KindCookie, cookieNames(cookieValue(curl.argString(start+1)))
+		case headerIndices[start]:
+			kind, names = KindHeader, []string{curl.headerArgName(start)}
+		case curl.argString(start) == "--url-query":
+			kind, names = KindQueryParam, []string{curl.argString(start + 1)}
+		case start == urlIndex:
+			if parsedURL, err := url.Parse(curl.argString(start)); err == nil {
+				for _, pair := range strings.Split(parsedURL.RawQuery, "&") {
+					if name, _, _ := strings.Cut(pair, "="); name != "" {
+						names = append(names, name)
+					}
+				}
+			}
+			kind = KindQueryParam
+		}
+
+		var notes []string
+		for _, name := range names {
+			reason, ok := reasons[annotationKey(kind, name)]
+			if !ok {
+				continue
+			}
+			if len(names) > 1 {
+				reason = fmt.Sprintf("%s (%s)", name, reason)
+			}
+			notes = append(notes, reason)
+		}
+
+		line := "    " + curl.rawArgs(start, i)
+		if len(notes) > 0 {
+			comment := "# required: " + strings.Join(notes, "; ")
+			line += " `" + strings.ReplaceAll(comment, "`", "'") + "`"
+		}
+		lines = append(lines, line)
+	}
+
+	return curl.argString(0) + " \\\n" + strings.Join(lines, " \\\n") + "\n", nil
+}
+
+// annotationKey identifies an element across decisions and the rendered
+// command. Headers are matched by name alone since a kept header's value
+// may have been shortened after it was found to be required.
+func annotationKey(kind, name string) string {
+	if kind == KindHeader {
+		name, _, _ = strings.Cut(name, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+	}
+	return kind + "\x00" + name
+}
+
+// rawArgs prints the arguments from start to end (inclusive) with their
+// original quoting
+func (c *CurlCommand) rawArgs(start, end int) string {
+	printer := syntax.NewPrinter()
+	var words []string
+	for i := start; i <= end; i++ {
+		var buf bytes.Buffer
+		printer.Print(&buf, c.Command.Args[i])
+		words = append(words, buf.String())
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,34 @@
+package curlmin
+
+import (
+	"testing"
+)
+
+func TestAnnotateCurlCommand(t *testing.T) {
+	curlCmd := `curl -H 'Authorization: Bearer xyz789' -b 'session=abc123; theme=dark' 'http://example.com/api?auth_key=def456&page=2'`
+	decisions := []Decision{
+		{Kind: KindHeader, Name: "Authorization: Bearer xyz789", Reason: "removing changes status 200 -> 401"},
+		{Kind: KindHeader, Name: "Accept: */*", Removed: true},
+		{Kind: KindCookie, Name: "session", Reason: "removing changes status 200 -> 302"},
+		{Kind: KindCookie, Name: "theme", Reason: "removing changes body size 10 -> 12 bytes"},
+		{Kind: KindQueryParam, Name: "auth_key", Reason: "removing changes status 200 -> 403"},
+	}
+
+	got, err := AnnotateCurlCommand(curlCmd, decisions)
+	if err != nil {
+		t.Fatalf("AnnotateCurlCommand() error: %v", err)
+	}
+
+	want := "curl \\\n" +
+		"    -H 'Authorization: Bearer xyz789' `# required: removing changes status 200 -> 401` \\\n" +
+		"    -b 'session=abc123; theme=dark' `# required: session (removing changes status 200 -> 302); theme (removing changes body size 10 -> 12 bytes)` \\\n" +
+		"    'http://example.com/api?auth_key=def456&page=2' `# required: auth_key (removing changes status 200 -> 403)`\n"
+	if got != want {
+		t.Errorf("AnnotateCurlCommand() =\n%s\nwant\n%s", got, want)
+	}
+
+	// The annotated command must still parse as the same curl command
+	if _, err := ParseCurlCommand(got); err != nil {
+		t.Errorf("annotated command doesn't parse: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package curlmin
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultAutoSamples is the number of baseline responses AutoCompare
+// inspects unless Options.AutoSamples says otherwise
+const defaultAutoSamples = 3
+
+// maxVaryingJSONPaths is the most JSON paths AutoCompare will ignore; a
+// response with more changing values than that is treated as unstable
+const maxVaryingJSONPaths = 10
+
+// chooseComparison replaces the comparison options with ones suited to the
+// baseline response, judged by its content type and by whether it changes
+// between identical requests. It returns a description of the choice.
+func (m *Minimizer) chooseComparison(baselineCmd string, baselineResp Response) (string, error) {
+	n := m.options.AutoSamples
+	if n < 2 {
+		n = defaultAutoSamples
+	}
+	samples := []Response{baselineResp}
+	for len(samples) < n {
+		resp, err := m.executeCurlCommand(baselineCmd)
+		if err != nil {
+			return "", err
+		}
+		samples = append(samples, resp)
+	}
+
+	stable := func(equal func(a, b Response) bool) bool {
+		for _, resp := range samples[1:] {
+			if !equal(baselineResp, resp) {
+				return false
+			}
+		}
+		return true
+	}
+	sameBody := func(a, b Response) bool { return a.Body == b.Body }
+
+	// Start over from no comparisons at all
+	o := &m.options
+	o.CompareStatusCode, o.CompareStatusText, o.CompareBodyContent = false, false, false
+	o.CompareWordCount, o.CompareLineCount, o.CompareByteCount, o.CountTolerancePercent = false, false, false, 0
+	o.CompareBodyLines, o.CompareJSON, o.CompareContentType = false, false, false
+	o.CompareRedirectChain, o.CompareTrailers, o.MaxDiffScore = false, false, 0
+	o.CompareHeaders, o.JQExpression, o.MinSimilarity = nil, "", 0
+	o.IgnoreJSONPaths, m.ignoreJSONPaths = nil, nil
+
+	mt := mediaType(baselineResp)
+	_, jsonErr := decodeJSON(baselineResp.Body)
+	isJSON := jsonErr == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+	isText := strings.HasPrefix(mt, "text/") || strings.Contains(mt, "xml") || strings.Contains(mt, "javascript") ||
+		(mt == "" && utf8.ValidString(baselineResp.Body))
+
+	var strategy string
+	varying, varyingOK := jsonVaryingPaths(samples)
+	switch {
+	case isJSON && stable(func(a, b Response) bool { return jsonEqual(a.Body, b.Body) }):
+		o.CompareJSON = true
+		strategy = "JSON structure, since the response is stable JSON"
+	case isJSON && varyingOK && len(varying) <= maxVaryingJSONPaths:
+		o.CompareJSON, o.IgnoreJSONPaths = true, varying
+		for _, path := range varying {
+			segments, _ := parseIgnoreJSONPath(path)
+			m.ignoreJSONPaths = append(m.ignoreJSONPaths, segments)
+		}
+		strategy = fmt.Sprintf("JSON structure ignoring %s, since those change between identical requests", strings.Join(varying, ", "))
+	case isText && stable(sameBody):
+		o.CompareBodyContent = true
+		strategy = "body content, since the response is stable"
+	case !isJSON && !isText && stable(func(a, b Response) bool { return len(a.Body) == len(b.Body) }):
+		o.CompareStatusCode, o.CompareByteCount = true, true
+		strategy = "status code and byte count, since the response is binary"
+	default:
+		o.CompareStatusCode, o.CompareContentType = true, true
+		strategy = "status code and content type, since the response changes between identical requests"
+		if start, end, ok := varyingRange(samples); ok && isText {
+			strategy += fmt.Sprintf(" (bytes %d-%d of %d vary)", start, end, len(baselineResp.Body))
+		}
+	}
+
+	if m.options.Verbose {
+		m.logf("Comparing by %s\n", strategy)
+	}
+	return strategy, nil
+}
+
+// jsonVaryingPaths finds the paths (in the form IgnoreJSONPaths takes) of
+// the values that differ between JSON responses to identical requests. It
+// returns false if the bodies aren't JSON, differ at the top level, or
+// have keys that a path can't express.
+func jsonVaryingPaths(samples []Response) ([]string, bool) {
+	values := make([]any, len(samples))
+	for i, resp := range samples {
+		v, err := decodeJSON(resp.Body)
+		if err != nil {
+			return nil, false
+		}
+		values[i] = v
+	}
+
+	varying := make(map[string]bool)
+	ok := true
+	var walk func(path string, vs []any)
+	walk = func(path string, vs []any) {
+		if !ok {
+			return
+		}
+		same := true
+		for _, v := range vs[1:] {
+			if !reflect.DeepEqual(vs[0], v) {
+				same = false
+				break
+			}
+		}
+		if same {
+			return
+		}
+
+		// Descend into objects and equally long arrays; anything else that
+		// differs is ignored as a whole
+		switch first := vs[0].(type) {
+		case map[string]any:
+			keys := make(map[string]bool)
+			for _, v := range vs {
+				object, isObject := v.(map[string]any)
+				if !isObject {
+					varying[path] = true
+					return
+				}
+				for key := range object {
+					keys[key] = true
+				}
+			}
+			for _, key := range slices.Sorted(maps.Keys(keys)) {
+				if key == "" || strings.ContainsAny(key, ".[]") {
+					ok = false
+					return
+				}
+				children := make([]any, len(vs))
+				for i, v := range vs {
+					children[i] = v.(map[string]any)[key]
+				}
+				walk(path+"."+key, children)
+			}
+		case []any:
+			for _, v := range vs {
+				if array, isArray := v.([]any); !isArray || len(array) != len(first) {
+					varying[path] = true
+					return
+				}
+			}
+			for i := range first {
+				children := make([]any, len(vs))
+				for j, v := range vs {
+					children[j] = v.([]any)[i]
+				}
+				walk(path+"["+strconv.Itoa(i)+"]", children)
+			}
+		default:
+			varying[path] = true
+		}
+	}
+	walk("", values)
+
+	if !ok || varying[""] {
+		return nil, false
+	}
+	return slices.Sorted(maps.Keys(varying)), true
+}
+
+// varyingRange returns the byte range of the baseline body (the first
+// sample) outside of which every sample is the same
+func varyingRange(samples []Response) (start, end int, ok bool) {
+	base := samples[0].Body
+	start, end = len(base), 0
+	for _, resp := range samples[1:] {
+		if resp.Body == base {
+			continue
+		}
+		ok = true
+		prefix := 0
+		for prefix < len(base) && prefix < len(resp.Body) && base[prefix] == resp.Body[prefix] {
+			prefix++
+		}
+		suffix := 0
+		for suffix < len(base)-prefix && suffix < len(resp.Body)-prefix && base[len(base)-1-suffix] == resp.Body[len(resp.Body)-1-suffix] {
+			suffix++
+		}
+		start, end = min(start, prefix), max(end, len(base)-suffix)
+	}
+	return start, end, ok
+}
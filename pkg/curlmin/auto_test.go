@@ -0,0 +1,101 @@
+package curlmin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChooseComparison(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			fmt.Fprint(w, `{"user": "admin", "roles": ["read", "write"]}`)
+		case "/json-ids":
+			// A fresh request ID and timestamp on every response
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"user": "admin", "meta": {"requestId": "r%d"}, "items": [{"id": 1, "ts": %d}]}`, requests, requests)
+		case "/html":
+			// A fresh nonce on every page load
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><script nonce="%d"></script></html>`, requests)
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			fmt.Fprint(w, "\x89PNG\r\n\x1a\n\xff\xfe")
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path    string
+		want    string
+		options func(Options) bool
+	}{
+		{"/json", "JSON structure", func(o Options) bool { return o.CompareJSON }},
+		{"/json-ids", "JSON structure ignoring .items[0].ts, .meta.requestId,", func(o Options) bool { return o.CompareJSON && len(o.IgnoreJSONPaths) == 2 }},
+		{"/html", "status code and content type, since the response changes between identical requests (bytes 21-22 of 40 vary)", func(o Options) bool { return o.CompareStatusCode && o.CompareContentType }},
+		{"/image", "status code and byte count", func(o Options) bool { return o.CompareStatusCode && o.CompareByteCount }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			m := New(Options{AutoCompare: true, CompareBodyContent: true})
+			baselineCmd := fmt.Sprintf("curl '%s%s'", server.URL, tt.path)
+			baselineResp, err := m.executeCurlCommand(baselineCmd)
+			if err != nil {
+				t.Fatalf("Failed to execute curl command: %v", err)
+			}
+
+			strategy, err := m.chooseComparison(baselineCmd, baselineResp)
+			if err != nil {
+				t.Fatalf("chooseComparison() failed: %v", err)
+			}
+			if !strings.HasPrefix(strategy, tt.want) {
+				t.Errorf("chooseComparison() chose %q, want %q", strategy, tt.want)
+			}
+			if !tt.options(m.options) {
+				t.Errorf("chooseComparison() set options %+v", m.options)
+			}
+			if m.options.CompareBodyContent {
+				t.Error("chooseComparison() kept the body comparison it was given")
+			}
+		})
+	}
+}
+
+func TestAutoSamples(t *testing.T) {
+	// The request ID changes every time, but nothing else does
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer xyz" {
+			fmt.Fprintf(w, `{"error": "unauthorized", "requestId": %d}`, requests)
+			return
+		}
+		fmt.Fprintf(w, `{"user": "admin", "requestId": %d}`, requests)
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'Accept: */*' -H 'Authorization: Bearer xyz' '%s/'`, server.URL)
+	m := New(Options{MinimizeHeaders: true, AutoCompare: true, AutoSamples: 5})
+	minimizedCmd, err := m.MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if want := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz' '%s/'`, server.URL); strings.TrimSpace(minimizedCmd) != want {
+		t.Errorf("Minimized command is %q, want %q", minimizedCmd, want)
+	}
+	if got, want := m.Comparison(), "JSON structure ignoring .requestId"; !strings.HasPrefix(got, want) {
+		t.Errorf("Comparison() = %q, want prefix %q", got, want)
+	}
+	// Five baseline samples, then one test per header
+	if requests != 7 {
+		t.Errorf("Sent %d requests, want 7", requests)
+	}
+}
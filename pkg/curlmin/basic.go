@@ -0,0 +1,48 @@
+package curlmin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// parseBasicAuth decodes the credentials of a Basic Authorization header value
+func parseBasicAuth(value string) (user, password string, ok bool) {
+	scheme, encoded, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
+
+// basicAuthValue encodes credentials as a Basic Authorization header value
+func basicAuthValue(user, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+}
+
+// noteBasicAuth flags a kept Authorization: Basic header as the likely auth
+// gate, naming the user but not the password
+func (m *Minimizer) noteBasicAuth(headerName string) {
+	name, value, _ := strings.Cut(headerName, ":")
+	if !strings.EqualFold(strings.TrimSpace(name), "Authorization") {
+		return
+	}
+	user, _, ok := parseBasicAuth(value)
+	if !ok {
+		return
+	}
+
+	note := fmt.Sprintf("likely the auth gate (Basic auth for user %q, password redacted)", user)
+	if m.logHeader(headerName) {
+		m.logf("Header is %s\n", note)
+	}
+	for i, d := range m.decisions {
+		if d.Kind == KindHeader && d.Name == headerName && !d.Removed {
+			m.decisions[i].Reason += "; " + note
+		}
+	}
+}
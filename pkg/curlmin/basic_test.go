@@ -0,0 +1,53 @@
+package curlmin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseBasicAuth(t *testing.T) {
+	user, password, ok := parseBasicAuth(" basic " + strings.TrimPrefix(basicAuthValue("alice", "s3cr:et"), "Basic "))
+	if !ok || user != "alice" || password != "s3cr:et" {
+		t.Errorf("parseBasicAuth() = %q, %q, %v, want alice, s3cr:et, true", user, password, ok)
+	}
+	for _, value := range []string{"Bearer xyz789", "Basic not-base64!", "Basic " + "YWxpY2U="} {
+		if _, _, ok := parseBasicAuth(value); ok {
+			t.Errorf("parseBasicAuth(%q) succeeded, want failure", value)
+		}
+	}
+}
+
+func TestMinimizeBasicAuth(t *testing.T) {
+	// The server checks the user but never the password
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, ok := r.BasicAuth(); ok && user == "alice" {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "Unauthorized")
+		}
+	}))
+	defer server.Close()
+
+	m := New(Options{MinimizeHeaders: true, MinimizeValues: true})
+	minimizedCmd, err := m.MinimizeCurlCommand(fmt.Sprintf(`curl -H 'Authorization: %s' -H 'Accept: */*' '%s/'`, basicAuthValue("alice", "hunter2"), server.URL))
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if want := fmt.Sprintf(`curl -H 'Authorization: %s' '%s/'`, basicAuthValue("alice", ""), server.URL); strings.TrimSpace(minimizedCmd) != want {
+		t.Errorf("Minimized command is %q, want %q", minimizedCmd, want)
+	}
+
+	// The kept header is flagged as the auth gate without revealing the password
+	for _, d := range m.Decisions() {
+		if d.Kind != KindHeader || d.Removed {
+			continue
+		}
+		if !strings.Contains(d.Reason, `Basic auth for user "alice"`) || strings.Contains(d.Reason, "hunter2") {
+			t.Errorf("Authorization header decision reason is %q", d.Reason)
+		}
+	}
+}
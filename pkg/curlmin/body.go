@@ -0,0 +1,633 @@
+package curlmin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Request body formats minimizeBody understands
+const (
+	bodyJSON = "json"
+	bodyForm = "form"
+)
+
+// requestContentType returns the lowercased value of the command's
+// Content-Type header, or "" if it doesn't set one
+func (c *CurlCommand) requestContentType() string {
+	contentType := ""
+	for _, i := range c.FindHeaderArgs() {
+		name, value, _ := strings.Cut(c.literalArg(i+1), ":")
+		if strings.EqualFold(strings.TrimSpace(name), "content-type") {
+			contentType = strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+	return contentType
+}
+
+// bodyFormat returns the format of a request body: JSON or form-urlencoded
+// per the Content-Type header (or --json), otherwise sniffed from the body
+// itself. It returns "" for bodies in any other format.
+func (c *CurlCommand) bodyFormat(dataIndex int, body string) string {
+	contentType := c.requestContentType()
+	switch {
+	case c.literalArg(dataIndex) == "--json" || strings.Contains(contentType, "json"):
+		return bodyJSON
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		return bodyForm
+	case contentType != "":
+		return ""
+	case strings.HasPrefix(strings.TrimSpace(body), "{"):
+		return bodyJSON
+	case strings.Contains(body, "="):
+		return bodyForm
+	}
+	return ""
+}
+
+// jsonFieldPaths lists the paths of the keys of a JSON object and of the
+// values nested in it, each before the paths nested inside it. Array
+// elements appear as "[i]" path segments.
+func jsonFieldPaths(obj *jsonObject, prefix []string) [][]string {
+	var paths [][]string
+	for _, key := range obj.keys {
+		path := append(slices.Clone(prefix), key)
+		paths = append(paths, path)
+		paths = append(paths, jsonValuePaths(obj.values[key], path)...)
+	}
+	return paths
+}
+
+// jsonValuePaths lists the paths nested in a JSON value: the keys of an
+// object, or the elements of an array
+func jsonValuePaths(value json.RawMessage, prefix []string) [][]string {
+	if obj, err := parseJSONObject(string(value)); err == nil {
+		return jsonFieldPaths(obj, prefix)
+	}
+
+	elements, ok := parseJSONArray(value)
+	if !ok {
+		return nil
+	}
+	var paths [][]string
+	for i, element := range elements {
+		path := append(slices.Clone(prefix), fmt.Sprintf("[%d]", i))
+		paths = append(paths, path)
+		paths = append(paths, jsonValuePaths(element, path)...)
+	}
+	return paths
+}
+
+// jsonPathName names a path for decisions and logging, e.g. "items[0].id"
+func jsonPathName(path []string) string {
+	var name strings.Builder
+	for i, segment := range path {
+		if i > 0 && !isJSONIndex(segment) {
+			name.WriteByte('.')
+		}
+		name.WriteString(segment)
+	}
+	return name.String()
+}
+
+// isJSONIndex reports whether a path segment is an array index
+func isJSONIndex(segment string) bool {
+	return strings.HasPrefix(segment, "[") && strings.HasSuffix(segment, "]")
+}
+
+// parseJSONArray decodes a JSON array into its elements, compacted
+func parseJSONArray(value json.RawMessage) ([]json.RawMessage, bool) {
+	var elements []json.RawMessage
+	if !strings.HasPrefix(strings.TrimSpace(string(value)), "[") || json.Unmarshal(value, &elements) != nil {
+		return nil, false
+	}
+	for i, element := range elements {
+		var buf bytes.Buffer
+		if json.Compact(&buf, element) == nil {
+			elements[i] = buf.Bytes()
+		}
+	}
+	return elements, true
+}
+
+// encodeJSONArray encodes array elements, keeping each one as it is
+func encodeJSONArray(elements []json.RawMessage) json.RawMessage {
+	parts := make([]string, len(elements))
+	for i, element := range elements {
+		parts[i] = string(element)
+	}
+	return json.RawMessage("[" + strings.Join(parts, ",") + "]")
+}
+
+// jsonIndex returns the element an index segment refers to in an array
+func jsonIndex(value json.RawMessage, segment string) ([]json.RawMessage, int, bool) {
+	elements, ok := parseJSONArray(value)
+	i, err := strconv.Atoi(strings.Trim(segment, "[]"))
+	if !ok || err != nil || i < 0 || i >= len(elements) {
+		return nil, 0, false
+	}
+	return elements, i, true
+}
+
+// jsonValueAt returns the value at path in a JSON value
+func jsonValueAt(value json.RawMessage, path []string) (json.RawMessage, bool) {
+	for _, segment := range path {
+		if isJSONIndex(segment) {
+			elements, i, ok := jsonIndex(value, segment)
+			if !ok {
+				return nil, false
+			}
+			value = elements[i]
+			continue
+		}
+
+		obj, err := parseJSONObject(string(value))
+		if err != nil {
+			return nil, false
+		}
+		var ok bool
+		if value, ok = obj.values[segment]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// editJSON returns a copy of a JSON value with the value at path replaced by
+// replacement, or removed if replacement is nil
+func editJSON(value json.RawMessage, path []string, replacement json.RawMessage) (json.RawMessage, error) {
+	if isJSONIndex(path[0]) {
+		elements, i, ok := jsonIndex(value, path[0])
+		if !ok {
+			return nil, fmt.Errorf("no array element %s", path[0])
+		}
+		switch {
+		case len(path) > 1:
+			edited, err := editJSON(elements[i], path[1:], replacement)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = edited
+		case replacement == nil:
+			elements = slices.Delete(elements, i, i+1)
+		default:
+			elements[i] = replacement
+		}
+		return encodeJSONArray(elements), nil
+	}
+
+	obj, err := parseJSONObject(string(value))
+	if err != nil {
+		return nil, err
+	}
+	child, ok := obj.values[path[0]]
+	if !ok {
+		return nil, fmt.Errorf("no key %q", path[0])
+	}
+	switch {
+	case len(path) > 1:
+		if child, err = editJSON(child, path[1:], replacement); err != nil {
+			return nil, err
+		}
+		obj = obj.with(path[0], child)
+	case replacement == nil:
+		obj = obj.without(path[0])
+	default:
+		obj = obj.with(path[0], replacement)
+	}
+	return json.RawMessage(obj.String()), nil
+}
+
+// removeJSONField returns a copy of a JSON object without the key at path
+func removeJSONField(obj *jsonObject, path []string) (*jsonObject, error) {
+	edited, err := editJSON(json.RawMessage(obj.String()), path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseJSONObject(string(edited))
+}
+
+// bodyFields returns the names of the fields in the command's request body:
+// dotted key paths for JSON, plus the fields of a GraphQL query (e.g.
+// query.user.name), and names for form-urlencoded bodies
+func (c *CurlCommand) bodyFields() []string {
+	if formIndices := c.FindFormArgs(); len(formIndices) > 0 {
+		var fields []string
+		for _, i := range formIndices {
+			fields = append(fields, c.formPartName(i))
+		}
+		return fields
+	}
+
+	dataIndex, err := c.FindDataArg()
+	if err != nil {
+		var fields []string
+		for _, field := range c.formArgFields() {
+			fields = append(fields, field.name)
+		}
+		return fields
+	}
+	body := c.literalArg(dataIndex + 1)
+
+	var fields []string
+	switch c.bodyFormat(dataIndex, body) {
+	case bodyJSON:
+		obj, err := parseJSONObject(body)
+		if err != nil {
+			return nil
+		}
+		for _, path := range jsonFieldPaths(obj, nil) {
+			fields = append(fields, jsonPathName(path))
+		}
+		fields = append(fields, graphQLFieldNames(obj)...)
+	case bodyForm:
+		for _, pair := range strings.Split(body, "&") {
+			if name, _, _ := strings.Cut(pair, "="); name != "" {
+				fields = append(fields, name)
+			}
+		}
+	}
+	return fields
+}
+
+// minimizeBody tries removing the fields of a JSON or form-urlencoded request
+// body one at a time. Bodies in other formats, or that don't parse, are left
+// untouched.
+func (m *Minimizer) minimizeBody(curl *CurlCommand, baselineResp Response) {
+	// curl doesn't mix -F with -d, so a multipart form is the whole body
+	if len(curl.FindFormArgs()) > 0 {
+		m.minimizeFormParts(curl, baselineResp)
+		return
+	}
+
+	dataIndex, err := curl.FindDataArg()
+	if err != nil {
+		// A form split across several arguments is minimized field by field
+		if len(curl.formArgFields()) > 0 {
+			m.minimizeFormArgs(curl, baselineResp)
+			return
+		}
+		if m.options.Verbose {
+			m.logf("Skipping request body: %v\n", err)
+		}
+		return
+	}
+
+	// GraphQL bodies are minimized by their own pass
+	if _, _, ok := curl.findGraphQLBody(); ok && m.options.MinimizeGraphQL {
+		return
+	}
+
+	body := curl.literalArg(dataIndex + 1)
+	switch curl.bodyFormat(dataIndex, body) {
+	case bodyJSON:
+		m.minimizeJSONBody(curl, dataIndex, baselineResp)
+	case bodyForm:
+		m.minimizeFormBody(curl, dataIndex, baselineResp)
+	default:
+		if m.options.Verbose {
+			m.logf("Skipping request body: not JSON or form-urlencoded\n")
+		}
+	}
+}
+
+// minimizeJSONBody tries removing the keys of a JSON object body, including
+// nested ones, restarting after each removal. The elements of the arrays it
+// keeps are reduced by reduceJSONArray.
+func (m *Minimizer) minimizeJSONBody(curl *CurlCommand, dataIndex int, baselineResp Response) {
+	needed := make(map[string]bool)
+	reduced := make(map[string]bool)
+	for {
+		body := curl.literalArg(dataIndex + 1)
+		obj, err := parseJSONObject(body)
+		if err != nil {
+			if m.options.Verbose {
+				m.logf("Skipping request body: can't parse JSON: %v\n", err)
+			}
+			return
+		}
+
+		foundRemovable := false
+		for _, path := range jsonFieldPaths(obj, nil) {
+			name := jsonPathName(path)
+
+			// Array elements have no names of their own, so they're only
+			// removed by reducing their array
+			if !needed[name] && !isJSONIndex(path[len(path)-1]) {
+				newObj, err := removeJSONField(obj, path)
+				if err != nil {
+					continue
+				}
+				newBody := newObj.String()
+
+				canRemove, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+					return c.SetDataArg(dataIndex, newBody)
+				})
+				if err == nil && canRemove {
+					if m.options.Verbose {
+						m.logf("Body field not needed: %s\n", name)
+					}
+					m.decide(KindBodyField, name, true)
+					// Fields nested in a removed one go with it
+					for _, nested := range jsonFieldPaths(obj, nil) {
+						nestedName := jsonPathName(nested)
+						if strings.HasPrefix(nestedName, name) && isJSONSubpath(nestedName[len(name):]) && !isJSONIndex(nested[len(nested)-1]) {
+							m.decide(KindBodyField, nestedName, true)
+						}
+					}
+					curl.SetDataArg(dataIndex, newBody)
+					foundRemovable = true
+					break
+				}
+
+				if m.options.Verbose {
+					m.logNeeded("Body field", name)
+				}
+				m.decide(KindBodyField, name, false)
+				needed[name] = true
+				if m.stopAtRequired("body field", name) {
+					return
+				}
+			}
+
+			// Reduce a kept array once, before its elements' fields are tried
+			if value, ok := jsonValueAt(json.RawMessage(body), path); ok && !reduced[name] {
+				if _, isArray := parseJSONArray(value); isArray {
+					reduced[name] = true
+					if m.reduceJSONArray(curl, dataIndex, path, baselineResp) {
+						foundRemovable = true
+						break
+					}
+				}
+			}
+		}
+
+		if !foundRemovable {
+			return
+		}
+	}
+}
+
+// isJSONSubpath reports whether the rest of a path name after a prefix
+// descends into it, as in ".id" or "[0]"
+func isJSONSubpath(rest string) bool {
+	return strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, "[")
+}
+
+// reduceJSONArray removes the elements of the array at path that the
+// response doesn't depend on, delta-debugging style: it tries removing
+// chunks of elements, halving the chunk size down to single elements. It
+// reports whether any element was removed.
+func (m *Minimizer) reduceJSONArray(curl *CurlCommand, dataIndex int, path []string, baselineResp Response) bool {
+	name := jsonPathName(path)
+	value, _ := jsonValueAt(json.RawMessage(curl.literalArg(dataIndex+1)), path)
+	elements, _ := parseJSONArray(value)
+	original := len(elements)
+	// Removed elements are named by their original index
+	indices := make([]int, len(elements))
+	for i := range indices {
+		indices[i] = i
+	}
+
+	for chunk := len(elements); chunk >= 1 && len(elements) > 0; chunk /= 2 {
+		for start := 0; start < len(elements); {
+			end := min(start+chunk, len(elements))
+			remaining := slices.Delete(slices.Clone(elements), start, end)
+
+			newBody, err := editJSON(json.RawMessage(curl.literalArg(dataIndex+1)), path, encodeJSONArray(remaining))
+			if err != nil {
+				return len(elements) < original
+			}
+			canRemove, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+				return c.SetDataArg(dataIndex, string(newBody))
+			})
+			if err == nil && canRemove {
+				for _, index := range indices[start:end] {
+					m.decide(KindBodyField, jsonPathName(append(slices.Clone(path), fmt.Sprintf("[%d]", index))), true)
+				}
+				curl.SetDataArg(dataIndex, string(newBody))
+				elements = remaining
+				indices = slices.Delete(indices, start, end)
+				continue
+			}
+			start = end
+		}
+		chunk = min(chunk, len(elements))
+	}
+
+	if m.options.Verbose && original > 0 {
+		m.logf("Body array %s: kept %d of %d elements\n", name, len(elements), original)
+	}
+	return len(elements) < original
+}
+
+// minimizeFormBody tries removing the name=value pairs of a form-urlencoded
+// body one at a time
+func (m *Minimizer) minimizeFormBody(curl *CurlCommand, dataIndex int, baselineResp Response) {
+	pairs := strings.Split(curl.literalArg(dataIndex+1), "&")
+	for i := 0; i < len(pairs); i++ {
+		name, _, _ := strings.Cut(pairs[i], "=")
+		if name == "" {
+			continue
+		}
+
+		remaining := slices.Delete(slices.Clone(pairs), i, i+1)
+		newBody := strings.Join(remaining, "&")
+		canRemove, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+			return c.SetDataArg(dataIndex, newBody)
+		})
+		if err == nil && canRemove {
+			if m.options.Verbose {
+				m.logf("Body field not needed: %s\n", name)
+			}
+			// Another pair with the same name may remain
+			if !slices.ContainsFunc(remaining, func(pair string) bool { return strings.HasPrefix(pair, name+"=") || pair == name }) {
+				m.decide(KindBodyField, name, true)
+			}
+			curl.SetDataArg(dataIndex, newBody)
+			pairs = remaining
+			i--
+			continue
+		}
+
+		if m.options.Verbose {
+			m.logNeeded("Body field", name)
+		}
+		m.decide(KindBodyField, name, false)
+		if m.stopAtRequired("body field", name) {
+			return
+		}
+	}
+}
+
+// formArgField is one field of a form-urlencoded body that curl joins from
+// several arguments (e.g. -d a=1 -d b=2) or URL-encodes itself
+// (--data-urlencode)
+type formArgField struct {
+	name string
+	// index is the body argument's flag index, and pair the field's position
+	// among the &-separated pairs of its value
+	index int
+	pair  int
+}
+
+// formArgFields lists the fields of a form body that isn't a single plain
+// body argument. Arguments read from a file are left out, since their
+// fields can't be edited, and so is any body sent as JSON.
+func (c *CurlCommand) formArgFields() []formArgField {
+	dataIndices := c.FindDataArgs()
+	contentType := c.requestContentType()
+	if contentType != "" && !strings.Contains(contentType, "x-www-form-urlencoded") {
+		return nil
+	}
+
+	var fields []formArgField
+	for _, index := range dataIndices {
+		flag, value := c.literalArg(index), c.literalArg(index+1)
+		switch {
+		case flag == "--json":
+			return nil
+		case flag == "--data-urlencode" && value != "":
+			// curl encodes the whole value as one field: content, =content,
+			// name=content, @file, or name@file
+			name, _, ok := strings.Cut(value, "=")
+			if fileName, _, isFile := strings.Cut(value, "@"); isFile && (!ok || len(fileName) < len(name)) {
+				name = fileName
+			}
+			if name == "" {
+				name = value
+			}
+			fields = append(fields, formArgField{name: name, index: index})
+		case flag == "--data-urlencode" || flag != "--data-raw" && strings.HasPrefix(value, "@"):
+			continue
+		default:
+			for pair, field := range strings.Split(value, "&") {
+				name, _, _ := strings.Cut(field, "=")
+				if name != "" {
+					fields = append(fields, formArgField{name: name, index: index, pair: pair})
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// remove drops the field from its argument, or drops the argument if the
+// field is all it carries. The last body argument is emptied instead, so
+// the request stays a POST.
+func (f formArgField) remove(c *CurlCommand) error {
+	value := c.literalArg(f.index + 1)
+	pairs := []string{value}
+	if c.literalArg(f.index) != "--data-urlencode" {
+		pairs = strings.Split(value, "&")
+	}
+	if f.pair >= len(pairs) {
+		return fmt.Errorf("no field %d in body argument %d", f.pair, f.index)
+	}
+
+	remaining := slices.Delete(pairs, f.pair, f.pair+1)
+	switch {
+	case len(remaining) > 0:
+		return c.SetDataArg(f.index, strings.Join(remaining, "&"))
+	case len(c.FindDataArgs()) == 1:
+		return c.SetDataArg(f.index, "")
+	default:
+		c.RemoveArg(f.index)
+		c.updateContentLength()
+		return nil
+	}
+}
+
+// minimizeFormArgs tries removing the fields of a form body spread across
+// several body arguments one at a time
+func (m *Minimizer) minimizeFormArgs(curl *CurlCommand, baselineResp Response) {
+	for i := 0; ; i++ {
+		fields := curl.formArgFields()
+		if i >= len(fields) {
+			return
+		}
+		field := fields[i]
+
+		canRemove, err := m.testModification(curl, baselineResp, field.remove)
+		if err == nil && canRemove {
+			if m.options.Verbose {
+				m.logf("Body field not needed: %s\n", field.name)
+			}
+			field.remove(curl)
+			// Another field with the same name may remain
+			if !slices.ContainsFunc(curl.formArgFields(), func(f formArgField) bool { return f.name == field.name }) {
+				m.decide(KindBodyField, field.name, true)
+			}
+			i--
+			continue
+		}
+
+		if m.options.Verbose {
+			m.logNeeded("Body field", field.name)
+		}
+		m.decide(KindBodyField, field.name, false)
+		if m.stopAtRequired("body field", field.name) {
+			return
+		}
+	}
+}
+
+// formPartName returns the name of the multipart form part set by the -F
+// argument at index, e.g. "file" for -F 'file=@photo.png;type=image/png'
+func (c *CurlCommand) formPartName(index int) string {
+	name, _, _ := strings.Cut(c.literalArg(index+1), "=")
+	return name
+}
+
+// minimizeFormParts tries removing the parts of a multipart form (-F) one at
+// a time, restarting after each removal. The last part is always kept, since
+// without any the request would no longer be a multipart POST.
+func (m *Minimizer) minimizeFormParts(curl *CurlCommand, baselineResp Response) {
+	for {
+		formIndices := curl.FindFormArgs()
+		if len(formIndices) < 2 {
+			return
+		}
+
+		tests := m.newCandidateTests(curl, baselineResp, candidateFuncs(formIndices, func(formIndex int) func(*CurlCommand) error {
+			return func(c *CurlCommand) error {
+				c.RemoveArg(formIndex)
+				return nil
+			}
+		}))
+
+		foundRemovable := false
+		for k, formIndex := range formIndices {
+			name := curl.formPartName(formIndex)
+
+			canRemove, err := tests.test(k)
+			if err == nil && canRemove {
+				if m.options.Verbose {
+					m.logf("Form part not needed: %s\n", name)
+				}
+				curl.RemoveArg(formIndex)
+				// Another part with the same name may remain
+				if !slices.ContainsFunc(curl.FindFormArgs(), func(i int) bool { return curl.formPartName(i) == name }) {
+					m.decide(KindBodyField, name, true)
+				}
+				foundRemovable = true
+				break
+			}
+
+			if m.options.Verbose {
+				m.logNeeded("Form part", name)
+			}
+			m.decide(KindBodyField, name, false)
+			if m.stopAtRequired("form part", name) {
+				return
+			}
+		}
+
+		if !foundRemovable {
+			return
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package curlmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFindDataArg(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want int
+	}{
+		{`curl -X POST -d 'a=1' http://example.com/`, 3},
+		{`curl --data-raw '@literal' http://example.com/`, 1},
+		{`curl -d '@body.json' http://example.com/`, -1},
+		{`curl -d 'a=1' -d 'b=2' http://example.com/`, -1},
+		{`curl --data-urlencode 'a=1 2' http://example.com/`, -1},
+		{`curl http://example.com/`, -1},
+	}
+	for _, tt := range tests {
+		curl, err := ParseCurlCommand(tt.cmd)
+		if err != nil {
+			t.Fatalf("Failed to parse curl command: %v", err)
+		}
+		got, err := curl.FindDataArg()
+		if got != tt.want || (err == nil) != (tt.want >= 0) {
+			t.Errorf("FindDataArg() for %q = %d, %v, want %d", tt.cmd, got, err, tt.want)
+		}
+	}
+}
+
+func TestMinimizeBody(t *testing.T) {
+	// The server needs user.id and action, whether sent as JSON or a form
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var userID, action string
+		if strings.Contains(r.Header.Get("Content-Type"), "json") {
+			var body struct {
+				User struct {
+					ID string `json:"id"`
+				} `json:"user"`
+				Action string `json:"action"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			userID, action = body.User.ID, body.Action
+		} else {
+			r.ParseForm()
+			userID, action = r.PostForm.Get("user_id"), r.PostForm.Get("action")
+		}
+		if userID == "42" && action == "save" {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Bad request")
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{
+			`-H 'Content-Type: application/json' -d '{"user": {"id": "42", "name": "alice"}, "action": "save", "client": {"v": 2}}'`,
+			`-H 'Content-Type: application/json' -d '{"user":{"id":"42"},"action":"save"}'`,
+		},
+		{
+			`-d 'csrf=abc&user_id=42&action=save&ts=1623456789'`,
+			`-d 'user_id=42&action=save'`,
+		},
+		// An explicit Content-Length follows the body as it shrinks
+		{
+			`-H 'Content-Length: 45' -d 'csrf=abc&user_id=42&action=save&ts=1623456789'`,
+			`-H 'Content-Length: 22' -d 'user_id=42&action=save'`,
+		},
+		{
+			`-H 'Content-Type: application/json' -H 'Content-Length: 60' -d '{"user": {"id": "42"}, "action": "save", "tags": ["a", "b"]}'`,
+			`-H 'Content-Type: application/json' -H 'Content-Length: 37' -d '{"user":{"id": "42"},"action":"save"}'`,
+		},
+		// Bodies that don't parse are left alone
+		{
+			`-H 'Content-Type: application/json' -d '{"user": not json'`,
+			`-H 'Content-Type: application/json' -d '{"user": not json'`,
+		},
+	}
+
+	for _, tt := range tests {
+		minimizedCmd, err := New(Options{MinimizeBody: true, Reproduce: true}).MinimizeCurlCommand(fmt.Sprintf(`curl %s '%s/'`, tt.body, server.URL))
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		if want := fmt.Sprintf(`curl %s '%s/'`, tt.want, server.URL); strings.TrimSpace(minimizedCmd) != want {
+			t.Errorf("Minimized command is %q, want %q", minimizedCmd, want)
+		}
+	}
+}
+
+func TestMinimizeJSONArrays(t *testing.T) {
+	// The server needs an item with id 7 and the tags of the order
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Items []struct {
+				ID int `json:"id"`
+			} `json:"items"`
+			Order struct {
+				Tags []string `json:"tags"`
+			} `json:"order"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		found := false
+		for _, item := range body.Items {
+			found = found || item.ID == 7
+		}
+		if found && slices.Contains(body.Order.Tags, "gift") {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Bad request")
+		}
+	}))
+	defer server.Close()
+
+	body := `{"items": [{"id": 1, "qty": 2}, {"id": 3}, {"id": 7, "qty": 1, "note": "x"}, {"id": 9}, {"id": 11}], "order": {"tags": ["rush", "gift", "fragile"], "ref": "abc"}}`
+	m := New(Options{MinimizeBody: true, Reproduce: true})
+	minimizedCmd, err := m.MinimizeCurlCommand(fmt.Sprintf(`curl -H 'Content-Type: application/json' -d '%s' '%s/'`, body, server.URL))
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	want := fmt.Sprintf(`curl -H 'Content-Type: application/json' -d '{"items":[{"id":7}],"order":{"tags":["gift"]}}' '%s/'`, server.URL)
+	if got := strings.TrimSpace(minimizedCmd); got != want {
+		t.Errorf("Minimized command is %q, want %q", got, want)
+	}
+
+	// Fields inside array elements are named by index
+	var kept []string
+	for _, d := range m.Decisions() {
+		if !d.Removed {
+			kept = append(kept, d.Name)
+		}
+	}
+	if want := []string{"items", "items[0].id", "order", "order.tags"}; !slices.Equal(kept, want) {
+		t.Errorf("Kept body fields are %v, want %v", kept, want)
+	}
+
+	// Removed array elements are named by their original index
+	var removed []string
+	for _, d := range m.Decisions() {
+		if d.Removed && strings.HasSuffix(d.Name, "]") {
+			removed = append(removed, d.Name)
+		}
+	}
+	slices.Sort(removed)
+	if want := []string{"items[0]", "items[1]", "items[3]", "items[4]", "order.tags[0]", "order.tags[2]"}; !slices.Equal(removed, want) {
+		t.Errorf("Removed array elements are %v, want %v", removed, want)
+	}
+
+	// An explicit Content-Length follows the body as elements are dropped
+	minimizedCmd, err = New(Options{MinimizeBody: true, Reproduce: true}).MinimizeCurlCommand(fmt.Sprintf(`curl -H 'Content-Type: application/json' -H 'Content-Length: %d' -d '%s' '%s/'`, len(body), body, server.URL))
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	want = fmt.Sprintf(`curl -H 'Content-Type: application/json' -H 'Content-Length: 46' -d '{"items":[{"id":7}],"order":{"tags":["gift"]}}' '%s/'`, server.URL)
+	if got := strings.TrimSpace(minimizedCmd); got != want {
+		t.Errorf("Minimized command is %q, want %q", got, want)
+	}
+}
+
+func TestMinimizeFormArgs(t *testing.T) {
+	// The server needs user_id and action, however curl joined the form
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Method == http.MethodPost && r.PostForm.Get("user_id") == "42" && r.PostForm.Get("action") == "save" {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Bad request")
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{
+			`-d 'csrf=abc&user_id=42' -d 'ts=1623456789' --data-urlencode 'action=save' --data-urlencode 'note=hello world'`,
+			`-d 'user_id=42' --data-urlencode 'action=save'`,
+		},
+		{
+			`--data-urlencode 'user_id=42' --data-urlencode 'action=save' --data-urlencode 'note=hello world'`,
+			`--data-urlencode 'user_id=42' --data-urlencode 'action=save'`,
+		},
+	}
+
+	for _, tt := range tests {
+		m := New(Options{MinimizeBody: true, Reproduce: true})
+		minimizedCmd, err := m.MinimizeCurlCommand(fmt.Sprintf(`curl %s '%s/'`, tt.body, server.URL))
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		if want := fmt.Sprintf(`curl %s '%s/'`, tt.want, server.URL); strings.TrimSpace(minimizedCmd) != want {
+			t.Errorf("Minimized command is %q, want %q", minimizedCmd, want)
+		}
+	}
+
+	// The last body argument is emptied rather than removed, keeping the POST
+	curl, err := ParseCurlCommand(`curl -d 'a=1' --data-urlencode 'b=2' http://example.com/`)
+	if err != nil {
+		t.Fatalf("Failed to parse curl command: %v", err)
+	}
+	for len(curl.formArgFields()) > 0 {
+		if err := curl.formArgFields()[0].remove(curl); err != nil {
+			t.Fatalf("Failed to remove field: %v", err)
+		}
+	}
+	if got, _ := curl.ToString(); strings.TrimSpace(got) != `curl --data-urlencode '' http://example.com/` {
+		t.Errorf("Command without fields is %q", got)
+	}
+}
+
+func TestMinimizeFormParts(t *testing.T) {
+	// The server needs the token part and an uploaded file
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Bad request")
+			return
+		}
+		if _, _, err := r.FormFile("file"); err != nil || r.FormValue("token") != "abc" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Bad request")
+			return
+		}
+		fmt.Fprint(w, "Uploaded")
+	}))
+	defer server.Close()
+
+	upload := filepath.Join(t.TempDir(), "photo.txt")
+	if err := os.WriteFile(upload, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("Failed to write upload: %v", err)
+	}
+
+	curlCmd := fmt.Sprintf(`curl -F 'token=abc' -F 'title=Holiday' -F 'file=@%s;type=text/plain' --form-string 'tags=a,b' '%s/'`, upload, server.URL)
+	m := New(Options{MinimizeBody: true})
+	minimizedCmd, err := m.MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	want := fmt.Sprintf(`curl -F 'token=abc' -F 'file=@%s;type=text/plain' '%s/'`, upload, server.URL)
+	if got := strings.TrimSpace(minimizedCmd); got != want {
+		t.Errorf("Minimized command is %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package curlmin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// errBudgetExhausted fails the requests a run would send after using up
+// MaxRequests or MaxDuration
+var errBudgetExhausted = errors.New("request budget exhausted")
+
+// untestedReason is the reason given for elements kept because the budget ran
+// out before they could be tested
+const untestedReason = "budget exhausted, not tested"
+
+// withBudget returns the context for a run, which ends once MaxDuration has
+// passed (with errBudgetExhausted as its cause), and a function to release it
+func (m *Minimizer) withBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.options.MaxDuration <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, m.options.MaxDuration, errBudgetExhausted)
+}
+
+// spendRequest counts a request about to be sent, failing with
+// errBudgetExhausted once MaxRequests have been sent
+func (m *Minimizer) spendRequest() error {
+	if n := m.requests.Add(1); m.options.MaxRequests > 0 && n > int64(m.options.MaxRequests) {
+		m.requests.Add(-1)
+		m.budgetExhausted("sent %d requests", m.options.MaxRequests)
+		return errBudgetExhausted
+	}
+	return nil
+}
+
+// outOfBudget reports whether a test failed because the run's budget ran out
+func (m *Minimizer) outOfBudget(err error) bool {
+	return errors.Is(err, errBudgetExhausted) || err != nil && context.Cause(m.context()) == errBudgetExhausted
+}
+
+// logNeeded logs that an element is kept, or that it went untested because
+// the budget ran out
+func (m *Minimizer) logNeeded(what, name string) {
+	if m.untested {
+		m.logf("%s not tested, budget exhausted: %s\n", what, name)
+		return
+	}
+	m.logf("%s needed: %s\n", what, name)
+}
+
+// budgetExhausted marks the run as partial, warning about it the first time
+func (m *Minimizer) budgetExhausted(format string, args ...any) {
+	if m.partial.CompareAndSwap(false, true) {
+		m.warn(fmt.Sprintf("stopped testing after the run "+format+"; the command is only partially minimized", args...))
+	}
+}
+
+// Partial reports whether the last run ran out of its MaxRequests or
+// MaxDuration budget, in which case the command it returned is the best
+// found so far rather than a fully minimized one
+func (m *Minimizer) Partial() bool {
+	return m.partial.Load()
+}
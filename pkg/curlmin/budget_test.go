@@ -0,0 +1,83 @@
+package curlmin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("X-Slow") != "" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	var headers []string
+	for i := range 6 {
+		headers = append(headers, fmt.Sprintf("-H 'X-Noise-%d: %d'", i, i))
+	}
+	curlCmd := fmt.Sprintf(`curl %s -H 'X-Slow: 1' '%s/'`, strings.Join(headers, " "), server.URL)
+
+	// The baseline and two removals fit in the request budget
+	requests.Store(0)
+	m := New(Options{MinimizeHeaders: true, MaxRequests: 3})
+	minimizedCmd, report, err := m.MinimizeCurlCommandWithReport(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("Sent %d requests with MaxRequests 3", n)
+	}
+	if !m.Partial() || !report.Partial || report.Requests != 3 {
+		t.Errorf("Partial() = %v, report = %+v; want a partial report of 3 requests", m.Partial(), report)
+	}
+	if got := strings.Count(minimizedCmd, "-H"); got != 5 {
+		t.Errorf("Minimized command %q has %d headers, want 5", minimizedCmd, got)
+	}
+	if len(m.Warnings()) == 0 {
+		t.Error("No warning about the partial result")
+	}
+	untested := 0
+	for _, d := range m.Decisions() {
+		if !d.Removed && d.Reason != untestedReason {
+			t.Errorf("Untested %s %q kept with reason %q, want %q", d.Kind, d.Name, d.Reason, untestedReason)
+		}
+		if !d.Removed {
+			untested++
+		}
+	}
+	if untested != 5 {
+		t.Errorf("%d headers kept as untested, want 5", untested)
+	}
+
+	// A run that finishes within its budget isn't partial
+	m = New(Options{MinimizeHeaders: true, MaxRequests: 100, MaxDuration: time.Minute})
+	if _, err := m.MinimizeCurlCommand(curlCmd); err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if m.Partial() {
+		t.Error("Partial() = true for a run within its budget")
+	}
+
+	// Every request with X-Slow takes 100ms, so a 250ms run can't finish
+	m = New(Options{MinimizeHeaders: true, MaxDuration: 250 * time.Millisecond})
+	minimizedCmd, err = m.MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if !m.Partial() {
+		t.Error("Partial() = false for a run past its MaxDuration")
+	}
+	if !strings.Contains(minimizedCmd, "X-Slow") {
+		t.Errorf("Minimized command %q lost an untested header", minimizedCmd)
+	}
+}
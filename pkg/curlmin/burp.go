@@ -0,0 +1,84 @@
+package curlmin
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// BurpItem is a request saved by Burp Suite's "Save items" export
+type BurpItem struct {
+	URL      string
+	Method   string
+	Protocol string
+	Request  []byte
+}
+
+// burpExport is the XML layout of a Burp "Save items" export
+type burpExport struct {
+	Items []struct {
+		URL      string `xml:"url"`
+		Method   string `xml:"method"`
+		Protocol string `xml:"protocol"`
+		Request  struct {
+			Base64 bool   `xml:"base64,attr"`
+			Data   string `xml:",chardata"`
+		} `xml:"request"`
+	} `xml:"item"`
+}
+
+// ParseBurpItems reads the requests in a Burp Suite XML export, decoding
+// requests that were saved base64-encoded
+func ParseBurpItems(data []byte) ([]BurpItem, error) {
+	var export burpExport
+	if err := xml.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("failed to parse Burp export: %w", err)
+	}
+
+	items := make([]BurpItem, 0, len(export.Items))
+	for i, item := range export.Items {
+		request := []byte(item.Request.Data)
+		if item.Request.Base64 {
+			var err error
+			request, err = base64.StdEncoding.DecodeString(strings.TrimSpace(item.Request.Data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode request %d: %w", i+1, err)
+			}
+		}
+		items = append(items, BurpItem{
+			URL:      strings.TrimSpace(item.URL),
+			Method:   strings.TrimSpace(item.Method),
+			Protocol: strings.TrimSpace(item.Protocol),
+			Request:  request,
+		})
+	}
+	return items, nil
+}
+
+// SelectBurpItem picks a request from a Burp export: the one at index
+// (counting from 1) if index is set, otherwise the first one whose URL
+// contains urlFilter
+func SelectBurpItem(items []BurpItem, index int, urlFilter string) (BurpItem, error) {
+	if index > 0 {
+		if index > len(items) {
+			return BurpItem{}, fmt.Errorf("no request %d in Burp export with %d requests", index, len(items))
+		}
+		return items[index-1], nil
+	}
+
+	for _, item := range items {
+		if strings.Contains(item.URL, urlFilter) {
+			return item, nil
+		}
+	}
+	if urlFilter != "" {
+		return BurpItem{}, fmt.Errorf("no request in Burp export has a URL containing %q", urlFilter)
+	}
+	return BurpItem{}, fmt.Errorf("no requests in Burp export")
+}
+
+// ToCurl converts the saved request into a curl command
+func (item BurpItem) ToCurl() (string, error) {
+	return RawRequestToCurl(item.Request, item.Protocol)
+}
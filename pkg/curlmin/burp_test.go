@@ -0,0 +1,74 @@
+package curlmin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+)
+
+func TestBurpItems(t *testing.T) {
+	login := "POST /login HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/x-www-form-urlencoded\r\nContent-Length: 7\r\n\r\nuser=me"
+	export := fmt.Sprintf(`<?xml version="1.0"?>
+<!DOCTYPE items [
+<!ELEMENT items (item*)>
+]>
+<items burpVersion="2024.1" exportTime="Mon Jan 01 00:00:00 UTC 2024">
+  <item>
+    <url><![CDATA[https://example.com/]]></url>
+    <method><![CDATA[GET]]></method>
+    <protocol>https</protocol>
+    <request base64="false"><![CDATA[GET / HTTP/1.1
+Host: example.com
+Accept: */*
+
+]]></request>
+  </item>
+  <item>
+    <url><![CDATA[https://example.com/login]]></url>
+    <method><![CDATA[POST]]></method>
+    <protocol>https</protocol>
+    <request base64="true"><![CDATA[%s]]></request>
+  </item>
+</items>`, base64.StdEncoding.EncodeToString([]byte(login)))
+
+	items, err := ParseBurpItems([]byte(export))
+	if err != nil {
+		t.Fatalf("ParseBurpItems() error = %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("ParseBurpItems() returned %d items, want 2", len(items))
+	}
+
+	tests := []struct {
+		name      string
+		index     int
+		urlFilter string
+		want      string
+	}{
+		{"default", 0, "", `curl -H 'Accept: */*' 'https://example.com/'`},
+		{"index", 2, "", `curl -H 'Content-Type: application/x-www-form-urlencoded' --data-raw 'user=me' 'https://example.com/login'`},
+		{"filter", 0, "/login", `curl -H 'Content-Type: application/x-www-form-urlencoded' --data-raw 'user=me' 'https://example.com/login'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := SelectBurpItem(items, tt.index, tt.urlFilter)
+			if err != nil {
+				t.Fatalf("SelectBurpItem() error = %v", err)
+			}
+			got, err := item.ToCurl()
+			if err != nil {
+				t.Fatalf("ToCurl() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ToCurl() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := SelectBurpItem(items, 3, ""); err == nil {
+		t.Errorf("Expected an error for an index past the last request")
+	}
+	if _, err := SelectBurpItem(items, 0, "/logout"); err == nil {
+		t.Errorf("Expected an error for a filter matching no request")
+	}
+}
@@ -0,0 +1,139 @@
+package curlmin
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// comparisonCacheSize bounds the number of comparison results kept
+const comparisonCacheSize = 4096
+
+// comparisonKey identifies a pair of responses by their content, along with
+// the comparison that was made (see comparisonScope)
+type comparisonKey [3][sha256.Size]byte
+
+// ComparisonCache remembers the outcome of comparing pairs of responses, so
+// responses that recur (e.g. the same 401 page for every rejected candidate)
+// aren't compared again. The oldest results are evicted first. A cache can be
+// shared by several Minimizers with Options.SharedComparisonCache.
+type ComparisonCache struct {
+	mu      sync.Mutex
+	results map[comparisonKey]bool
+	order   []comparisonKey
+}
+
+// NewComparisonCache returns an empty ComparisonCache
+func NewComparisonCache() *ComparisonCache {
+	return &ComparisonCache{results: make(map[comparisonKey]bool)}
+}
+
+// get returns the cached result of comparing a pair, if there is one
+func (c *ComparisonCache) get(key comparisonKey) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.results[key]
+	return result, ok
+}
+
+// put records the result of comparing a pair
+func (c *ComparisonCache) put(key comparisonKey, result bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.results[key]; ok {
+		return
+	}
+	if len(c.order) >= comparisonCacheSize {
+		delete(c.results, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.results[key] = result
+	c.order = append(c.order, key)
+}
+
+// comparisonScope hashes the run's comparison settings, so Minimizers that
+// share a cache but compare differently (e.g. after AutoCompare picked
+// different comparisons) don't reuse each other's results
+func (m *Minimizer) comparisonScope() [sha256.Size]byte {
+	config, _ := json.Marshal(m.ComparisonConfig())
+	return sha256.Sum256(config)
+}
+
+// responseHash hashes everything about a response that a comparison can look at
+func responseHash(resp Response) [sha256.Size]byte {
+	h := sha256.New()
+	write := func(s string) {
+		binary.Write(h, binary.LittleEndian, uint64(len(s)))
+		h.Write([]byte(s))
+	}
+
+	binary.Write(h, binary.LittleEndian, int64(resp.StatusCode))
+	binary.Write(h, binary.LittleEndian, resp.TimedOut)
+	write(resp.Status)
+	write(resp.RawHeaders)
+	write(resp.Body)
+	for _, location := range resp.RedirectChain {
+		write(location)
+	}
+	for _, name := range slices.Sorted(maps.Keys(resp.Trailer)) {
+		write(name)
+		binary.Write(h, binary.LittleEndian, uint64(len(resp.Trailer[name])))
+		for _, value := range resp.Trailer[name] {
+			write(value)
+		}
+	}
+
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// responseCache remembers the response to each command tested during a run,
+// so a candidate identical to one tested before (e.g. when a pass restarts
+// after a removal) doesn't cost another request
+type responseCache struct {
+	mu        sync.Mutex
+	responses map[string]Response
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{responses: make(map[string]Response)}
+}
+
+// get returns the response cached for a command, if there is one
+func (c *responseCache) get(curlCmd string) (Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp, ok := c.responses[curlCmd]
+	return resp, ok
+}
+
+// put records the response to a command
+func (c *responseCache) put(curlCmd string, resp Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses[curlCmd] = resp
+}
+
+// executeCandidate executes a candidate command, reusing the response to an
+// identical command tested earlier in the run when ResponseCache is set
+func (m *Minimizer) executeCandidate(curlCmd string) (Response, error) {
+	if m.responses == nil {
+		return m.executeCurlCommand(curlCmd)
+	}
+	if resp, ok := m.responses.get(curlCmd); ok {
+		if m.options.Verbose {
+			m.logf("Reusing the response to an identical command: %s\n", curlCmd)
+		}
+		return resp, nil
+	}
+
+	resp, err := m.executeCurlCommand(curlCmd)
+	if err == nil {
+		m.responses.put(curlCmd, resp)
+	}
+	return resp, err
+}
@@ -0,0 +1,105 @@
+package curlmin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestComparisonCache(t *testing.T) {
+	a := Response{StatusCode: 200, Body: "ok"}
+	b := Response{StatusCode: 200, Body: "ok", RedirectChain: []string{"/login"}}
+	if responseHash(a) == responseHash(b) {
+		t.Fatal("responseHash() ignored the redirect chain")
+	}
+
+	cache := NewComparisonCache()
+	first := comparisonKey{responseHash(a), responseHash(b)}
+	cache.put(first, true)
+	if result, ok := cache.get(first); !ok || !result {
+		t.Errorf("get() = %v, %v, want true, true", result, ok)
+	}
+
+	// Filling the cache evicts the oldest result
+	for i := 0; i < comparisonCacheSize; i++ {
+		cache.put(comparisonKey{responseHash(Response{StatusCode: i})}, false)
+	}
+	if _, ok := cache.get(first); ok {
+		t.Error("get() found a result that should have been evicted")
+	}
+	if len(cache.results) != comparisonCacheSize {
+		t.Errorf("cache holds %d results, want %d", len(cache.results), comparisonCacheSize)
+	}
+}
+
+func TestSharedComparisonCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xyz" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	// A second Minimizer finds the first one's results
+	cache := NewComparisonCache()
+	curlCmd := fmt.Sprintf(`curl -H 'X-A: 1' -H 'Authorization: Bearer xyz' '%s/'`, server.URL)
+	for _, options := range []Options{
+		{MinimizeHeaders: true, ComparisonCache: true, SharedComparisonCache: cache},
+		{MinimizeHeaders: true, ComparisonCache: true, SharedComparisonCache: cache},
+	} {
+		if _, err := New(options).MinimizeCurlCommand(curlCmd); err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+	}
+	if got := len(cache.results); got != 2 {
+		t.Errorf("Shared cache holds %d results, want 2", got)
+	}
+
+	// One that compares differently doesn't
+	options := Options{MinimizeHeaders: true, CompareStatusCode: true, ComparisonCache: true, SharedComparisonCache: cache}
+	if _, err := New(options).MinimizeCurlCommand(curlCmd); err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if got := len(cache.results); got != 4 {
+		t.Errorf("Shared cache holds %d results, want 4", got)
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xyz" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'X-A: 1' -H 'Authorization: Bearer xyz' -H 'X-B: 2' '%s/?a=1&b=2'`, server.URL)
+	minimize := func(responseCache bool) (string, int) {
+		_, report, err := New(Options{
+			MinimizeHeaders: true,
+			MinimizeParams:  true,
+			Strategy:        StrategyDDMin,
+			ResponseCache:   responseCache,
+		}).MinimizeCurlCommandWithReport(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		kept := strings.Join(append(report.KeptHeaders, report.KeptParams...), ",")
+		return kept, report.Requests
+	}
+
+	wantKept, uncachedRequests := minimize(false)
+	gotKept, cachedRequests := minimize(true)
+	if gotKept != wantKept {
+		t.Errorf("Kept %q with the response cache, %q without", gotKept, wantKept)
+	}
+	if cachedRequests >= uncachedRequests {
+		t.Errorf("Sent %d requests with the response cache, %d without; want fewer", cachedRequests, uncachedRequests)
+	}
+}
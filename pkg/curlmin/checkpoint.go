@@ -0,0 +1,58 @@
+package curlmin
+
+import (
+	"slices"
+)
+
+// Checkpoint records how far a minimization has progressed: the current
+// (partially minimized) command and which passes have completed. Resume by
+// minimizing Command with Options.CompletedPasses set to Completed.
+type Checkpoint struct {
+	Command   string   `json:"command"`
+	Completed []string `json:"completed"`
+}
+
+// Pass names used in checkpoints and Options.CompletedPasses
+const (
+	PassHeaders = "headers"
+	PassCookies = "cookies"
+	PassParams  = "params"
+	PassBody    = "body"
+	PassGraphQL = "graphql"
+)
+
+// Checkpoint returns the progress of the current (or last) run. It's safe to
+// call from another goroutine while MinimizeCurlCommand is running, e.g. from
+// an interrupt handler.
+func (m *Minimizer) Checkpoint() Checkpoint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Checkpoint{
+		Command:   m.checkpoint.Command,
+		Completed: slices.Clone(m.checkpoint.Completed),
+	}
+}
+
+// setCheckpointCommand records cmd as the latest resumable command
+func (m *Minimizer) setCheckpointCommand(cmd string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoint.Command = cmd
+}
+
+// passCompleted records that a pass finished, leaving curl as the resume point
+func (m *Minimizer) passCompleted(pass string, curl *CurlCommand) {
+	cmd, err := curl.ToString()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		m.checkpoint.Command = cmd
+	}
+	m.checkpoint.Completed = append(m.checkpoint.Completed, pass)
+}
+
+// skipPass reports whether a previous run already completed pass
+func (m *Minimizer) skipPass(pass string) bool {
+	return slices.Contains(m.options.CompletedPasses, pass)
+}
@@ -0,0 +1,231 @@
+package curlmin
+
+import (
+	"bytes"
+	"regexp"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// DiffOp says whether a DiffPart is in both commands or only one
+type DiffOp int
+
+const (
+	DiffEqual  DiffOp = iota // In both commands
+	DiffDelete               // Only in the original command
+	DiffInsert               // Only in the minimized command
+)
+
+// DiffPart is a run of text in a command diff
+type DiffPart struct {
+	Op   DiffOp
+	Text string
+}
+
+// diffToken splits an argument into words, punctuation, and spaces, so that
+// joining the tokens gives back the argument
+var diffToken = regexp.MustCompile(`[\p{L}\p{N}_]+|\s+|.`)
+
+// diffPlainArg matches arguments that are left unquoted in a diff
+var diffPlainArg = regexp.MustCompile(`^[A-Za-z0-9_./:=@,+%-]+$`)
+
+// diffWord matches the words of an argument, for pairing changed arguments
+var diffWord = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+
+// DiffCurlCommands compares two versions of a command (usually the original
+// and minimized ones) argument by argument, keeping each flag with its
+// value. An argument that was changed rather than removed, like a URL that
+// lost some params, is compared word by word with the original it's most
+// like, so only the parts that changed are marked. Both commands are printed
+// the same way first, so differences in quoting or line breaks don't show up.
+func DiffCurlCommands(original, minimized string) ([]DiffPart, error) {
+	unitsA, err := diffUnits(original)
+	if err != nil {
+		return nil, err
+	}
+	unitsB, err := diffUnits(minimized)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []DiffPart
+	add := func(op DiffOp, text string) {
+		if n := len(parts); n > 0 && parts[n-1].Op == op {
+			parts[n-1].Text += text
+			return
+		}
+		parts = append(parts, DiffPart{Op: op, Text: text})
+	}
+	// Add a whole argument, after a space that joins a run of removed or
+	// added arguments if it's between two of them
+	addUnit := func(op DiffOp, text string) {
+		if n := len(parts); n > 0 && op != DiffEqual && parts[n-1].Op == op {
+			add(op, " ")
+		} else if n > 0 {
+			add(DiffEqual, " ")
+		}
+		add(op, text)
+	}
+
+	ops := diffSequences(unitsA, unitsB)
+	for i := 0; i < len(ops); {
+		if ops[i].Op == DiffEqual {
+			addUnit(DiffEqual, ops[i].Text)
+			i++
+			continue
+		}
+
+		// Take a run of removed arguments and the arguments added after them
+		var deleted, inserted []string
+		for ; i < len(ops) && ops[i].Op == DiffDelete; i++ {
+			deleted = append(deleted, ops[i].Text)
+		}
+		for ; i < len(ops) && ops[i].Op == DiffInsert; i++ {
+			inserted = append(inserted, ops[i].Text)
+		}
+
+		// Compare each added argument word by word with the removed one still
+		// ahead of it that has most of its words, since minimizing mostly
+		// shortens arguments
+		for _, unit := range inserted {
+			words := diffWord.FindAllString(unit, -1)
+			best, bestScore := -1, 0.5
+			for k, candidate := range deleted {
+				common := commonTokens(diffWord.FindAllString(candidate, -1), words)
+				if score := float64(common) / float64(len(words)); score > bestScore {
+					best, bestScore = k, score
+				}
+			}
+			if best < 0 {
+				addUnit(DiffInsert, unit)
+				continue
+			}
+			for _, text := range deleted[:best] {
+				addUnit(DiffDelete, text)
+			}
+			if len(parts) > 0 {
+				add(DiffEqual, " ")
+			}
+			for _, tokenOp := range diffSequences(diffToken.FindAllString(deleted[best], -1), diffToken.FindAllString(unit, -1)) {
+				add(tokenOp.Op, tokenOp.Text)
+			}
+			deleted = deleted[best+1:]
+		}
+		for _, text := range deleted {
+			addUnit(DiffDelete, text)
+		}
+	}
+	return parts, nil
+}
+
+// diffUnits returns a command's arguments, quoted the same way whatever
+// quoting they came with, and with each flag that takes a value joined to it
+func diffUnits(curlCmd string) ([]string, error) {
+	if preprocessed, err := PreprocessCurlCommand(curlCmd); err == nil {
+		curlCmd = preprocessed
+	}
+	curl, err := ParseCurlCommand(curlCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	printer := syntax.NewPrinter()
+	print := func(i int) (string, error) {
+		word := curl.Command.Args[i]
+		if literalWord(word) {
+			value, err := expand.Literal(nil, word)
+			if err == nil && diffPlainArg.MatchString(value) {
+				return value, nil
+			} else if err == nil {
+				word = quotedWord(value)
+			}
+		}
+		var buf bytes.Buffer
+		err := printer.Print(&buf, word)
+		return buf.String(), err
+	}
+
+	var units []string
+	for i := 0; i < len(curl.Command.Args); i++ {
+		unit, err := print(i)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && i+1 < len(curl.Command.Args) && takesValue(curl.literalArg(i)) {
+			value, err := print(i + 1)
+			if err != nil {
+				return nil, err
+			}
+			unit += " " + value
+			i++
+		}
+		units = append(units, unit)
+	}
+	return units, nil
+}
+
+// literalWord reports whether a word has no expansions, so its value is
+// known without running it
+func literalWord(word *syntax.Word) bool {
+	literal := true
+	syntax.Walk(word, func(node syntax.Node) bool {
+		switch node.(type) {
+		case *syntax.ParamExp, *syntax.CmdSubst, *syntax.ArithmExp, *syntax.ProcSubst, *syntax.BraceExp, *syntax.ExtGlob:
+			literal = false
+		}
+		return literal
+	})
+	return literal
+}
+
+// commonTokens returns the length of the longest common subsequence of a
+// and b
+func commonTokens(a, b []string) int {
+	common := 0
+	for _, part := range diffSequences(a, b) {
+		if part.Op == DiffEqual {
+			common++
+		}
+	}
+	return common
+}
+
+// diffSequences returns the edits turning a into b, one part per element,
+// keeping the longest common subsequence and putting deletions before
+// insertions
+func diffSequences(a, b []string) []DiffPart {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var parts []DiffPart
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			parts = append(parts, DiffPart{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			parts = append(parts, DiffPart{Op: DiffDelete, Text: a[i]})
+			i++
+		default:
+			parts = append(parts, DiffPart{Op: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	return parts
+}
@@ -0,0 +1,68 @@
+package curlmin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffCurlCommands(t *testing.T) {
+	// Render parts like git's word diff
+	render := func(parts []DiffPart) string {
+		var b strings.Builder
+		for _, part := range parts {
+			switch part.Op {
+			case DiffDelete:
+				b.WriteString("[-" + part.Text + "-]")
+			case DiffInsert:
+				b.WriteString("{+" + part.Text + "+}")
+			default:
+				b.WriteString(part.Text)
+			}
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name      string
+		original  string
+		minimized string
+		want      string
+	}{
+		{
+			name:      "removed flags with their values",
+			original:  `curl -X POST -H 'A: 1' -H "B: 2" -d 'x=1' http://example.com/`,
+			minimized: `curl -H 'B: 2' -d x=1 http://example.com/`,
+			want:      `curl [--X POST -H 'A: 1'-] -H 'B: 2' -d x=1 http://example.com/`,
+		},
+		{
+			name:      "shortened arguments paired with their originals",
+			original:  "curl -H 'Cookie: a=1; b=2' \\\n  -H 'Cookie: c=3; d=4' 'http://example.com/?x=1&y=2'",
+			minimized: `curl -H 'Cookie: d=4' 'http://example.com/?y=2'`,
+			want:      `curl [--H 'Cookie: a=1; b=2'-] -H 'Cookie: [-c=3; -]d=4' 'http://example.com/?[-x=1&-]y=2'`,
+		},
+		{
+			name:      "added argument",
+			original:  `curl http://example.com/`,
+			minimized: `curl -H 'X: 1' http://example.com/`,
+			want:      `curl {+-H 'X: 1'+} http://example.com/`,
+		},
+		{
+			name:      "unchanged",
+			original:  `curl -H 'A: 1' http://example.com/`,
+			minimized: `curl -H 'A: 1' http://example.com/`,
+			want:      `curl -H 'A: 1' http://example.com/`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := DiffCurlCommands(tt.original, tt.minimized)
+			if err != nil {
+				t.Fatalf("DiffCurlCommands() error = %v", err)
+			}
+			if got := render(parts); got != tt.want {
+				t.Errorf("DiffCurlCommands() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
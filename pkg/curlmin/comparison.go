@@ -0,0 +1,64 @@
+package curlmin
+
+import (
+	"maps"
+	"slices"
+)
+
+// ComparisonConfig describes how responses are compared to the baseline,
+// including what AutoCompare chose
+type ComparisonConfig struct {
+	// Comparisons are the enabled comparisons, e.g. "status" and "body",
+	// all of which must pass. They're ignored when Match is set.
+	Comparisons []string `json:"comparisons"`
+	// Match is the string (or regex, with MatchIsRegex) a body must
+	// contain instead
+	Match        string `json:"match,omitempty"`
+	MatchIsRegex bool   `json:"match_is_regex,omitempty"`
+	// Forbid rejects bodies that gain a match for it
+	Forbid string `json:"forbid,omitempty"`
+
+	Headers               []string `json:"headers,omitempty"`
+	JQ                    string   `json:"jq,omitempty"`
+	IgnoreJSONPaths       []string `json:"ignore_json_paths,omitempty"`
+	IgnoreLines           []string `json:"ignore_lines,omitempty"`
+	CountTolerancePercent float64  `json:"count_tolerance_percent,omitempty"`
+	MaxDiffScore          float64  `json:"max_diff_score,omitempty"`
+	MinSimilarity         float64  `json:"min_similarity,omitempty"`
+	StripHTMLNoise        bool     `json:"strip_html_noise,omitempty"`
+	TimeoutMatchesTimeout bool     `json:"timeout_matches_timeout,omitempty"`
+
+	// Auto explains the comparison AutoCompare chose, if it did
+	Auto string `json:"auto,omitempty"`
+}
+
+// ComparisonConfig returns how responses are compared, which after a run
+// with AutoCompare reflects the comparison it chose
+func (m *Minimizer) ComparisonConfig() ComparisonConfig {
+	o := m.options
+	config := ComparisonConfig{
+		Comparisons:           []string{},
+		Match:                 o.MatchPattern,
+		MatchIsRegex:          o.MatchIsRegex,
+		Forbid:                o.ForbidRegex,
+		Headers:               o.CompareHeaders,
+		JQ:                    o.JQExpression,
+		IgnoreJSONPaths:       o.IgnoreJSONPaths,
+		IgnoreLines:           o.IgnoreLinePatterns,
+		CountTolerancePercent: o.CountTolerancePercent,
+		MaxDiffScore:          o.MaxDiffScore,
+		MinSimilarity:         o.MinSimilarity,
+		StripHTMLNoise:        o.StripHTMLNoise,
+		TimeoutMatchesTimeout: o.TimeoutComparison == TimeoutMatchesTimeout,
+		Auto:                  m.comparison,
+	}
+	if o.MatchPattern == "" {
+		enabled := m.enabledComparisons()
+		for _, key := range slices.Sorted(maps.Keys(enabled)) {
+			if enabled[key] {
+				config.Comparisons = append(config.Comparisons, key)
+			}
+		}
+	}
+	return config
+}
@@ -0,0 +1,31 @@
+package curlmin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComparisonConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		want    []string
+	}{
+		{name: "default", options: Options{}, want: []string{"body"}},
+		{name: "reproduce", options: Options{CompareBodyContent: true, Reproduce: true}, want: []string{"body", "status"}},
+		{name: "jq and headers", options: Options{JQExpression: ".id", CompareHeaders: []string{"Location"}}, want: []string{"headers", "jq"}},
+		{name: "match", options: Options{CompareStatusCode: true, MatchPattern: "ok"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := New(tt.options).ComparisonConfig()
+			if !reflect.DeepEqual(config.Comparisons, tt.want) {
+				t.Errorf("Comparisons = %v, want %v", config.Comparisons, tt.want)
+			}
+			if config.Match != tt.options.MatchPattern || config.JQ != tt.options.JQExpression {
+				t.Errorf("ComparisonConfig() = %+v, doesn't reflect the options", config)
+			}
+		})
+	}
+}
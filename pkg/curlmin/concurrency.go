@@ -0,0 +1,113 @@
+package curlmin
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// candidateTests tests the candidates of one iteration of a pass. With
+// Concurrency above 1, the first result asked for runs every remaining test,
+// up to Concurrency at a time. Otherwise each test runs when its result is
+// asked for, so a pass that stops at the first removable candidate sends no
+// more requests than it needs. Either way, results are recorded in candidate
+// order, so the outcome doesn't depend on which request finishes first.
+type candidateTests struct {
+	m            *Minimizer
+	curl         *CurlCommand
+	baselineResp Response
+	modify       []func(*CurlCommand) error
+	results      []*testResult
+}
+
+// newCandidateTests prepares the tests of a pass's candidate modifications
+func (m *Minimizer) newCandidateTests(curl *CurlCommand, baselineResp Response, modify []func(*CurlCommand) error) *candidateTests {
+	return &candidateTests{
+		m:            m,
+		curl:         curl,
+		baselineResp: baselineResp,
+		modify:       modify,
+		results:      make([]*testResult, len(modify)),
+	}
+}
+
+// test returns the result of the i-th candidate's test, like testModification
+func (t *candidateTests) test(i int) (bool, error) {
+	if t.results[i] == nil {
+		if t.m.options.Concurrency > 1 {
+			t.runAll()
+		} else {
+			result := t.m.runTest(t.curl, t.baselineResp, t.modify[i])
+			t.results[i] = &result
+		}
+	}
+	return t.m.record(*t.results[i])
+}
+
+// runAll runs every test that hasn't run yet, up to Concurrency at a time
+func (t *candidateTests) runAll() {
+	slots := make(chan struct{}, t.m.options.Concurrency)
+	var wg sync.WaitGroup
+	for i, modify := range t.modify {
+		if t.results[i] != nil {
+			continue
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result := t.m.runTest(t.curl, t.baselineResp, modify)
+			t.results[i] = &result
+		}()
+	}
+	wg.Wait()
+}
+
+// invalidate discards the results of the candidates after the i-th, which
+// were tested against the command before it changed (e.g. when a kept
+// element's value was shortened)
+func (t *candidateTests) invalidate(i int) {
+	clear(t.results[i+1:])
+}
+
+// waitToSend delays the start of a request until RequestDelay, plus a random
+// amount up to RequestJitter, has passed since the previous one started. It
+// returns the run's context error if the context is done, in which case the
+// request mustn't be sent.
+func (m *Minimizer) waitToSend() error {
+	ctx := m.context()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if m.options.RequestDelay <= 0 && m.options.RequestJitter <= 0 {
+		return nil
+	}
+
+	m.requestMu.Lock()
+	defer m.requestMu.Unlock()
+	if !m.lastRequest.IsZero() {
+		delay := m.options.RequestDelay
+		if m.options.RequestJitter > 0 {
+			delay += rand.N(m.options.RequestJitter + 1)
+		}
+		timer := time.NewTimer(delay - time.Since(m.lastRequest))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	m.lastRequest = time.Now()
+	return nil
+}
+
+// candidateFuncs returns the modification testing each candidate
+func candidateFuncs[T any](candidates []T, modify func(T) func(*CurlCommand) error) []func(*CurlCommand) error {
+	funcs := make([]func(*CurlCommand) error, len(candidates))
+	for i, candidate := range candidates {
+		funcs[i] = modify(candidate)
+	}
+	return funcs
+}
@@ -0,0 +1,122 @@
+package curlmin
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrency(t *testing.T) {
+	// The server needs the auth header, the session cookie, and the id param
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if r.Header.Get("Authorization") != "Bearer xyz" || err != nil || cookie.Value != "abc" || r.URL.Query().Get("id") == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'Accept: */*' -H 'Authorization: Bearer xyz' -H 'X-Requested-With: XMLHttpRequest' -H 'Cookie: _ga=1; session=abc; _gid=2' --url-query 'page=1' '%s/?id=42&utm_source=test&ref=home'`, server.URL)
+
+	minimize := func(options Options) (string, []Decision) {
+		options.MinimizeHeaders = true
+		options.MinimizeCookies = true
+		options.MinimizeParams = true
+		m := New(options)
+		minimizedCmd, err := m.MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		return strings.TrimSpace(minimizedCmd), m.Decisions()
+	}
+
+	want, wantDecisions := minimize(Options{})
+	got, gotDecisions := minimize(Options{Concurrency: 4})
+	if got != want {
+		t.Errorf("Minimized command with concurrency is %q, want %q", got, want)
+	}
+
+	// Query params are tried in map order, so compare the decisions as a set
+	removed := func(decisions []Decision) map[string]bool {
+		result := make(map[string]bool)
+		for _, d := range decisions {
+			result[d.Kind+" "+d.Name] = d.Removed
+		}
+		return result
+	}
+	if got, want := removed(gotDecisions), removed(wantDecisions); !maps.Equal(got, want) {
+		t.Errorf("Decisions with concurrency are %v, want %v", got, want)
+	}
+}
+
+func TestRequestDelay(t *testing.T) {
+	var mu sync.Mutex
+	var starts []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'Accept: */*' -H 'X-One: 1' -H 'X-Two: 2' '%s/'`, server.URL)
+
+	const delay = 50 * time.Millisecond
+	_, err := New(Options{
+		MinimizeHeaders: true,
+		Concurrency:     3,
+		RequestDelay:    delay,
+	}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(starts) < 2 {
+		t.Fatalf("Server got %d requests, want at least 2", len(starts))
+	}
+	// Allow for the time between curl starting and the request arriving
+	for i := 1; i < len(starts); i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < delay/2 {
+			t.Errorf("Request %d arrived %v after the previous one, want about %v", i, gap, delay)
+		}
+	}
+}
+
+func TestRequestJitter(t *testing.T) {
+	const delay, jitter = 10 * time.Millisecond, 40 * time.Millisecond
+	m := New(Options{RequestDelay: delay, RequestJitter: jitter})
+
+	var gaps []time.Duration
+	last := time.Now()
+	for range 20 {
+		if err := m.waitToSend(); err != nil {
+			t.Fatalf("waitToSend() failed: %v", err)
+		}
+		now := time.Now()
+		gaps = append(gaps, now.Sub(last))
+		last = now
+	}
+
+	// The first request goes right away; the rest wait between delay and
+	// delay+jitter, by different amounts
+	gaps = gaps[1:]
+	for i, gap := range gaps {
+		if gap < delay || gap > delay+jitter+20*time.Millisecond {
+			t.Errorf("Gap %d is %v, want between %v and %v", i, gap, delay, delay+jitter)
+		}
+	}
+	if spread := slices.Max(gaps) - slices.Min(gaps); spread < 5*time.Millisecond {
+		t.Errorf("Gaps only vary by %v, want them spread over the jitter", spread)
+	}
+}
@@ -0,0 +1,154 @@
+package curlmin
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// converters turn a request into code or text for another tool, by the
+// target name used with ConvertCurlCommand
+var converters = map[string]func(*convertRequest) (string, error){
+	"fetch":      toFetch,
+	"go":         toGo,
+	"httpie":     toHTTPie,
+	"postman":    toPostman,
+	"powershell": toPowerShell,
+	"python":     toPython,
+	"raw":        toRaw,
+}
+
+// ConvertTargets returns the names of the targets ConvertCurlCommand
+// supports, sorted
+func ConvertTargets() []string {
+	return slices.Sorted(maps.Keys(converters))
+}
+
+// ConvertCurlCommand translates a curl command into an equivalent request
+// for another tool, e.g. Python requests code for the "python" target. Only
+// the flags ToHTTPRequest interprets carry over, plus -k and -L; commands
+// with multipart forms (-F), uploads (-T), or bodies read from files
+// (-d @file) are rejected.
+func ConvertCurlCommand(curlCmd, target string) (string, error) {
+	convert, ok := converters[target]
+	if !ok {
+		return "", fmt.Errorf("unknown conversion target %q, expected one of %s", target, strings.Join(ConvertTargets(), ", "))
+	}
+
+	if preprocessed, err := PreprocessCurlCommand(curlCmd); err == nil {
+		curlCmd = preprocessed
+	}
+	curl, err := ParseCurlCommand(curlCmd)
+	if err != nil {
+		return "", err
+	}
+	req, err := curl.convertRequest()
+	if err != nil {
+		return "", err
+	}
+	return convert(req)
+}
+
+// convertRequest is a request in the terms most tools use: cookies and basic
+// credentials are split out of the headers
+type convertRequest struct {
+	Method string
+	URL    string
+	// Header holds the headers besides Cookie (unless it can't be parsed)
+	// and the Authorization header for User and Password
+	Header  http.Header
+	Cookies []*http.Cookie
+	// User and Password are the credentials given with -u, if BasicAuth
+	BasicAuth      bool
+	User, Password string
+	Body           string
+	HasBody        bool
+	// Insecure skips TLS certificate verification, like -k
+	Insecure bool
+	// FollowRedirects follows redirects, like -L
+	FollowRedirects bool
+}
+
+// headerNames returns the request's header names, sorted
+func (r *convertRequest) headerNames() []string {
+	return slices.Sorted(maps.Keys(r.Header))
+}
+
+// checkConvertible fails for flags that shape the request in ways
+// ToHTTPRequest doesn't interpret, rather than converting a different
+// request: multipart forms, uploads, and bodies read from files
+func (c *CurlCommand) checkConvertible() error {
+	for i := 1; i < len(c.Command.Args); i++ {
+		flag := c.literalArg(i)
+		value := c.literalArg(i + 1)
+		switch {
+		case flag == "-F" || flag == "--form" || flag == "--form-string":
+			return fmt.Errorf("can't convert multipart form data (%s)", flag)
+		case flag == "-T" || flag == "--upload-file":
+			return fmt.Errorf("can't convert an upload (%s)", flag)
+		case flag == "--data-urlencode":
+			// Read from a file as "@file" or "name@file"
+			if at := strings.Index(value, "@"); at >= 0 && !strings.Contains(value[:at], "=") {
+				return fmt.Errorf("can't convert a request body read from a file (%s %s)", flag, value)
+			}
+		case dataFlags[flag] && flag != "--data-raw" && strings.HasPrefix(value, "@"):
+			return fmt.Errorf("can't convert a request body read from a file (%s %s)", flag, value)
+		}
+		if takesValue(flag) {
+			i++
+		}
+	}
+	return nil
+}
+
+// convertRequest builds the convertRequest for a command
+func (c *CurlCommand) convertRequest() (*convertRequest, error) {
+	if err := c.checkConvertible(); err != nil {
+		return nil, err
+	}
+	req, err := c.ToHTTPRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &convertRequest{Method: req.Method, URL: req.URL.String(), Header: req.Header}
+	if req.Host != "" && req.Host != req.URL.Host {
+		r.Header.Set("Host", req.Host)
+	}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		r.Body, r.HasBody = string(body), true
+	}
+
+	if cookie := r.Header.Get("Cookie"); cookie != "" {
+		if cookies, err := http.ParseCookie(cookie); err == nil {
+			r.Cookies = cookies
+			r.Header.Del("Cookie")
+		}
+	}
+
+	for i := 1; i < len(c.Command.Args); i++ {
+		flag := c.literalArg(i)
+		switch flag {
+		case "-u", "--user":
+			r.User, r.Password, r.BasicAuth = req.BasicAuth()
+			if r.BasicAuth {
+				r.Header.Del("Authorization")
+			}
+		case "-k", "--insecure":
+			r.Insecure = true
+		case "-L", "--location":
+			r.FollowRedirects = true
+		}
+		if takesValue(flag) {
+			i++
+		}
+	}
+	return r, nil
+}
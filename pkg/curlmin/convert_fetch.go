@@ -0,0 +1,60 @@
+package curlmin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// jsString quotes a value as a JavaScript string
+func jsString(value string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// toFetch writes a request as a JavaScript fetch() call. Browsers don't let
+// scripts set cookies on a request, so a request with cookies is sent with
+// the browser's own cookies for the site (credentials: "include") and
+// otherwise with none.
+func toFetch(r *convertRequest) (string, error) {
+	header := r.Header.Clone()
+	if r.BasicAuth {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(r.User+":"+r.Password)))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "const response = await fetch(%s, {\n", jsString(r.URL))
+	if r.Method != http.MethodGet {
+		fmt.Fprintf(&b, "  method: %s,\n", jsString(r.Method))
+	}
+	if len(header) > 0 {
+		b.WriteString("  headers: {\n")
+		for _, name := range slices.Sorted(maps.Keys(header)) {
+			fmt.Fprintf(&b, "    %s: %s,\n", jsString(name), jsString(strings.Join(header[name], ", ")))
+		}
+		b.WriteString("  },\n")
+	}
+	if r.HasBody {
+		fmt.Fprintf(&b, "  body: %s,\n", jsString(r.Body))
+	}
+	if len(r.Cookies) > 0 {
+		cookies := make([]string, len(r.Cookies))
+		for i, cookie := range r.Cookies {
+			cookies[i] = cookie.Name + "=" + cookie.Value
+		}
+		fmt.Fprintf(&b, "  // Sends the browser's cookies for the site, which should include %s\n", strings.Join(cookies, "; "))
+		b.WriteString("  credentials: \"include\",\n")
+	} else {
+		b.WriteString("  credentials: \"omit\",\n")
+	}
+	b.WriteString("});\nconsole.log(await response.text());\n")
+	return b.String(), nil
+}
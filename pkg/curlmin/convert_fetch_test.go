@@ -0,0 +1,49 @@
+package curlmin
+
+import "testing"
+
+func TestConvertToFetch(t *testing.T) {
+	tests := []struct {
+		name    string
+		curlCmd string
+		want    string
+	}{
+		{
+			name:    "get",
+			curlCmd: `curl 'http://example.com/?q=1'`,
+			want: `const response = await fetch("http://example.com/?q=1", {
+  credentials: "omit",
+});
+console.log(await response.text());
+`,
+		},
+		{
+			name:    "post with cookies and auth",
+			curlCmd: `curl -u admin:secret -H 'Content-Type: application/json' -b 'sid=1' -d '{"a":"<b>"}' https://example.com/api`,
+			want: `const response = await fetch("https://example.com/api", {
+  method: "POST",
+  headers: {
+    "Authorization": "Basic YWRtaW46c2VjcmV0",
+    "Content-Type": "application/json",
+  },
+  body: "{\"a\":\"<b>\"}",
+  // Sends the browser's cookies for the site, which should include sid=1
+  credentials: "include",
+});
+console.log(await response.text());
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertCurlCommand(tt.curlCmd, "fetch")
+			if err != nil {
+				t.Fatalf("ConvertCurlCommand() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertCurlCommand() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
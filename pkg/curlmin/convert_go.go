@@ -0,0 +1,67 @@
+package curlmin
+
+import (
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+)
+
+// toGo writes a request as a Go program using net/http
+func toGo(r *convertRequest) (string, error) {
+	quote := strconv.Quote
+
+	var b strings.Builder
+	b.WriteString("package main\n\nimport (\n")
+	if r.Insecure {
+		b.WriteString("\"crypto/tls\"\n")
+	}
+	b.WriteString("\"fmt\"\n\"io\"\n\"net/http\"\n")
+	if r.HasBody {
+		b.WriteString("\"strings\"\n")
+	}
+	b.WriteString(")\n\nfunc main() {\n")
+
+	body := "nil"
+	if r.HasBody {
+		fmt.Fprintf(&b, "body := strings.NewReader(%s)\n", quote(r.Body))
+		body = "body"
+	}
+	fmt.Fprintf(&b, "req, err := http.NewRequest(%s, %s, %s)\n", quote(r.Method), quote(r.URL), body)
+	b.WriteString("if err != nil {\npanic(err)\n}\n")
+
+	for _, name := range r.headerNames() {
+		// The Host header is carried on the request itself
+		if name == "Host" {
+			fmt.Fprintf(&b, "req.Host = %s\n", quote(r.Header.Get(name)))
+			continue
+		}
+		for _, value := range r.Header[name] {
+			fmt.Fprintf(&b, "req.Header.Add(%s, %s)\n", quote(name), quote(value))
+		}
+	}
+	for _, cookie := range r.Cookies {
+		fmt.Fprintf(&b, "req.AddCookie(&http.Cookie{Name: %s, Value: %s})\n", quote(cookie.Name), quote(cookie.Value))
+	}
+	if r.BasicAuth {
+		fmt.Fprintf(&b, "req.SetBasicAuth(%s, %s)\n", quote(r.User), quote(r.Password))
+	}
+
+	b.WriteString("\nclient := &http.Client{\n")
+	if r.Insecure {
+		b.WriteString("Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},\n")
+	}
+	if !r.FollowRedirects {
+		b.WriteString("// Don't follow redirects, like curl without -L\n")
+		b.WriteString("CheckRedirect: func(req *http.Request, via []*http.Request) error {\nreturn http.ErrUseLastResponse\n},\n")
+	}
+	b.WriteString("}\n")
+	b.WriteString("resp, err := client.Do(req)\nif err != nil {\npanic(err)\n}\ndefer resp.Body.Close()\n\n")
+	b.WriteString("respBody, err := io.ReadAll(resp.Body)\nif err != nil {\npanic(err)\n}\nfmt.Println(string(respBody))\n}\n")
+
+	formatted, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format Go code: %w", err)
+	}
+	return string(formatted), nil
+}
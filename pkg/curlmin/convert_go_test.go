@@ -0,0 +1,101 @@
+package curlmin
+
+import "testing"
+
+func TestConvertToGo(t *testing.T) {
+	tests := []struct {
+		name    string
+		curlCmd string
+		want    string
+	}{
+		{
+			name:    "get",
+			curlCmd: `curl http://example.com/`,
+			want: `package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+func main() {
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	if err != nil {
+		panic(err)
+	}
+
+	client := &http.Client{
+		// Don't follow redirects, like curl without -L
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(respBody))
+}
+`,
+		},
+		{
+			name:    "post with everything",
+			curlCmd: `curl -k -L -H 'X-Api-Key: abc' -b 'sid=1' -d 'a=1' https://example.com/api`,
+			want: `package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+func main() {
+	body := strings.NewReader("a=1")
+	req, err := http.NewRequest("POST", "https://example.com/api", body)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("X-Api-Key", "abc")
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "1"})
+
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(respBody))
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertCurlCommand(tt.curlCmd, "go")
+			if err != nil {
+				t.Fatalf("ConvertCurlCommand() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertCurlCommand() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package curlmin
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// httpieKeyEscaper escapes the characters HTTPie reads as separators in the
+// key of a request item
+var httpieKeyEscaper = strings.NewReplacer(`\`, `\\`, `:`, `\:`, `=`, `\=`, `@`, `\@`)
+
+// toHTTPie writes a request as an HTTPie command. JSON object and
+// form-urlencoded bodies become request items, so HTTPie sets the
+// Content-Type itself; other bodies are sent with --raw.
+func toHTTPie(r *convertRequest) (string, error) {
+	header := r.Header.Clone()
+	if len(r.Cookies) > 0 {
+		cookies := make([]string, len(r.Cookies))
+		for i, cookie := range r.Cookies {
+			cookies[i] = cookie.Name + "=" + cookie.Value
+		}
+		header.Set("Cookie", strings.Join(cookies, "; "))
+	}
+
+	var flags, data []string
+	if r.HasBody {
+		mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+		var ok bool
+		switch mediaType {
+		case "application/json":
+			data, ok = httpieJSONItems(r.Body)
+		case "application/x-www-form-urlencoded":
+			data, ok = httpieFormItems(r.Body)
+			if ok {
+				flags = append(flags, "--form")
+			}
+		}
+		if ok {
+			header.Del("Content-Type")
+		} else {
+			flags = append(flags, "--raw="+r.Body)
+		}
+	}
+	if r.BasicAuth {
+		flags = append(flags, "-a", r.User+":"+r.Password)
+	}
+	if r.Insecure {
+		flags = append(flags, "--verify=no")
+	}
+	if r.FollowRedirects {
+		flags = append(flags, "--follow")
+	}
+
+	args := append([]string{"http"}, flags...)
+	// HTTPie sends a GET, or a POST if there's a body
+	defaultMethod := http.MethodGet
+	if r.HasBody {
+		defaultMethod = http.MethodPost
+	}
+	if r.Method != defaultMethod {
+		args = append(args, r.Method)
+	}
+	args = append(args, r.URL)
+
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[name] {
+			if value == "" {
+				// A header item with no value unsets the header instead
+				args = append(args, name+";")
+				continue
+			}
+			args = append(args, name+":"+value)
+		}
+	}
+	args = append(args, data...)
+	return shellCommand(args)
+}
+
+// httpieJSONItems converts a JSON object body into HTTPie request items:
+// key=value for strings and key:=json for everything else
+func httpieJSONItems(body string) ([]string, bool) {
+	obj, err := parseJSONObject(body)
+	if err != nil || len(obj.keys) == 0 {
+		return nil, false
+	}
+
+	var items []string
+	for _, key := range obj.keys {
+		value := obj.values[key]
+		var s string
+		if bytes.HasPrefix(value, []byte(`"`)) && json.Unmarshal(value, &s) == nil {
+			items = append(items, httpieKeyEscaper.Replace(key)+"="+s)
+			continue
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, value); err != nil {
+			return nil, false
+		}
+		items = append(items, httpieKeyEscaper.Replace(key)+":="+compact.String())
+	}
+	return items, true
+}
+
+// httpieFormItems converts a form-urlencoded body into key=value HTTPie
+// request items, keeping their order
+func httpieFormItems(body string) ([]string, bool) {
+	var items []string
+	for _, pair := range strings.Split(body, "&") {
+		name, value, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(name)
+		if err != nil || name == "" {
+			return nil, false
+		}
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			return nil, false
+		}
+		items = append(items, httpieKeyEscaper.Replace(name)+"="+value)
+	}
+	return items, true
+}
@@ -0,0 +1,44 @@
+package curlmin
+
+import "testing"
+
+func TestConvertToHTTPie(t *testing.T) {
+	tests := []struct {
+		name    string
+		curlCmd string
+		want    string
+	}{
+		{
+			name:    "get",
+			curlCmd: `curl 'http://example.com/?q=1'`,
+			want:    `http 'http://example.com/?q=1'`,
+		},
+		{
+			name:    "json body, cookies, and auth",
+			curlCmd: `curl -k -L -u admin:secret -H 'X-Api-Key: abc' -H 'Content-Type: application/json' -b 'sid=1; pref=dark' -d '{"name":"x","n": 1,"a:b":null}' https://example.com/api`,
+			want:    `http -a 'admin:secret' '--verify=no' --follow 'https://example.com/api' 'Cookie:sid=1; pref=dark' 'X-Api-Key:abc' 'name=x' 'n:=1' 'a\:b:=null'`,
+		},
+		{
+			name:    "form body with another method",
+			curlCmd: `curl -X PUT -d 'a=1&b=x%20y' http://example.com/`,
+			want:    `http --form PUT 'http://example.com/' 'a=1' 'b=x y'`,
+		},
+		{
+			name:    "other body",
+			curlCmd: `curl -H 'Content-Type: text/plain' -d 'hello' http://example.com/`,
+			want:    `http '--raw=hello' 'http://example.com/' 'Content-Type:text/plain'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertCurlCommand(tt.curlCmd, "httpie")
+			if err != nil {
+				t.Fatalf("ConvertCurlCommand() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertCurlCommand() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package curlmin
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"mime"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// postmanSchema identifies the Postman collection format written
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// Postman collection v2.1, limited to what a single request needs
+type postmanCollection struct {
+	Info postmanInfo   `json:"info"`
+	Item []postmanItem `json:"item"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type postmanItem struct {
+	Name                    string           `json:"name"`
+	Request                 postmanRequest   `json:"request"`
+	ProtocolProfileBehavior *postmanBehavior `json:"protocolProfileBehavior,omitempty"`
+}
+
+type postmanBehavior struct {
+	FollowRedirects bool `json:"followRedirects"`
+	StrictSSL       bool `json:"strictSSL"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanKeyValue `json:"header"`
+	Body   *postmanBody      `json:"body,omitempty"`
+	URL    postmanURL        `json:"url"`
+	Auth   *postmanAuth      `json:"auth,omitempty"`
+}
+
+type postmanKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+type postmanBody struct {
+	Mode    string              `json:"mode"`
+	Raw     string              `json:"raw"`
+	Options *postmanBodyOptions `json:"options,omitempty"`
+}
+
+type postmanBodyOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Protocol string            `json:"protocol,omitempty"`
+	Host     []string          `json:"host,omitempty"`
+	Port     string            `json:"port,omitempty"`
+	Path     []string          `json:"path,omitempty"`
+	Query    []postmanKeyValue `json:"query,omitempty"`
+}
+
+type postmanAuth struct {
+	Type  string            `json:"type"`
+	Basic []postmanKeyValue `json:"basic"`
+}
+
+// toPostman writes a request as a Postman v2.1 collection holding just that
+// request, named after its method and URL
+func toPostman(r *convertRequest) (string, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return "", err
+	}
+
+	request := postmanRequest{
+		Method: r.Method,
+		Header: []postmanKeyValue{},
+		URL: postmanURL{
+			Raw:      r.URL,
+			Protocol: u.Scheme,
+			Host:     strings.Split(u.Hostname(), "."),
+			Port:     u.Port(),
+		},
+	}
+	if path := strings.TrimPrefix(u.EscapedPath(), "/"); path != "" {
+		request.URL.Path = strings.Split(path, "/")
+	}
+	if u.RawQuery != "" {
+		for _, pair := range strings.Split(u.RawQuery, "&") {
+			key, value, _ := strings.Cut(pair, "=")
+			request.URL.Query = append(request.URL.Query, postmanKeyValue{Key: key, Value: value})
+		}
+	}
+
+	header := r.Header.Clone()
+	if len(r.Cookies) > 0 {
+		cookies := make([]string, len(r.Cookies))
+		for i, cookie := range r.Cookies {
+			cookies[i] = cookie.Name + "=" + cookie.Value
+		}
+		header.Set("Cookie", strings.Join(cookies, "; "))
+	}
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[name] {
+			request.Header = append(request.Header, postmanKeyValue{Key: name, Value: value})
+		}
+	}
+
+	if r.HasBody {
+		request.Body = &postmanBody{Mode: "raw", Raw: r.Body}
+		// Postman highlights and formats JSON bodies it knows are JSON
+		if mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type")); strings.HasSuffix(mediaType, "json") {
+			request.Body.Options = &postmanBodyOptions{}
+			request.Body.Options.Raw.Language = "json"
+		}
+	}
+	if r.BasicAuth {
+		request.Auth = &postmanAuth{Type: "basic", Basic: []postmanKeyValue{
+			{Key: "username", Value: r.User, Type: "string"},
+			{Key: "password", Value: r.Password, Type: "string"},
+		}}
+	}
+
+	item := postmanItem{Name: r.Method + " " + r.URL, Request: request}
+	// Postman follows redirects and checks certificates unless told not to
+	if !r.FollowRedirects || r.Insecure {
+		item.ProtocolProfileBehavior = &postmanBehavior{FollowRedirects: r.FollowRedirects, StrictSSL: !r.Insecure}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(postmanCollection{
+		Info: postmanInfo{Name: "curlmin", Schema: postmanSchema},
+		Item: []postmanItem{item},
+	})
+	return buf.String(), err
+}
@@ -0,0 +1,49 @@
+package curlmin
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestConvertToPostman(t *testing.T) {
+	curlCmd := `curl -k -u admin:secret -H 'Content-Type: application/json' -b 'sid=1' -d '{"a":1}' 'https://example.com:8443/v1/items?x=1&y=2'`
+	out, err := ConvertCurlCommand(curlCmd, "postman")
+	if err != nil {
+		t.Fatalf("ConvertCurlCommand() error = %v", err)
+	}
+
+	var collection postmanCollection
+	if err := json.Unmarshal([]byte(out), &collection); err != nil {
+		t.Fatalf("Output isn't JSON: %v\n%s", err, out)
+	}
+	if collection.Info.Schema != postmanSchema || len(collection.Item) != 1 {
+		t.Fatalf("Collection = %+v, want one item with the v2.1 schema", collection)
+	}
+
+	item := collection.Item[0]
+	want := postmanRequest{
+		Method: "POST",
+		Header: []postmanKeyValue{{Key: "Content-Type", Value: "application/json"}, {Key: "Cookie", Value: "sid=1"}},
+		Body:   &postmanBody{Mode: "raw", Raw: `{"a":1}`, Options: &postmanBodyOptions{}},
+		URL: postmanURL{
+			Raw:      "https://example.com:8443/v1/items?x=1&y=2",
+			Protocol: "https",
+			Host:     []string{"example", "com"},
+			Port:     "8443",
+			Path:     []string{"v1", "items"},
+			Query:    []postmanKeyValue{{Key: "x", Value: "1"}, {Key: "y", Value: "2"}},
+		},
+		Auth: &postmanAuth{Type: "basic", Basic: []postmanKeyValue{
+			{Key: "username", Value: "admin", Type: "string"},
+			{Key: "password", Value: "secret", Type: "string"},
+		}},
+	}
+	want.Body.Options.Raw.Language = "json"
+	if !reflect.DeepEqual(item.Request, want) {
+		t.Errorf("Request = %+v, want %+v", item.Request, want)
+	}
+	if item.ProtocolProfileBehavior == nil || item.ProtocolProfileBehavior.FollowRedirects || item.ProtocolProfileBehavior.StrictSSL {
+		t.Errorf("ProtocolProfileBehavior = %+v, want redirects and certificate checks off", item.ProtocolProfileBehavior)
+	}
+}
@@ -0,0 +1,92 @@
+package curlmin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// psStringEscaper escapes the characters that are special in a PowerShell
+// double-quoted string
+var psStringEscaper = strings.NewReplacer(
+	"`", "``",
+	`"`, "`\"",
+	"$", "`$",
+	"\n", "`n",
+	"\r", "`r",
+	"\t", "`t",
+	"\x00", "`0",
+)
+
+// psString quotes a value as a PowerShell double-quoted string
+func psString(value string) string {
+	return `"` + psStringEscaper.Replace(value) + `"`
+}
+
+// toPowerShell writes a request as an Invoke-WebRequest command, laid out
+// like a browser's "Copy as PowerShell": cookies and the user agent are set
+// on a $session web session, and the other headers are given with -Headers.
+// Invoke-WebRequest follows redirects like curl -L, so without -L the command
+// stops at the first response with -MaximumRedirection 0.
+func toPowerShell(r *convertRequest) (string, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return "", err
+	}
+
+	header := r.Header.Clone()
+	if r.BasicAuth {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(r.User+":"+r.Password)))
+	}
+	// Invoke-WebRequest won't take these as headers
+	contentType := header.Get("Content-Type")
+	userAgent := header.Get("User-Agent")
+	header.Del("Content-Type")
+	header.Del("User-Agent")
+
+	var b strings.Builder
+	session := len(r.Cookies) > 0 || userAgent != ""
+	if session {
+		b.WriteString("$session = New-Object Microsoft.PowerShell.Commands.WebRequestSession\n")
+		if userAgent != "" {
+			fmt.Fprintf(&b, "$session.UserAgent = %s\n", psString(userAgent))
+		}
+		for _, cookie := range r.Cookies {
+			fmt.Fprintf(&b, "$session.Cookies.Add((New-Object System.Net.Cookie(%s, %s, \"/\", %s)))\n",
+				psString(cookie.Name), psString(cookie.Value), psString(u.Hostname()))
+		}
+	}
+
+	b.WriteString("Invoke-WebRequest -UseBasicParsing -Uri " + psString(r.URL))
+	if r.Method != http.MethodGet {
+		b.WriteString(" `\n-Method " + psString(r.Method))
+	}
+	if session {
+		b.WriteString(" `\n-WebSession $session")
+	}
+	if len(header) > 0 {
+		b.WriteString(" `\n-Headers @{\n")
+		for _, name := range slices.Sorted(maps.Keys(header)) {
+			fmt.Fprintf(&b, "  %s=%s\n", psString(name), psString(strings.Join(header[name], ", ")))
+		}
+		b.WriteString("}")
+	}
+	if contentType != "" {
+		b.WriteString(" `\n-ContentType " + psString(contentType))
+	}
+	if r.HasBody {
+		b.WriteString(" `\n-Body " + psString(r.Body))
+	}
+	if r.Insecure {
+		b.WriteString(" `\n-SkipCertificateCheck")
+	}
+	if !r.FollowRedirects {
+		b.WriteString(" `\n-MaximumRedirection 0")
+	}
+	b.WriteString("\n")
+	return b.String(), nil
+}
@@ -0,0 +1,56 @@
+package curlmin
+
+import "testing"
+
+func TestConvertToPowerShell(t *testing.T) {
+	tests := []struct {
+		name    string
+		curlCmd string
+		want    string
+	}{
+		{
+			name:    "get",
+			curlCmd: `curl 'http://example.com/?q=1'`,
+			want: "Invoke-WebRequest -UseBasicParsing -Uri \"http://example.com/?q=1\" `\n" +
+				"-MaximumRedirection 0\n",
+		},
+		{
+			name:    "post with cookies and auth",
+			curlCmd: `curl -k -u admin:secret -A 'Mozilla/5.0' -H 'Content-Type: application/json' -b 'sid=1' -d '{"a":"$b"}' https://example.com/api`,
+			want: "$session = New-Object Microsoft.PowerShell.Commands.WebRequestSession\n" +
+				"$session.UserAgent = \"Mozilla/5.0\"\n" +
+				"$session.Cookies.Add((New-Object System.Net.Cookie(\"sid\", \"1\", \"/\", \"example.com\")))\n" +
+				"Invoke-WebRequest -UseBasicParsing -Uri \"https://example.com/api\" `\n" +
+				"-Method \"POST\" `\n" +
+				"-WebSession $session `\n" +
+				"-Headers @{\n" +
+				"  \"Authorization\"=\"Basic YWRtaW46c2VjcmV0\"\n" +
+				"} `\n" +
+				"-ContentType \"application/json\" `\n" +
+				"-Body \"{`\"a`\":`\"`$b`\"}\" `\n" +
+				"-SkipCertificateCheck `\n" +
+				"-MaximumRedirection 0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertCurlCommand(tt.curlCmd, "powershell")
+			if err != nil {
+				t.Fatalf("ConvertCurlCommand() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertCurlCommand() =\n%s\nwant\n%s", got, tt.want)
+			}
+
+			// The command reads back as the same request
+			curlCmd, err := PowerShellToCurl(got)
+			if err != nil {
+				t.Fatalf("PowerShellToCurl() error = %v", err)
+			}
+			if again, _ := ConvertCurlCommand(curlCmd, "powershell"); again != got {
+				t.Errorf("round trip =\n%s\nwant\n%s", again, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package curlmin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// pythonMethods are the methods with their own function in requests
+var pythonMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// toPython writes a request as a Python script using requests
+func toPython(r *convertRequest) (string, error) {
+	var b strings.Builder
+	b.WriteString("import requests\n\n")
+
+	// Go's quoted strings are valid Python strings
+	quote := strconv.Quote
+	writeDict := func(name string, keys, values []string) {
+		fmt.Fprintf(&b, "%s = {\n", name)
+		for i := range keys {
+			fmt.Fprintf(&b, "    %s: %s,\n", quote(keys[i]), quote(values[i]))
+		}
+		b.WriteString("}\n\n")
+	}
+
+	args := []string{quote(r.URL)}
+	if len(r.Header) > 0 {
+		// A dict holds one value per name, so repeated headers are joined
+		var names, values []string
+		for _, name := range r.headerNames() {
+			names, values = append(names, name), append(values, strings.Join(r.Header[name], ", "))
+		}
+		writeDict("headers", names, values)
+		args = append(args, "headers=headers")
+	}
+	if len(r.Cookies) > 0 {
+		var names, values []string
+		for _, cookie := range r.Cookies {
+			names, values = append(names, cookie.Name), append(values, cookie.Value)
+		}
+		writeDict("cookies", names, values)
+		args = append(args, "cookies=cookies")
+	}
+	if r.HasBody {
+		fmt.Fprintf(&b, "data = %s\n\n", quote(r.Body))
+		args = append(args, "data=data")
+	}
+	if r.BasicAuth {
+		args = append(args, fmt.Sprintf("auth=(%s, %s)", quote(r.User), quote(r.Password)))
+	}
+	if r.Insecure {
+		args = append(args, "verify=False")
+	}
+	// requests follows redirects unless told not to, while curl needs -L
+	if !r.FollowRedirects {
+		args = append(args, "allow_redirects=False")
+	}
+
+	call := "requests." + strings.ToLower(r.Method)
+	if !pythonMethods[r.Method] {
+		call = "requests.request"
+		args = append([]string{quote(r.Method)}, args...)
+	}
+	if len(args) == 1 {
+		fmt.Fprintf(&b, "response = %s(%s)\n", call, args[0])
+	} else {
+		fmt.Fprintf(&b, "response = %s(\n", call)
+		for _, arg := range args {
+			fmt.Fprintf(&b, "    %s,\n", arg)
+		}
+		b.WriteString(")\n")
+	}
+	b.WriteString("print(response.text)\n")
+	return b.String(), nil
+}
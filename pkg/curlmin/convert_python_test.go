@@ -0,0 +1,94 @@
+package curlmin
+
+import "testing"
+
+func TestConvertToPython(t *testing.T) {
+	tests := []struct {
+		name    string
+		curlCmd string
+		want    string
+	}{
+		{
+			name:    "get",
+			curlCmd: `curl 'http://example.com/?q=1'`,
+			want: `import requests
+
+response = requests.get(
+    "http://example.com/?q=1",
+    allow_redirects=False,
+)
+print(response.text)
+`,
+		},
+		{
+			name:    "post with everything",
+			curlCmd: `curl -k -u admin:secret -H 'X-Api-Key: abc' -H 'Content-Type: application/json' -b 'sid=1; pref=dark' -d '{"a":1}' https://example.com/api`,
+			want: `import requests
+
+headers = {
+    "Content-Type": "application/json",
+    "X-Api-Key": "abc",
+}
+
+cookies = {
+    "sid": "1",
+    "pref": "dark",
+}
+
+data = "{\"a\":1}"
+
+response = requests.post(
+    "https://example.com/api",
+    headers=headers,
+    cookies=cookies,
+    data=data,
+    auth=("admin", "secret"),
+    verify=False,
+    allow_redirects=False,
+)
+print(response.text)
+`,
+		},
+		{
+			name:    "repeated header following redirects",
+			curlCmd: `curl -L -H 'X-A: 1' -H 'X-A: 2' http://example.com/`,
+			want: `import requests
+
+headers = {
+    "X-A": "1, 2",
+}
+
+response = requests.get(
+    "http://example.com/",
+    headers=headers,
+)
+print(response.text)
+`,
+		},
+		{
+			name:    "method without its own function",
+			curlCmd: `curl -X PURGE http://example.com/`,
+			want: `import requests
+
+response = requests.request(
+    "PURGE",
+    "http://example.com/",
+    allow_redirects=False,
+)
+print(response.text)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertCurlCommand(tt.curlCmd, "python")
+			if err != nil {
+				t.Fatalf("ConvertCurlCommand() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertCurlCommand() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
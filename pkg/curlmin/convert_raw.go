@@ -0,0 +1,57 @@
+package curlmin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// toRaw writes a request as the HTTP/1.1 message that carries it, with CRLF
+// line endings, e.g. to paste into Burp Repeater or pipe to netcat. Headers
+// curl adds on its own (User-Agent and Accept) are left out, apart from Host
+// and Content-Length, which replaces any framing headers the command sets
+// (Content-Length and Transfer-Encoding) since the body is written as is.
+func toRaw(r *convertRequest) (string, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s HTTP/1.1\r\n", r.Method, u.RequestURI())
+
+	host := u.Host
+	if r.Header.Get("Host") != "" {
+		host = r.Header.Get("Host")
+	}
+	fmt.Fprintf(&b, "Host: %s\r\n", host)
+
+	header := r.Header.Clone()
+	header.Del("Host")
+	header.Del("Content-Length")
+	header.Del("Transfer-Encoding")
+	if r.BasicAuth {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(r.User+":"+r.Password)))
+	}
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[name] {
+			fmt.Fprintf(&b, "%s: %s\r\n", name, value)
+		}
+	}
+	if len(r.Cookies) > 0 {
+		cookies := make([]string, len(r.Cookies))
+		for i, cookie := range r.Cookies {
+			cookies[i] = cookie.Name + "=" + cookie.Value
+		}
+		fmt.Fprintf(&b, "Cookie: %s\r\n", strings.Join(cookies, "; "))
+	}
+	if r.HasBody {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n", len(r.Body))
+	}
+	b.WriteString("\r\n")
+	b.WriteString(r.Body)
+	return b.String(), nil
+}
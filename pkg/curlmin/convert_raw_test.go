@@ -0,0 +1,58 @@
+package curlmin
+
+import "testing"
+
+func TestConvertToRaw(t *testing.T) {
+	curlCmd := `curl -u admin:secret -H 'X-Api-Key: abc' -b 'sid=1; pref=dark' -d 'a=1' 'https://example.com:8443/api?x=1'`
+	got, err := ConvertCurlCommand(curlCmd, "raw")
+	if err != nil {
+		t.Fatalf("ConvertCurlCommand() error = %v", err)
+	}
+	want := "POST /api?x=1 HTTP/1.1\r\n" +
+		"Host: example.com:8443\r\n" +
+		"Authorization: Basic YWRtaW46c2VjcmV0\r\n" +
+		"Content-Type: application/x-www-form-urlencoded\r\n" +
+		"X-Api-Key: abc\r\n" +
+		"Cookie: sid=1; pref=dark\r\n" +
+		"Content-Length: 3\r\n" +
+		"\r\n" +
+		"a=1"
+	if got != want {
+		t.Errorf("ConvertCurlCommand() = %q, want %q", got, want)
+	}
+
+	// Reading the request back gives the same request
+	roundTrip, err := RawRequestToCurl([]byte(got), "https")
+	if err != nil {
+		t.Fatalf("RawRequestToCurl() error = %v", err)
+	}
+	again, err := ConvertCurlCommand(roundTrip, "raw")
+	if err != nil {
+		t.Fatalf("ConvertCurlCommand() error = %v", err)
+	}
+	if again != got {
+		t.Errorf("Round trip gives %q, want %q", again, got)
+	}
+}
+
+func TestConvertToRawFraming(t *testing.T) {
+	// The body's real length replaces the command's framing headers
+	for _, curlCmd := range []string{
+		`curl -H 'Content-Length: 3' -d 'a=12' http://example.com/`,
+		`curl -H 'Transfer-Encoding: chunked' -d 'a=12' http://example.com/`,
+	} {
+		got, err := ConvertCurlCommand(curlCmd, "raw")
+		if err != nil {
+			t.Fatalf("ConvertCurlCommand() error = %v", err)
+		}
+		want := "POST / HTTP/1.1\r\n" +
+			"Host: example.com\r\n" +
+			"Content-Type: application/x-www-form-urlencoded\r\n" +
+			"Content-Length: 4\r\n" +
+			"\r\n" +
+			"a=12"
+		if got != want {
+			t.Errorf("ConvertCurlCommand(%q) = %q, want %q", curlCmd, got, want)
+		}
+	}
+}
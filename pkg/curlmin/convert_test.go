@@ -0,0 +1,62 @@
+package curlmin
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestConvertRequest(t *testing.T) {
+	curl, err := ParseCurlCommand(`curl -k -u admin:secret -H 'X-Api-Key: abc' -b 'sid=1; pref=dark' -d 'a=1' 'https://example.com/api?x=1'`)
+	if err != nil {
+		t.Fatalf("ParseCurlCommand() error = %v", err)
+	}
+	got, err := curl.convertRequest()
+	if err != nil {
+		t.Fatalf("convertRequest() error = %v", err)
+	}
+
+	want := &convertRequest{
+		Method: http.MethodPost,
+		URL:    "https://example.com/api?x=1",
+		Header: http.Header{
+			"X-Api-Key":    {"abc"},
+			"Content-Type": {"application/x-www-form-urlencoded"},
+		},
+		Cookies:   []*http.Cookie{{Name: "sid", Value: "1"}, {Name: "pref", Value: "dark"}},
+		BasicAuth: true,
+		User:      "admin",
+		Password:  "secret",
+		Body:      "a=1",
+		HasBody:   true,
+		Insecure:  true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("convertRequest() = %+v, want %+v", got, want)
+	}
+}
+
+func TestConvertCurlCommandUnconvertible(t *testing.T) {
+	for _, curlCmd := range []string{
+		`curl -F a=b http://example.com/`,
+		`curl -T file.txt http://example.com/`,
+		`curl -d @body.json http://example.com/`,
+		`curl --data-binary @body.bin http://example.com/`,
+		`curl --data-urlencode name@file.txt http://example.com/`,
+	} {
+		if _, err := ConvertCurlCommand(curlCmd, "python"); err == nil {
+			t.Errorf("ConvertCurlCommand(%q) succeeded, want an error", curlCmd)
+		}
+	}
+
+	// --data-raw sends @ as is
+	if _, err := ConvertCurlCommand(`curl --data-raw @me http://example.com/`, "python"); err != nil {
+		t.Errorf("ConvertCurlCommand() error = %v", err)
+	}
+}
+
+func TestConvertCurlCommandUnknownTarget(t *testing.T) {
+	if _, err := ConvertCurlCommand("curl http://example.com/", "cobol"); err == nil {
+		t.Error("Expected an error for an unknown target")
+	}
+}
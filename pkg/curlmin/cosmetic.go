@@ -0,0 +1,88 @@
+package curlmin
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultCosmeticHeaders are the header names (or glob patterns) tried first
+// by CosmeticHeadersFirst when Options.CosmeticHeaders is nil. Browsers send
+// them with every request, but servers rarely depend on them.
+var DefaultCosmeticHeaders = []string{
+	"Accept",
+	"Accept-Encoding",
+	"Accept-Language",
+	"Cache-Control",
+	"DNT",
+	"Pragma",
+	"Priority",
+	"Sec-Ch-Ua*",
+	"Sec-Fetch-*",
+	"Sec-GPC",
+	"Upgrade-Insecure-Requests",
+}
+
+// isCosmeticHeader reports whether a header name matches one of the
+// cosmetic header patterns, ignoring case
+func (m *Minimizer) isCosmeticHeader(name string) bool {
+	patterns := m.options.CosmeticHeaders
+	if patterns == nil {
+		patterns = DefaultCosmeticHeaders
+	}
+
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// removeCosmeticHeaders tests removing every cosmetic header at once and, if
+// the response doesn't change, removes them without testing each one. If it
+// does, they're left for the regular header pass to test individually.
+func (m *Minimizer) removeCosmeticHeaders(curl *CurlCommand, baselineResp Response) {
+	hasBody := curl.HasBody()
+
+	var cosmetic []int
+	for _, i := range append(curl.FindHeaderArgs(), curl.FindHeaderFlagArgs()...) {
+		name, _, _ := strings.Cut(curl.headerArgName(i), ":")
+		name = strings.TrimSpace(name)
+		if hasBody && framingHeaders[strings.ToLower(name)] {
+			continue
+		}
+		if m.isCosmeticHeader(name) && !m.keeps(KindHeader, name) {
+			cosmetic = append(cosmetic, i)
+		}
+	}
+	if len(cosmetic) == 0 {
+		return
+	}
+	slices.Sort(cosmetic)
+
+	canRemove, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+		// Remove from the end so earlier indices stay valid
+		for _, i := range slices.Backward(cosmetic) {
+			c.RemoveArg(i)
+		}
+		return nil
+	})
+	if err != nil || !canRemove {
+		if m.options.Verbose {
+			m.logf("Cosmetic headers not removable together, testing them individually\n")
+		}
+		return
+	}
+
+	for _, i := range cosmetic {
+		headerName := curl.headerArgName(i)
+		if m.logHeader(headerName) {
+			m.logf("Cosmetic header not needed: %s\n", headerName)
+		}
+		m.decide(KindHeader, headerName, true)
+	}
+	for _, i := range slices.Backward(cosmetic) {
+		curl.RemoveArg(i)
+	}
+}
@@ -0,0 +1,71 @@
+package curlmin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestIsCosmeticHeader(t *testing.T) {
+	m := New(Options{})
+	for _, name := range []string{"Accept", "accept-language", "Sec-Ch-Ua-Platform", "sec-fetch-mode"} {
+		if !m.isCosmeticHeader(name) {
+			t.Errorf("isCosmeticHeader(%q) = false, want true", name)
+		}
+	}
+	for _, name := range []string{"Authorization", "X-Accept-Token", "User-Agent"} {
+		if m.isCosmeticHeader(name) {
+			t.Errorf("isCosmeticHeader(%q) = true, want false", name)
+		}
+	}
+
+	// A configured list replaces the defaults
+	m = New(Options{CosmeticHeaders: []string{"X-Requested-With"}})
+	if !m.isCosmeticHeader("x-requested-with") || m.isCosmeticHeader("Accept") {
+		t.Error("isCosmeticHeader() didn't use the configured list")
+	}
+}
+
+func TestCosmeticHeadersFirst(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") == "Bearer xyz789" {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "Unauthorized")
+		}
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz789' -H 'Accept: text/html' -H 'Accept-Language: en-US' -H 'Sec-Fetch-Mode: navigate' -H 'Sec-Fetch-Site: none' -H 'Sec-Ch-Ua-Mobile: ?0' -H 'Upgrade-Insecure-Requests: 1' '%s/'`, server.URL)
+
+	minimize := func(cosmeticFirst bool) (string, int) {
+		requests.Store(0)
+		minimizedCmd, err := New(Options{
+			MinimizeHeaders:      true,
+			CosmeticHeadersFirst: cosmeticFirst,
+		}).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		return strings.TrimSpace(minimizedCmd), int(requests.Load())
+	}
+
+	sequentialCmd, sequentialRequests := minimize(false)
+	cosmeticCmd, cosmeticRequests := minimize(true)
+
+	if want := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz789' '%s/'`, server.URL); cosmeticCmd != want {
+		t.Errorf("Minimized command is %q, want %q", cosmeticCmd, want)
+	}
+	if cosmeticCmd != sequentialCmd {
+		t.Errorf("Minimized command %q differs from sequential %q", cosmeticCmd, sequentialCmd)
+	}
+	if cosmeticRequests >= sequentialRequests {
+		t.Errorf("Cosmetic headers first made %d requests, sequential %d", cosmeticRequests, sequentialRequests)
+	}
+}
@@ -3,7 +3,11 @@ package curlmin
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"net/url"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
 
 	"mvdan.cc/sh/v3/syntax"
@@ -105,6 +109,18 @@ func ParseCurlCommand(curlCmd string) (*CurlCommand, error) {
 	}, nil
 }
 
+// argString returns the argument at index as a string with surrounding quotes removed
+func (c *CurlCommand) argString(index int) string {
+	if index < 0 || index >= len(c.Command.Args) {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	printer := syntax.NewPrinter()
+	printer.Print(&buf, c.Command.Args[index])
+	return strings.Trim(buf.String(), "'\"")
+}
+
 // FindHeaderArgs finds all header arguments (-H) in the curl command
 func (c *CurlCommand) FindHeaderArgs() []int {
 	var headerIndices []int
@@ -128,6 +144,188 @@ func (c *CurlCommand) FindHeaderArgs() []int {
 	return headerIndices
 }
 
+// headerFlags maps curl flags that set a request header on their own to the
+// name of the header they set
+var headerFlags = map[string]string{
+	"-A":           "User-Agent",
+	"--user-agent": "User-Agent",
+	"-e":           "Referer",
+	"--referer":    "Referer",
+}
+
+// FindHeaderFlagArgs finds all flags that set a header without -H (e.g. -A, -e)
+func (c *CurlCommand) FindHeaderFlagArgs() []int {
+	var flagIndices []int
+	for i := 1; i+1 < len(c.Command.Args); i++ {
+		if _, ok := headerFlags[c.argString(i)]; ok {
+			flagIndices = append(flagIndices, i)
+		}
+	}
+	return flagIndices
+}
+
+// authFlags lists curl's authentication flags; flags that take a value (e.g.
+// --oauth2-bearer) are also listed in valueFlags
+var authFlags = map[string]bool{
+	"-u":              true,
+	"--user":          true,
+	"--oauth2-bearer": true,
+	"--aws-sigv4":     true,
+	"--delegation":    true,
+	"--sasl-authzid":  true,
+	"--sasl-ir":       true,
+	"--basic":         true,
+	"--digest":        true,
+	"--ntlm":          true,
+	"--ntlm-wb":       true,
+	"--negotiate":     true,
+	"--anyauth":       true,
+}
+
+// FindAuthArgs finds all authentication flags (-u, --oauth2-bearer, --negotiate, etc.)
+func (c *CurlCommand) FindAuthArgs() []int {
+	var authIndices []int
+	for i := 1; i < len(c.Command.Args); i++ {
+		flag := c.argString(i)
+		if !authFlags[flag] {
+			continue
+		}
+		if takesValue(flag) {
+			if i+1 >= len(c.Command.Args) {
+				continue
+			}
+			authIndices = append(authIndices, i)
+			i++
+			continue
+		}
+		authIndices = append(authIndices, i)
+	}
+	return authIndices
+}
+
+// tlsFlags lists curl's flags that pin the TLS version or ciphers. Removing
+// one can change whether the handshake succeeds at all, so they're preserved
+// unless MinimizeTLS is set.
+var tlsFlags = map[string]bool{
+	"-1":                    true,
+	"-2":                    true,
+	"-3":                    true,
+	"--tlsv1":               true,
+	"--tlsv1.0":             true,
+	"--tlsv1.1":             true,
+	"--tlsv1.2":             true,
+	"--tlsv1.3":             true,
+	"--sslv2":               true,
+	"--sslv3":               true,
+	"--tls-max":             true,
+	"--ciphers":             true,
+	"--tls13-ciphers":       true,
+	"--curves":              true,
+	"--proxy-tlsv1":         true,
+	"--proxy-ciphers":       true,
+	"--proxy-tls13-ciphers": true,
+}
+
+// FindTLSArgs finds all TLS version and cipher flags (--tlsv1.2, --ciphers, etc.)
+func (c *CurlCommand) FindTLSArgs() []int {
+	var tlsIndices []int
+	for i := 1; i < len(c.Command.Args); i++ {
+		flag := c.argString(i)
+		if !tlsFlags[flag] {
+			continue
+		}
+		if takesValue(flag) {
+			if i+1 >= len(c.Command.Args) {
+				continue
+			}
+			tlsIndices = append(tlsIndices, i)
+			i++
+			continue
+		}
+		tlsIndices = append(tlsIndices, i)
+	}
+	return tlsIndices
+}
+
+// ExpandHeaderFiles replaces each -H @file argument with one -H argument per
+// header in the file, so the headers can be minimized individually. Reading
+// headers from stdin (-H @-) can't be expanded, nor can unreadable files;
+// those arguments are left as they are and described in the returned list.
+func (c *CurlCommand) ExpandHeaderFiles() []string {
+	var skipped []string
+	for _, headerIndex := range slices.Backward(c.FindHeaderArgs()) {
+		path, ok := strings.CutPrefix(c.literalArg(headerIndex+1), "@")
+		if !ok {
+			continue
+		}
+		if path == "-" {
+			skipped = append(skipped, "-H @- reads headers from stdin")
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("-H @%s: %v", path, err))
+			continue
+		}
+
+		var expanded []*syntax.Word
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			expanded = append(expanded,
+				&syntax.Word{Parts: []syntax.WordPart{&syntax.Lit{Value: "-H"}}},
+				quotedWord(line),
+			)
+		}
+
+		c.Command.Args = slices.Replace(c.Command.Args, headerIndex, headerIndex+2, expanded...)
+	}
+	return skipped
+}
+
+// headerArgName describes the header-pass element at index: the header for
+// -H, "Name: value" for header-setting flags like -e, and the flag plus any
+// value for auth flags
+func (c *CurlCommand) headerArgName(index int) string {
+	flag := c.argString(index)
+	if name, ok := headerFlags[flag]; ok {
+		return name + ": " + c.argString(index+1)
+	}
+	if authFlags[flag] || tlsFlags[flag] {
+		if takesValue(flag) {
+			return flag + " " + c.argString(index+1)
+		}
+		return flag
+	}
+	return c.argString(index + 1)
+}
+
+// SetArg replaces the argument at index with a single-quoted value
+func (c *CurlCommand) SetArg(index int, value string) error {
+	if index < 1 || index >= len(c.Command.Args) {
+		return fmt.Errorf("invalid argument index")
+	}
+
+	c.Command.Args[index] = quotedWord(value)
+	return nil
+}
+
+// quotedWord builds a single-quoted shell word for a value. Unlike a literal
+// holding the quoted text, it expands back to the value itself.
+func quotedWord(value string) *syntax.Word {
+	var parts []syntax.WordPart
+	for i, part := range strings.Split(value, "'") {
+		if i > 0 {
+			parts = append(parts, &syntax.Lit{Value: `\'`})
+		}
+		parts = append(parts, &syntax.SglQuoted{Value: part})
+	}
+	return &syntax.Word{Parts: parts}
+}
+
 // FindCookieArgs finds all cookie arguments (-b, --cookie, or -H "Cookie:") in the curl command
 func (c *CurlCommand) FindCookieArgs() []int {
 	var cookieIndices []int
@@ -161,65 +359,243 @@ func (c *CurlCommand) FindCookieArgs() []int {
 	return cookieIndices
 }
 
-// FindURLArg finds the URL argument in the curl command
-func (c *CurlCommand) FindURLArg() (int, error) {
-	// First, look for arguments that don't start with a dash and aren't preceded by a flag
+// FindDataArgs finds all request body arguments (-d, --data, --data-raw, etc.) in the curl command
+func (c *CurlCommand) FindDataArgs() []int {
+	var dataIndices []int
 	for i, arg := range c.Command.Args {
-		if i == 0 || i == len(c.Command.Args)-1 {
-			continue // Skip the curl command itself and the last argument (which can't be followed by a value)
+		if i == 0 {
+			continue // Skip the curl command itself
 		}
 
 		var buf bytes.Buffer
 		printer := syntax.NewPrinter()
 		printer.Print(&buf, arg)
-		argStr := buf.String()
-		argStr = strings.Trim(argStr, "'\"")
+		argStr := strings.TrimSpace(buf.String())
 
-		// Check if it's a flag that expects a value
-		if strings.HasPrefix(argStr, "-") {
-			// Skip this argument and its value
-			i++
+		// Check if it's a data flag
+		if dataFlags[argStr] && i+1 < len(c.Command.Args) {
+			dataIndices = append(dataIndices, i)
+		}
+	}
+	return dataIndices
+}
+
+// dataFlags lists the curl flags that send their value as the request body
+var dataFlags = map[string]bool{
+	"-d":               true,
+	"--data":           true,
+	"--data-raw":       true,
+	"--data-ascii":     true,
+	"--data-binary":    true,
+	"--data-urlencode": true,
+	"--json":           true,
+}
+
+// FindDataArg finds the single argument (-d, --data, --data-raw, etc.) that
+// sets the request body. It fails if there's more than one, since curl joins
+// them, or if the body is read from a file (@file) or URL-encoded by curl.
+func (c *CurlCommand) FindDataArg() (int, error) {
+	dataIndices := c.FindDataArgs()
+	if len(dataIndices) != 1 {
+		return -1, fmt.Errorf("found %d body arguments, expected 1", len(dataIndices))
+	}
+
+	index := dataIndices[0]
+	flag := c.literalArg(index)
+	if flag == "--data-urlencode" {
+		return -1, fmt.Errorf("body is URL-encoded by curl")
+	}
+	if flag != "--data-raw" && strings.HasPrefix(c.literalArg(index+1), "@") {
+		return -1, fmt.Errorf("body is read from a file")
+	}
+	return index, nil
+}
+
+// SetDataArg replaces the value of the body argument at index (the flag's
+// index, as returned by FindDataArg)
+func (c *CurlCommand) SetDataArg(index int, newBody string) error {
+	if !dataFlags[c.argString(index)] {
+		return fmt.Errorf("argument %d isn't a body argument", index)
+	}
+	if err := c.SetArg(index+1, newBody); err != nil {
+		return err
+	}
+	c.updateContentLength()
+	return nil
+}
+
+// updateContentLength sets an explicit -H 'Content-Length' header to the
+// length of the body the command now sends, so that a body shrunk by a pass
+// isn't sent with the length of the original
+func (c *CurlCommand) updateContentLength() {
+	req, err := c.httpRequest()
+	if err != nil || req.Body == nil {
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return
+	}
+	c.SetHeaderValue("Content-Length", strconv.Itoa(len(body)))
+}
+
+// FindFormArgs finds all multipart form arguments (-F, --form, --form-string) in the curl command
+func (c *CurlCommand) FindFormArgs() []int {
+	var formIndices []int
+	for i := 1; i+1 < len(c.Command.Args); i++ {
+		switch c.argString(i) {
+		case "-F", "--form", "--form-string":
+			formIndices = append(formIndices, i)
+		}
+	}
+	return formIndices
+}
+
+// HasBody reports whether the curl command sends a request body
+func (c *CurlCommand) HasBody() bool {
+	if len(c.FindDataArgs()) > 0 {
+		return true
+	}
+
+	for i, arg := range c.Command.Args {
+		if i == 0 || i+1 >= len(c.Command.Args) {
 			continue
 		}
 
-		// Check if the previous argument is a flag
-		var prevBuf bytes.Buffer
-		printer.Print(&prevBuf, c.Command.Args[i-1])
-		prevStr := prevBuf.String()
-		prevStr = strings.Trim(prevStr, "'\"")
+		var buf bytes.Buffer
+		printer := syntax.NewPrinter()
+		printer.Print(&buf, arg)
+		switch strings.TrimSpace(buf.String()) {
+		case "-F", "--form", "--form-string", "-T", "--upload-file":
+			return true
+		}
+	}
+	return false
+}
+
+// FindURLArg finds the URL argument in the curl command. The URL may appear
+// anywhere among the arguments; values of flags that take one (per
+// valueFlags) are never mistaken for it.
+func (c *CurlCommand) FindURLArg() (int, error) {
+	for i := 1; i < len(c.Command.Args); i++ {
+		argStr := c.argString(i)
+
+		// The value of --url is the URL itself
+		if argStr == "--url" {
+			if i+1 < len(c.Command.Args) {
+				return i + 1, nil
+			}
+			break
+		}
 
-		if strings.HasPrefix(prevStr, "-") {
-			// This is a value for a flag, not a URL
+		// Skip flags, along with their values if they take one
+		if strings.HasPrefix(argStr, "-") && argStr != "-" {
+			if takesValue(argStr) {
+				i++
+			}
 			continue
 		}
 
 		// Try to parse it as a URL
-		_, err := url.Parse(argStr)
-		if err == nil {
+		if _, err := url.Parse(argStr); err == nil {
 			return i, nil
 		}
 	}
 
-	// If we didn't find a URL yet, look for the last argument
-	lastIndex := len(c.Command.Args) - 1
-	if lastIndex > 0 {
-		var buf bytes.Buffer
-		printer := syntax.NewPrinter()
-		printer.Print(&buf, c.Command.Args[lastIndex])
-		argStr := buf.String()
-		argStr = strings.Trim(argStr, "'\"")
-
-		// Check if it's not a flag
-		if !strings.HasPrefix(argStr, "-") {
-			// Try to parse it as a URL
-			_, err := url.Parse(argStr)
-			if err == nil {
-				return lastIndex, nil
-			}
+	return -1, fmt.Errorf("could not find URL in curl command")
+}
+
+// valueFlags lists the curl flags that take a separate value argument
+var valueFlags = map[string]bool{
+	// Short flags
+	"-A": true, "-b": true, "-c": true, "-C": true, "-d": true, "-D": true,
+	"-e": true, "-E": true, "-F": true, "-H": true, "-K": true, "-m": true,
+	"-o": true, "-P": true, "-Q": true, "-r": true, "-t": true, "-T": true,
+	"-u": true, "-U": true, "-w": true, "-x": true, "-X": true, "-y": true,
+	"-Y": true, "-z": true,
+
+	// Long flags
+	"--abstract-unix-socket": true, "--alt-svc": true, "--cacert": true,
+	"--capath": true, "--cert": true, "--cert-type": true, "--ciphers": true,
+	"--config": true, "--curves": true,
+	"--connect-timeout": true, "--connect-to": true, "--continue-at": true,
+	"--cookie": true, "--cookie-jar": true, "--create-file-mode": true,
+	"--crlfile": true, "--data": true, "--data-ascii": true,
+	"--data-binary": true, "--data-raw": true, "--data-urlencode": true,
+	"--dns-interface": true, "--dns-ipv4-addr": true, "--dns-ipv6-addr": true,
+	"--dns-servers": true, "--doh-url": true, "--dump-header": true,
+	"--egd-file": true, "--engine": true, "--etag-compare": true,
+	"--etag-save": true, "--expect100-timeout": true, "--form": true,
+	"--form-string": true, "--ftp-account": true,
+	"--ftp-alternative-to-user": true, "--ftp-method": true, "--ftp-port": true,
+	"--ftp-ssl-ccc-mode": true, "--oauth2-bearer": true, "--aws-sigv4": true,
+	"--delegation": true, "--sasl-authzid": true, "--happy-eyeballs-timeout-ms": true,
+	"--haproxy-clientip": true, "--header": true, "--hostpubmd5": true,
+	"--hostpubsha256": true, "--hsts": true, "--interface": true,
+	"--ip-tos": true, "--ipfs-gateway": true, "--json": true,
+	"--keepalive-time": true, "--key": true, "--key-type": true, "--krb": true,
+	"--libcurl": true, "--limit-rate": true, "--local-port": true,
+	"--login-options": true, "--mail-auth": true, "--mail-from": true,
+	"--mail-rcpt": true, "--max-filesize": true, "--max-redirs": true,
+	"--max-time": true, "--netrc-file": true, "--noproxy": true,
+	"--output": true, "--output-dir": true, "--parallel-max": true,
+	"--pass": true, "--pinnedpubkey": true, "--preproxy": true, "--proto": true,
+	"--proto-default": true, "--proto-redir": true, "--proxy": true,
+	"--proxy-cacert": true, "--proxy-capath": true, "--proxy-cert": true,
+	"--proxy-cert-type": true, "--proxy-ciphers": true, "--proxy-crlfile": true,
+	"--proxy-header": true, "--proxy-tls13-ciphers": true,
+	"--proxy-key": true, "--proxy-key-type": true, "--proxy-pass": true,
+	"--proxy-pinnedpubkey": true, "--proxy-service-name": true,
+	"--proxy-tlsauthtype": true, "--proxy-tlspassword": true,
+	"--proxy-tlsuser": true, "--proxy-user": true, "--proxy1.0": true,
+	"--pubkey": true, "--quote": true, "--random-file": true, "--range": true,
+	"--rate": true, "--referer": true, "--request": true,
+	"--request-target": true, "--resolve": true, "--retry": true,
+	"--retry-delay": true, "--retry-max-time": true,
+	"--service-name": true, "--socks4": true, "--socks4a": true,
+	"--socks5": true, "--socks5-gssapi-service": true,
+	"--socks5-hostname": true, "--speed-limit": true, "--speed-time": true,
+	"--stderr": true, "--telnet-option": true, "--tftp-blksize": true,
+	"--time-cond": true, "--tls-max": true, "--tls13-ciphers": true,
+	"--tlsauthtype": true, "--tlspassword": true,
+	"--tlsuser": true, "--trace": true, "--trace-ascii": true,
+	"--trace-config": true, "--unix-socket": true, "--upload-file": true,
+	"--url": true, "--url-query": true, "--user": true, "--user-agent": true, "--variable": true,
+	"--write-out": true,
+}
+
+// takesValue reports whether a flag consumes the following argument as its
+// value. Clustered short flags (e.g. -sSLX) take a value only if the last
+// letter does; a value-taking letter earlier in the cluster consumes the rest
+// of the cluster as its value instead.
+func takesValue(flag string) bool {
+	if strings.HasPrefix(flag, "--") {
+		return valueFlags[flag]
+	}
+
+	letters := strings.TrimPrefix(flag, "-")
+	for i := range letters {
+		if valueFlags["-"+letters[i:i+1]] {
+			return i == len(letters)-1
 		}
 	}
+	return false
+}
 
-	return -1, fmt.Errorf("could not find URL in curl command")
+// FindURLQueryArgs finds all --url-query arguments, which append query
+// parameters to the URL (curl 8.x)
+func (c *CurlCommand) FindURLQueryArgs() []int {
+	var queryIndices []int
+	for i := 1; i < len(c.Command.Args)-1; i++ {
+		flag := c.argString(i)
+		if flag == "--url-query" {
+			queryIndices = append(queryIndices, i)
+		}
+		if takesValue(flag) {
+			i++
+		}
+	}
+	return queryIndices
 }
 
 // FindQueryParams finds query parameters in the URL
@@ -259,34 +635,42 @@ func (c *CurlCommand) FindQueryParams() (map[string]string, error) {
 	return queryParams, nil
 }
 
-// RemoveArg removes an argument and its value from the curl command
+// RemoveArg removes an argument and its value from the curl command. The
+// arguments are spliced out in place, so the remaining ones keep their order.
 func (c *CurlCommand) RemoveArg(index int) {
 	if index < 1 || index >= len(c.Command.Args) {
 		return
 	}
 
-	// Check if this is a flag with a value
-	if index+1 < len(c.Command.Args) {
-		var buf bytes.Buffer
-		printer := syntax.NewPrinter()
-		printer.Print(&buf, c.Command.Args[index])
-		argStr := buf.String()
-
-		var nextBuf bytes.Buffer
-		printer.Print(&nextBuf, c.Command.Args[index+1])
-		nextStr := nextBuf.String()
-
-		// If this is a flag and the next arg doesn't start with a dash, remove both
-		if strings.HasPrefix(argStr, "-") && !strings.HasPrefix(nextStr, "-") {
-			c.Command.Args = append(c.Command.Args[:index], c.Command.Args[index+2:]...)
-			return
-		}
+	// If this is a flag that takes a value, remove both
+	if index+1 < len(c.Command.Args) && takesValue(c.argString(index)) {
+		c.Command.Args = append(c.Command.Args[:index], c.Command.Args[index+2:]...)
+		return
 	}
 
 	// Otherwise just remove this arg
 	c.Command.Args = append(c.Command.Args[:index], c.Command.Args[index+1:]...)
 }
 
+// SetURLArg replaces the URL argument in the curl command
+func (c *CurlCommand) SetURLArg(urlStr string) error {
+	urlIndex, err := c.FindURLArg()
+	if err != nil {
+		return err
+	}
+
+	word := &syntax.Word{
+		Parts: []syntax.WordPart{
+			&syntax.Lit{
+				Value: "'" + urlStr + "'",
+			},
+		},
+	}
+
+	c.Command.Args[urlIndex] = word
+	return nil
+}
+
 // RemoveQueryParam removes a query parameter from the URL
 func (c *CurlCommand) RemoveQueryParam(param string) error {
 	urlIndex, err := c.FindURLArg()
@@ -309,13 +693,7 @@ func (c *CurlCommand) RemoveQueryParam(param string) error {
 		return nil
 	}
 
-	query, err := url.ParseQuery(parsedURL.RawQuery)
-	if err != nil {
-		return err
-	}
-
-	query.Del(param)
-	parsedURL.RawQuery = query.Encode()
+	parsedURL.RawQuery = removeRawQueryParam(parsedURL.RawQuery, param)
 
 	// Create a new word node with the updated URL
 	word := &syntax.Word{
@@ -330,6 +708,108 @@ func (c *CurlCommand) RemoveQueryParam(param string) error {
 	return nil
 }
 
+// removeRawQueryParam removes every occurrence of param from a raw query
+// string, splicing the remaining pairs back together as they were rather
+// than re-encoding them (which would corrupt base64 and other encoded values)
+func removeRawQueryParam(rawQuery, param string) string {
+	var kept []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil && unescaped == param {
+			continue
+		}
+		kept = append(kept, pair)
+	}
+	return strings.Join(kept, "&")
+}
+
+// isDataURL reports whether the command's URL is a data: URL
+func (c *CurlCommand) isDataURL() bool {
+	urlIndex, err := c.FindURLArg()
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(strings.ToLower(c.argString(urlIndex)), "data:")
+}
+
+// SetHeaderValue sets the value of every -H header with the given name
+// (case-insensitive), reporting whether any was found
+func (c *CurlCommand) SetHeaderValue(name, value string) bool {
+	found := false
+	for _, headerIndex := range c.FindHeaderArgs() {
+		headerName, _, ok := strings.Cut(c.argString(headerIndex+1), ":")
+		if ok && strings.EqualFold(strings.TrimSpace(headerName), name) {
+			c.SetArg(headerIndex+1, strings.TrimSpace(headerName)+": "+value)
+			found = true
+		}
+	}
+	return found
+}
+
+// SetQueryParamValue sets the value of a URL query parameter in place,
+// leaving the rest of the query string byte for byte as it was, and reports
+// whether the parameter was found
+func (c *CurlCommand) SetQueryParamValue(name, value string) bool {
+	urlIndex, err := c.FindURLArg()
+	if err != nil {
+		return false
+	}
+
+	urlStr := c.argString(urlIndex)
+	base, rawQuery, ok := strings.Cut(urlStr, "?")
+	if !ok {
+		return false
+	}
+	rawQuery, fragment, hasFragment := strings.Cut(rawQuery, "#")
+
+	found := false
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil && unescaped == name {
+			pairs[i] = key + "=" + url.QueryEscape(value)
+			found = true
+		}
+	}
+	if !found {
+		return false
+	}
+
+	urlStr = base + "?" + strings.Join(pairs, "&")
+	if hasFragment {
+		urlStr += "#" + fragment
+	}
+	c.SetURLArg(urlStr)
+	return true
+}
+
+// SetCookieValue sets the value of a cookie in every cookie argument that
+// carries it, reporting whether it was found
+func (c *CurlCommand) SetCookieValue(name, value string) bool {
+	found := false
+	for _, cookieIndex := range c.FindCookieArgs() {
+		arg := c.argString(cookieIndex + 1)
+		cookieStr := cookieValue(arg)
+		prefix := arg[:len(arg)-len(cookieStr)]
+
+		cookies := strings.Split(cookieStr, ";")
+		changed := false
+		for i, cookie := range cookies {
+			cookieName, _, ok := strings.Cut(strings.TrimSpace(cookie), "=")
+			if ok && strings.TrimSpace(cookieName) == name && !isCookieAttribute(cookie) {
+				// Keep the cookie's leading space, if any
+				cookies[i] = cookie[:len(cookie)-len(strings.TrimLeft(cookie, " "))] + name + "=" + value
+				changed = true
+			}
+		}
+		if changed {
+			c.SetArg(cookieIndex+1, prefix+strings.Join(cookies, ";"))
+			found = true
+		}
+	}
+	return found
+}
+
 // parseCookieString parses a cookie string and removes a specific cookie
 // Returns the updated cookie string and a boolean indicating if all cookies were removed
 func parseCookieString(cookieStr string, cookieName string) (string, bool) {
@@ -337,22 +817,30 @@ func parseCookieString(cookieStr string, cookieName string) (string, bool) {
 	cookies := strings.Split(cookieStr, ";")
 
 	var newCookies []string
+	kept := false
 	for _, cookie := range cookies {
 		cookie = strings.TrimSpace(cookie)
 		if cookie == "" {
 			continue
 		}
 
+		// Keep attributes as they are, unless no cookies are left
+		if isCookieAttribute(cookie) {
+			newCookies = append(newCookies, cookie)
+			continue
+		}
+
 		parts := strings.SplitN(cookie, "=", 2)
 		if len(parts) == 2 {
 			cookieNamePart := strings.TrimSpace(parts[0])
 			if cookieNamePart != cookieName {
 				newCookies = append(newCookies, cookie)
+				kept = true
 			}
 		}
 	}
 
-	if len(newCookies) == 0 {
+	if !kept {
 		// All cookies were removed
 		return "", true
 	}
@@ -361,6 +849,91 @@ func parseCookieString(cookieStr string, cookieName string) (string, bool) {
 	return strings.Join(newCookies, "; "), false
 }
 
+// cookieSet returns the names of all cookies the command sends, in order,
+// with each name listed once
+func (c *CurlCommand) cookieSet() []string {
+	var names []string
+	for _, cookieIndex := range c.FindCookieArgs() {
+		for _, name := range cookieNames(cookieValue(c.argString(cookieIndex + 1))) {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// cookieValue strips a "Cookie:" header prefix, leaving the cookie string
+func cookieValue(arg string) string {
+	if strings.HasPrefix(strings.ToLower(arg), "cookie:") {
+		return arg[len("cookie:"):]
+	}
+	return arg
+}
+
+// cookieAttributes are the Set-Cookie attributes that can show up in cookie
+// strings copied from a jar or a response; they aren't cookies themselves
+var cookieAttributes = map[string]bool{
+	"path":        true,
+	"domain":      true,
+	"expires":     true,
+	"max-age":     true,
+	"secure":      true,
+	"httponly":    true,
+	"samesite":    true,
+	"partitioned": true,
+	"priority":    true,
+	"version":     true,
+	"comment":     true,
+}
+
+// isCookieAttribute reports whether a segment of a cookie string is an
+// attribute (e.g. Path=/ or Secure) rather than a name=value pair
+func isCookieAttribute(segment string) bool {
+	name, _, _ := strings.Cut(strings.TrimSpace(segment), "=")
+	return cookieAttributes[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// cookieNames returns the names of the cookies in a cookie string, skipping
+// any attributes
+func cookieNames(cookieStr string) []string {
+	var names []string
+	for _, cookie := range strings.Split(cookieStr, ";") {
+		name, _, ok := strings.Cut(strings.TrimSpace(cookie), "=")
+		if ok && !isCookieAttribute(cookie) {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	return names
+}
+
+// RemoveCookie removes every occurrence of the named cookie, whether it's set
+// via a Cookie header or a cookie flag. It reports whether any were removed.
+func (c *CurlCommand) RemoveCookie(cookieName string) bool {
+	removed := false
+	for {
+		found := false
+		for _, cookieIndex := range c.FindCookieArgs() {
+			cookieStr := c.argString(cookieIndex + 1)
+			isHeader := strings.HasPrefix(strings.ToLower(cookieStr), "cookie:")
+			if isHeader {
+				cookieStr = cookieStr[len("cookie:"):]
+			}
+
+			if slices.Contains(cookieNames(cookieStr), cookieName) {
+				if c.RemoveCookieFromArg(cookieIndex, cookieName, isHeader) == nil {
+					found = true
+					removed = true
+				}
+				break
+			}
+		}
+		if !found {
+			return removed
+		}
+	}
+}
+
 // RemoveCookieFromArg removes a specific cookie from either a Cookie header or a cookie flag
 // isHeader should be true for Cookie headers, false for cookie flags
 func (c *CurlCommand) RemoveCookieFromArg(argIndex int, cookieName string, isHeader bool) error {
@@ -0,0 +1,231 @@
+package curlmin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindURLArg(t *testing.T) {
+	tests := []struct {
+		name    string
+		curlCmd string
+		wantURL string
+	}{
+		{
+			name:    "URL last",
+			curlCmd: `curl -H 'Accept: */*' -X POST -d 'a=1' 'http://example.com/last'`,
+			wantURL: "http://example.com/last",
+		},
+		{
+			name:    "URL first",
+			curlCmd: `curl 'http://example.com/first' -H 'Accept: */*' -b 'session=abc123'`,
+			wantURL: "http://example.com/first",
+		},
+		{
+			name:    "URL in middle",
+			curlCmd: `curl -H 'Accept: */*' 'http://example.com/middle' -o out.txt --compressed`,
+			wantURL: "http://example.com/middle",
+		},
+		{
+			name:    "URL after boolean flags",
+			curlCmd: `curl -sSL --compressed http://example.com/bool -H 'Accept: */*'`,
+			wantURL: "http://example.com/bool",
+		},
+		{
+			name:    "URL after clustered value flag",
+			curlCmd: `curl -sX PUT http://example.com/cluster`,
+			wantURL: "http://example.com/cluster",
+		},
+		{
+			name:    "URL given with --url",
+			curlCmd: `curl -H 'Accept: */*' --url 'http://example.com/flag' -d 'a=1'`,
+			wantURL: "http://example.com/flag",
+		},
+		{
+			name:    "URL after --url-query",
+			curlCmd: `curl --url-query 'a=1' 'http://example.com/query' --url-query b=2`,
+			wantURL: "http://example.com/query",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			curl, err := ParseCurlCommand(tt.curlCmd)
+			if err != nil {
+				t.Fatalf("Failed to parse curl command: %v", err)
+			}
+
+			urlIndex, err := curl.FindURLArg()
+			if err != nil {
+				t.Fatalf("Failed to find URL: %v", err)
+			}
+
+			if got := curl.argString(urlIndex); got != tt.wantURL {
+				t.Errorf("FindURLArg() found %q, want %q", got, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestFindAuthArgs(t *testing.T) {
+	curl, err := ParseCurlCommand(`curl --negotiate -u : --oauth2-bearer tok 'http://example.com/' --anyauth`)
+	if err != nil {
+		t.Fatalf("Failed to parse curl command: %v", err)
+	}
+
+	var got []string
+	for _, i := range curl.FindAuthArgs() {
+		got = append(got, curl.argString(i))
+	}
+	want := []string{"--negotiate", "-u", "--oauth2-bearer", "--anyauth"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("FindAuthArgs() found %v, want %v", got, want)
+	}
+
+	// Removing a flag without a value must leave the URL that follows it
+	curl.RemoveArg(1)
+	curl.RemoveArg(3)
+	cmd, err := curl.ToString()
+	if err != nil {
+		t.Fatalf("Failed to print curl command: %v", err)
+	}
+	if want := `curl -u : 'http://example.com/' --anyauth`; strings.TrimSpace(cmd) != want {
+		t.Errorf("after RemoveArg got %q, want %q", cmd, want)
+	}
+}
+
+func TestExpandHeaderFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "headers.txt")
+	if err := os.WriteFile(path, []byte("Accept: */*\r\n\r\nX-Token: it's secret\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write header file: %v", err)
+	}
+
+	curl, err := ParseCurlCommand(`curl -H @` + path + ` -H @- 'http://example.com/'`)
+	if err != nil {
+		t.Fatalf("Failed to parse curl command: %v", err)
+	}
+
+	skipped := curl.ExpandHeaderFiles()
+	if len(skipped) != 1 {
+		t.Errorf("ExpandHeaderFiles() skipped %v, want only stdin", skipped)
+	}
+
+	cmd, err := curl.ToString()
+	if err != nil {
+		t.Fatalf("Failed to print curl command: %v", err)
+	}
+	if want := `curl -H 'Accept: */*' -H 'X-Token: it'\''s secret' -H @- 'http://example.com/'`; strings.TrimSpace(cmd) != want {
+		t.Errorf("after ExpandHeaderFiles got %q, want %q", cmd, want)
+	}
+}
+
+func TestSetValues(t *testing.T) {
+	curl, err := ParseCurlCommand(`curl -H 'authorization: Bearer old' -b 'a=1; token=old' -H 'Cookie: token=old' 'http://example.com/?b64=YQ%3D%3D&token=old#top'`)
+	if err != nil {
+		t.Fatalf("Failed to parse curl command: %v", err)
+	}
+
+	if !curl.SetHeaderValue("Authorization", "Bearer new") {
+		t.Error("SetHeaderValue() didn't find the header")
+	}
+	if !curl.SetCookieValue("token", "new") {
+		t.Error("SetCookieValue() didn't find the cookie")
+	}
+	if !curl.SetQueryParamValue("token", "new value") {
+		t.Error("SetQueryParamValue() didn't find the parameter")
+	}
+	if curl.SetQueryParamValue("missing", "x") {
+		t.Error("SetQueryParamValue() found a parameter that isn't there")
+	}
+
+	cmd, err := curl.ToString()
+	if err != nil {
+		t.Fatalf("Failed to print curl command: %v", err)
+	}
+	want := `curl -H 'authorization: Bearer new' -b 'a=1; token=new' -H 'Cookie: token=new' 'http://example.com/?b64=YQ%3D%3D&token=new+value#top'`
+	if strings.TrimSpace(cmd) != want {
+		t.Errorf("after setting values got %q, want %q", cmd, want)
+	}
+}
+
+func TestRemoveRawQueryParam(t *testing.T) {
+	tests := []struct {
+		rawQuery string
+		param    string
+		want     string
+	}{
+		{"a=1&b=2", "a", "b=2"},
+		{"sig=YQ==&z=1&a=x+y", "z", "sig=YQ==&a=x+y"},
+		{"a=1&b=2&a=3", "a", "b=2"},
+		{"my%20key=1&b=2", "my key", "b=2"},
+		{"flag&b=2", "flag", "b=2"},
+	}
+
+	for _, tt := range tests {
+		if got := removeRawQueryParam(tt.rawQuery, tt.param); got != tt.want {
+			t.Errorf("removeRawQueryParam(%q, %q) = %q, want %q", tt.rawQuery, tt.param, got, tt.want)
+		}
+	}
+}
+
+func TestFindTLSArgs(t *testing.T) {
+	curl, err := ParseCurlCommand(`curl --tlsv1.2 --ciphers ECDHE-RSA-AES128-GCM-SHA256 'https://example.com/' --tls-max 1.2 -H 'Accept: */*'`)
+	if err != nil {
+		t.Fatalf("Failed to parse curl command: %v", err)
+	}
+
+	// The cipher list must not be mistaken for the URL
+	urlIndex, err := curl.FindURLArg()
+	if err != nil {
+		t.Fatalf("Failed to find URL: %v", err)
+	}
+	if got := curl.argString(urlIndex); got != "https://example.com/" {
+		t.Errorf("FindURLArg() found %q, want %q", got, "https://example.com/")
+	}
+
+	var got []string
+	for _, i := range curl.FindTLSArgs() {
+		got = append(got, curl.headerArgName(i))
+	}
+	want := []string{"--tlsv1.2", "--ciphers ECDHE-RSA-AES128-GCM-SHA256", "--tls-max 1.2"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("FindTLSArgs() found %v, want %v", got, want)
+	}
+}
+
+func TestCookieAttributes(t *testing.T) {
+	cookieStr := "session=abc123; Path=/; Secure; theme=dark; HttpOnly; SameSite=Lax"
+	if got, want := cookieNames(cookieStr), []string{"session", "theme"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("cookieNames() = %v, want %v", got, want)
+	}
+
+	curl, err := ParseCurlCommand(`curl -b '` + cookieStr + `' 'http://example.com/'`)
+	if err != nil {
+		t.Fatalf("Failed to parse curl command: %v", err)
+	}
+
+	// Attributes aren't cookies, so there's nothing to remove
+	if curl.RemoveCookie("Path") {
+		t.Error("RemoveCookie() removed the Path attribute")
+	}
+
+	// Removing a cookie leaves the attributes alone
+	if !curl.RemoveCookie("session") {
+		t.Error("RemoveCookie() didn't find the session cookie")
+	}
+	cmd, err := curl.ToString()
+	if err != nil {
+		t.Fatalf("Failed to print curl command: %v", err)
+	}
+	if want := `curl -b 'Path=/; Secure; theme=dark; HttpOnly; SameSite=Lax' 'http://example.com/'`; strings.TrimSpace(cmd) != want {
+		t.Errorf("after RemoveCookie got %q, want %q", cmd, want)
+	}
+
+	// Once the last cookie is gone, the attributes go with it
+	curl.RemoveCookie("theme")
+	if cmd, _ := curl.ToString(); strings.TrimSpace(cmd) != `curl 'http://example.com/'` {
+		t.Errorf("after removing every cookie got %q", cmd)
+	}
+}
@@ -2,14 +2,28 @@ package curlmin
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"maps"
+	"mime"
+	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/itchyny/gojq"
 	"mvdan.cc/sh/v3/syntax"
 )
 
@@ -17,87 +31,890 @@ type Options struct {
 	MinimizeHeaders bool
 	MinimizeCookies bool
 	MinimizeParams  bool
-	Verbose         bool
+	// Verbose logs each step of a run to Logger
+	Verbose bool
+	// Logger receives the output of Verbose; defaults to writing to stderr
+	Logger Logger
+	// KeepBody guarantees the request body is never a removal candidate
+	KeepBody bool
+	// MinimizeValues tries shortening the values of required elements, e.g.
+	// trimming a Referer to its origin, a User-Agent to its first product,
+	// dropping the password from Basic credentials, or replacing a numeric
+	// query parameter's value with 0
+	MinimizeValues bool
+	// StopAtFirstRequired halts minimization at the first element found to be required
+	StopAtFirstRequired bool
+	// PreferShortest tries removing the longest elements first so that, among
+	// equally minimal results, the shortest rendered command is preferred
+	PreferShortest bool
+	// Reproduce minimizes toward preserving an error (4xx/5xx) baseline
+	// response exactly, status code included, e.g. to find a minimal bug
+	// repro. It also accepts such a baseline when RequireSuccess is set.
+	Reproduce bool
+	// RequireSuccess refuses to minimize a command whose baseline response
+	// is an error (4xx/5xx), which usually means the copied session expired
+	RequireSuccess bool
+	// ConfirmRuns re-sends each candidate that matches the baseline this many
+	// more times, and only removes the element if every response matches, so
+	// a flaky endpoint that happens to return the baseline once doesn't get
+	// a required element dropped
+	ConfirmRuns int
+	// Shell is the shell that runs curl (and any PreRequestHook) with -c;
+	// defaults to sh. Use bash for commands with $'...' quoting, for example.
+	Shell string
+	// Native sends requests with Go's net/http instead of the curl binary
+	Native bool
+	// Executor, if set, sends every request in place of the curl binary or
+	// Native, e.g. through a proxy client or from recorded responses
+	Executor Executor
+	// OnProgress, if set, is called with each pass started, candidate
+	// tested, and element removed or kept, e.g. to drive a progress bar.
+	// It's called from the goroutine running the minimization, which waits
+	// for it to return.
+	OnProgress func(ProgressEvent)
+	// Transport is used by the native backend's client; defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	// TraceRequests captures the request headers curl actually sent via --trace-ascii
+	TraceRequests bool
+	// PairedParamCookie declares {param, cookie} pairs (e.g. double-submit CSRF
+	// tokens) that are tested and kept or removed together
+	PairedParamCookie [][2]string
+	// ReportDir, if set, is a directory where the baseline and final responses
+	// (and the commands that produced them) are written for inspection
+	ReportDir string
+	// HARFile, if set, is where every request sent during a run (baseline
+	// and candidates) and its response are saved as a HAR archive, written
+	// when the run ends, even if it fails
+	HARFile string
+	// CompletedPasses names passes (PassHeaders, PassCookies, PassParams, PassBody, PassGraphQL)
+	// already completed by an earlier run; they are skipped when resuming
+	// from a Checkpoint
+	CompletedPasses []string
+	// SignedURLFamilies are the signed URL schemes whose parameters are
+	// preserved as a group; nil means DefaultSignedURLFamilies
+	SignedURLFamilies []SignedURLFamily
+	// MinimizeSignedParams tests signed URL parameters individually instead
+	// of preserving them
+	MinimizeSignedParams bool
+	// IrrelevantParams are names or glob patterns (e.g. utm_*) of query
+	// parameters that are removed up front without testing whether they're
+	// needed. This saves requests for known noise, but the removal is never
+	// verified.
+	IrrelevantParams []string
+	// MinimizeBody tries removing the fields of a JSON (including nested
+	// keys) or form-urlencoded request body one at a time. Bodies in other
+	// formats are left untouched, as are GraphQL bodies when MinimizeGraphQL
+	// is set. Ignored when KeepBody is set.
+	MinimizeBody bool
+	// MinimizeGraphQL tries removing the selected fields and the variables
+	// of a GraphQL request body (a JSON object with a "query" string) one at
+	// a time. Ignored when KeepBody is set.
+	MinimizeGraphQL bool
+	// GreedyPass removes every header or cookie that's removable on its own
+	// in one go, instead of restarting the pass after each removal. If
+	// removing them together changes the response, they're verified one at
+	// a time. This saves requests when many elements are clearly unneeded.
+	GreedyPass bool
+	// Strategy is how the header, cookie, and query parameter passes search
+	// for removable elements; the default, StrategyLinear, tests them one at
+	// a time
+	Strategy Strategy
+	// CosmeticHeadersFirst tests removing all the headers matching
+	// CosmeticHeaders at once before the regular header pass, which shrinks
+	// browser-copied commands quickly. If the response changes, they're
+	// tested one at a time as usual.
+	CosmeticHeadersFirst bool
+	// CosmeticHeaders are header names or glob patterns (e.g. Sec-Fetch-*),
+	// matched ignoring case; nil means DefaultCosmeticHeaders
+	CosmeticHeaders []string
+	// MinimizeTLS tests removing TLS version and cipher flags (e.g.
+	// --tlsv1.2, --ciphers) along with the headers instead of preserving them
+	MinimizeTLS bool
+	// TimeoutComparison controls whether a timed-out request differs from
+	// the baseline (the default) or matches a baseline that also timed out
+	TimeoutComparison TimeoutComparison
+	// PreRequestHook is a shell command run before every request whose
+	// output updates header, query parameter, and cookie values in the
+	// command about to be sent (e.g. to substitute a fresh token); see
+	// runPreRequestHook for the output format
+	PreRequestHook string
+	// KeepHeaders, KeepCookies, and KeepParams name elements (matched
+	// ignoring case) that are always kept without testing, e.g. a signature
+	// parameter known to be required. Headers set by flags are named by the
+	// header (e.g. User-Agent for -A), and auth flags by the flag itself
+	// (e.g. --oauth2-bearer).
+	KeepHeaders []string
+	KeepCookies []string
+	KeepParams  []string
+	// KeepPatterns are regular expressions, matched against the whole name
+	// ignoring case, for header, cookie, and param names kept the same way
+	// (e.g. "X-Api-.*")
+	KeepPatterns []string
+	// OnlyHeaders, OnlyCookies, and OnlyParams, when set, restrict the
+	// elements of their kind that are tested to those whose whole name
+	// matches one of the regular expressions (ignoring case), e.g.
+	// "utm_.*" to only minimize tracking params. Other elements of that
+	// kind are kept without testing.
+	OnlyHeaders []string
+	OnlyCookies []string
+	OnlyParams  []string
+	// Concurrency is how many candidates of a pass are tested at once;
+	// 0 or 1 tests them one at a time. With more, every candidate of an
+	// iteration is tested up front, and the results are used in order, so the
+	// outcome is the same but some requests may turn out to be unneeded.
+	Concurrency int
+	// RequestDelay is the minimum time between the starts of two requests,
+	// for rate-limited targets
+	RequestDelay time.Duration
+	// RequestJitter adds a random extra delay of up to this much before
+	// each request, so the requests don't arrive at a fixed interval
+	RequestJitter time.Duration
+	// MaxRequests and MaxDuration, if set, stop testing once a run has sent
+	// that many requests (including the baseline) or taken that long. The
+	// run then returns the command as minimized so far, keeping every
+	// element it didn't get to test, and Partial reports true.
+	MaxRequests int
+	MaxDuration time.Duration
+	// Strict refuses to minimize a command that uses a construct curlmin
+	// can't fully model (see UnsupportedConstructs), returning
+	// ErrUnsupported instead of a possibly wrong result
+	Strict bool
+	// LogFilter is a regular expression restricting verbose header decisions
+	// to headers whose name matches it
+	LogFilter string
+	// AutoCompare sends the baseline request a few times and picks the
+	// comparison options from the responses, replacing any that are set: JSON
+	// structure for stable JSON (ignoring the paths whose values change
+	// between identical requests, if there are only a few), body content for
+	// other stable text, status and byte count for binary, and status and
+	// content type for responses that change between identical requests
+	AutoCompare bool
+	// AutoSamples is the number of baseline responses AutoCompare inspects
+	// (3 if unset)
+	AutoSamples int
 	// Response comparison options
 	CompareStatusCode  bool
+	CompareStatusText  bool
 	CompareBodyContent bool
 	CompareWordCount   bool
 	CompareLineCount   bool
 	CompareByteCount   bool
+	// CountTolerancePercent lets the word, line, and byte counts of a
+	// response differ from the baseline's by up to this percentage, e.g. 5
+	// for bodies that jitter slightly; 0 requires exact counts
+	CountTolerancePercent float64
+	// CompareBodyLines compares bodies line by line, ignoring lines that
+	// match any of IgnoreLinePatterns (regular expressions)
+	CompareBodyLines   bool
+	IgnoreLinePatterns []string
+	// CompareRedirectChain requires the ordered list of redirect Locations
+	// to match, not just the final response
+	CompareRedirectChain bool
+	// MaxDiffScore treats bodies as equivalent when their line diff score
+	// (lines inserted or deleted, divided by the lines in both bodies) is at
+	// most this value, e.g. 0.05; 0 disables the comparison
+	MaxDiffScore float64
+	// MinSimilarity treats bodies as equivalent when their similarity (see
+	// similarity) is at least this value, e.g. 0.95, for pages with rotating
+	// ads, CSRF tokens, or nonces; 0 disables the comparison
+	MinSimilarity float64
+	// CompareContentType compares the media type of the Content-Type header
+	// (e.g. application/json vs. text/html), ignoring parameters like charset
+	CompareContentType bool
+	// CompareHeaders lists response headers whose values must match the
+	// baseline's, for APIs that signal success through headers (e.g.
+	// X-Request-Status) while the body changes on every request
+	CompareHeaders []string
+	// CompareTrailers requires the trailer fields sent after a chunked body
+	// (see Response.Trailer) to match, which are otherwise ignored
+	CompareTrailers bool
+	// ComparisonCache reuses the result of comparing a pair of responses
+	// whose content was compared before, which saves time with the more
+	// expensive comparisons (e.g. JSON)
+	ComparisonCache bool
+	// SharedComparisonCache is a comparison cache shared with other
+	// Minimizers, e.g. across a batch of commands, used in place of a cache
+	// of the Minimizer's own when ComparisonCache is set
+	SharedComparisonCache *ComparisonCache
+	// ResponseCache reuses the response to a candidate command that's
+	// identical to one already tested in the run, instead of sending it
+	// again. It's ignored with a PreRequestHook, whose fresh values make
+	// every request different, and confirmation runs always send requests.
+	ResponseCache bool
+	// StripHTMLNoise removes HTML comments, inline script contents, and nonce
+	// attributes from both bodies before they're compared
+	StripHTMLNoise bool
+	// BodyNormalizer, if set, is applied to both bodies before they're
+	// compared, e.g. to strip timestamps or decrypt a field that none of the
+	// built-in comparisons handle
+	BodyNormalizer func([]byte) []byte
+	// CompareJSON compares bodies as JSON values, ignoring whitespace, key
+	// order, and number formatting (1.0 equals 1, 1e3 equals 1000)
+	CompareJSON bool
+	// IgnoreJSONPaths lists paths (e.g. .timestamp, .meta.requestId, or
+	// .items[].updatedAt) whose values are left out when comparing bodies
+	// as JSON with CompareJSON
+	IgnoreJSONPaths []string
+	// JQExpression, if set, compares the values this jq expression (e.g.
+	// ".data.id") extracts from both bodies rather than the bodies
+	// themselves. A body that isn't JSON never matches.
+	JQExpression string
+	// MatchPattern, if set, replaces the other comparisons: a response is
+	// equivalent to the baseline if its body contains this string, or
+	// matches it as a regular expression when MatchIsRegex is set. The
+	// baseline itself must match, for bodies that embed CSRF tokens,
+	// timestamps, or request IDs that change on every request.
+	MatchPattern string
+	MatchIsRegex bool
+	// ForbidRegex, if set, rejects a candidate whose body matches this
+	// regular expression when the baseline's doesn't (e.g. "Access Denied"
+	// or "captcha"), on top of the other comparisons
+	ForbidRegex string
 }
 
+// Errors returned by MinimizeCurlCommand, for use with errors.Is
+var (
+	// ErrParse is returned when the curl command can't be parsed
+	ErrParse = errors.New("failed to parse curl command")
+	// ErrBaseline is returned when the baseline request can't be executed
+	ErrBaseline = errors.New("failed to get baseline response")
+	// ErrCurlNotFound is returned when no curl binary is available
+	ErrCurlNotFound = errors.New("curl executable not found")
+	// ErrUnsupported is returned in Strict mode when the command uses
+	// constructs that curlmin can't fully model
+	ErrUnsupported = errors.New("command uses unsupported constructs")
+)
+
 type Minimizer struct {
 	options Options
+
+	// logFilter is the compiled LogFilter, if any
+	logFilter *regexp.Regexp
+
+	// match is the compiled MatchPattern, if MatchIsRegex is set
+	match *regexp.Regexp
+
+	// forbid is the compiled ForbidRegex, if any
+	forbid *regexp.Regexp
+
+	// jq is the compiled JQExpression, if any
+	jq *gojq.Code
+
+	// keep are the compiled KeepPatterns
+	keep []*regexp.Regexp
+	// only are the compiled OnlyHeaders, OnlyCookies, and OnlyParams, by
+	// element kind
+	only map[string][]*regexp.Regexp
+
+	// ignoreLines are the compiled IgnoreLinePatterns
+	ignoreLines []*regexp.Regexp
+
+	// ignoreJSONPaths are the parsed IgnoreJSONPaths
+	ignoreJSONPaths [][]string
+
+	// err records an invalid option found by New, returned before any requests are made
+	err error
+
+	// firstRequired is the first required element found when StopAtFirstRequired is set
+	firstRequired string
+
+	// decisions records the outcome for each tested element
+	decisions []Decision
+
+	// untested is set when the most recent test wasn't sent because the
+	// budget ran out
+	untested bool
+
+	// lastDifference describes how the most recent rejected candidate's
+	// response differed
+	lastDifference string
+
+	// warnings are reported by Warnings
+	warnings []string
+
+	// har records the current run's requests when HARFile is set
+	har *harRecorder
+
+	// responses holds the current run's responses when ResponseCache is set
+	responses *responseCache
+
+	// cache holds comparison results when ComparisonCache is set, and
+	// cacheScope identifies the current run's comparison in its keys
+	cache      *ComparisonCache
+	cacheScope [sha256.Size]byte
+
+	// assumeRemovable, set by Plan, decides every comparison in place of
+	// the responses
+	assumeRemovable *bool
+
+	// comparison describes the comparison chosen by AutoCompare
+	comparison string
+
+	// testTime is the time spent on test requests since the last decision
+	testTime time.Duration
+
+	// requests counts the requests sent by the current run
+	requests atomic.Int64
+
+	// ctx is the context of the current run; requests aren't sent once it's done
+	ctx context.Context
+
+	// partial is set when the current run runs out of its request budget
+	partial atomic.Bool
+
+	// requestMu guards lastRequest, when the last request started, for RequestDelay
+	requestMu   sync.Mutex
+	lastRequest time.Time
+
+	// mu guards checkpoint, which may be read while a run is in progress
+	mu         sync.Mutex
+	checkpoint Checkpoint
 }
 
 func New(options Options) *Minimizer {
-	return &Minimizer{
+	m := &Minimizer{
 		options: options,
 	}
+
+	if options.LogFilter != "" {
+		m.logFilter, m.err = regexp.Compile(options.LogFilter)
+		if m.err != nil {
+			m.err = fmt.Errorf("invalid log filter: %w", m.err)
+		}
+	}
+
+	if options.ComparisonCache {
+		m.cache = options.SharedComparisonCache
+		if m.cache == nil {
+			m.cache = NewComparisonCache()
+		}
+	}
+
+	if match, err := compileMatch(options); err != nil {
+		m.err = err
+	} else {
+		m.match = match
+	}
+
+	if jq, err := compileJQ(options); err != nil {
+		m.err = err
+	} else {
+		m.jq = jq
+	}
+
+	if options.ForbidRegex != "" {
+		forbid, err := regexp.Compile(options.ForbidRegex)
+		if err != nil {
+			m.err = fmt.Errorf("invalid forbidden pattern %q: %w", options.ForbidRegex, err)
+		} else {
+			m.forbid = forbid
+		}
+	}
+
+	if options.MinSimilarity < 0 || options.MinSimilarity > 1 {
+		m.err = fmt.Errorf("invalid similarity %v: must be between 0 and 1", options.MinSimilarity)
+	}
+
+	for _, pattern := range options.IgnoreLinePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			m.err = fmt.Errorf("invalid ignore-line pattern %q: %w", pattern, err)
+			break
+		}
+		m.ignoreLines = append(m.ignoreLines, re)
+	}
+
+	for _, pattern := range options.KeepPatterns {
+		re, err := compileNamePattern(pattern)
+		if err != nil {
+			m.err = fmt.Errorf("invalid keep pattern %q: %w", pattern, err)
+			break
+		}
+		m.keep = append(m.keep, re)
+	}
+
+	m.only = map[string][]*regexp.Regexp{}
+	for kind, patterns := range map[string][]string{KindHeader: options.OnlyHeaders, KindCookie: options.OnlyCookies, KindQueryParam: options.OnlyParams} {
+		for _, pattern := range patterns {
+			re, err := compileNamePattern(pattern)
+			if err != nil {
+				m.err = fmt.Errorf("invalid only-%s pattern %q: %w", kind, pattern, err)
+				break
+			}
+			m.only[kind] = append(m.only[kind], re)
+		}
+	}
+
+	for _, jsonPath := range options.IgnoreJSONPaths {
+		segments, err := parseIgnoreJSONPath(jsonPath)
+		if err != nil {
+			m.err = err
+			break
+		}
+		m.ignoreJSONPaths = append(m.ignoreJSONPaths, segments)
+	}
+
+	for _, pattern := range options.CosmeticHeaders {
+		if _, err := path.Match(pattern, ""); err != nil {
+			m.err = fmt.Errorf("invalid cosmetic header pattern %q: %w", pattern, err)
+			break
+		}
+	}
+
+	for _, pattern := range options.IrrelevantParams {
+		if _, err := path.Match(pattern, ""); err != nil {
+			m.err = fmt.Errorf("invalid irrelevant param pattern %q: %w", pattern, err)
+			break
+		}
+	}
+
+	return m
 }
 
+// MinimizeCurlCommand returns the smallest version of a curl command that
+// still gets an equivalent response
 func (m *Minimizer) MinimizeCurlCommand(curlCmd string) (string, error) {
+	return m.MinimizeCurlCommandContext(context.Background(), curlCmd)
+}
+
+// MinimizeCurlCommandContext is like MinimizeCurlCommand, but stops sending
+// requests once ctx is done (killing a curl process that's still running)
+// and returns ctx's error
+func (m *Minimizer) MinimizeCurlCommandContext(ctx context.Context, curlCmd string) (string, error) {
+	minimizedCmd, _, err := m.MinimizeCurlCommandWithReportContext(ctx, curlCmd)
+	return minimizedCmd, err
+}
+
+// MinimizeCurlCommandWithReport is like MinimizeCurlCommand, but also reports
+// which headers, cookies, and params were removed and kept
+func (m *Minimizer) MinimizeCurlCommandWithReport(curlCmd string) (string, *Report, error) {
+	return m.MinimizeCurlCommandWithReportContext(context.Background(), curlCmd)
+}
+
+// MinimizeCurlCommandWithReportContext is MinimizeCurlCommandWithReport with
+// a context, like MinimizeCurlCommandContext
+func (m *Minimizer) MinimizeCurlCommandWithReportContext(ctx context.Context, curlCmd string) (string, *Report, error) {
+	if m.err != nil {
+		return "", nil, m.err
+	}
+	m.har = nil
+	if m.options.HARFile != "" {
+		m.har = newHARRecorder()
+	}
+
+	minimizedCmd, report, err := m.minimize(ctx, curlCmd)
+
+	// Save what was sent even if the run failed partway
+	if m.har != nil {
+		if harErr := m.har.write(m.options.HARFile); harErr != nil && err == nil {
+			return "", nil, harErr
+		}
+	}
+	return minimizedCmd, report, err
+}
+
+// minimize runs the passes for MinimizeCurlCommandWithReportContext
+func (m *Minimizer) minimize(ctx context.Context, curlCmd string) (string, *Report, error) {
+	m.requests.Store(0)
+	m.partial.Store(false)
+	m.untested = false
+	m.responses = nil
+	if m.options.ResponseCache && m.options.PreRequestHook == "" {
+		m.responses = newResponseCache()
+	}
+	runCtx, cancel := m.withBudget(ctx)
+	defer cancel()
+	m.ctx = runCtx
+	defer func() { m.ctx = nil }()
+
 	// Preprocess the curl command to remove comments and fold multi-line commands
 	preprocessed, err := PreprocessCurlCommand(curlCmd)
 	if err != nil {
 		// If preprocessing fails, try with the original command
 		if m.options.Verbose {
-			fmt.Printf("Warning: Failed to preprocess curl command: %v\n", err)
-			fmt.Printf("Proceeding with original command\n")
+			m.logf("Warning: Failed to preprocess curl command: %v\n", err)
+			m.logf("Proceeding with original command\n")
 		}
 	} else {
 		// Use the preprocessed command
 		curlCmd = preprocessed
 	}
 
+	if m.options.Strict {
+		unsupported, err := UnsupportedConstructs(curlCmd)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %w", ErrParse, err)
+		}
+		if len(unsupported) > 0 {
+			return "", nil, fmt.Errorf("%w: %s", ErrUnsupported, strings.Join(unsupported, ", "))
+		}
+	}
+
 	// Parse the curl command into a syntax tree
 	curl, err := ParseCurlCommand(curlCmd)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse curl command: %w", err)
+		return "", nil, fmt.Errorf("%w: %w", ErrParse, err)
+	}
+
+	// Warn about signed URLs that will fail no matter what's removed
+	m.warnings = nil
+	m.comparison = ""
+	m.checkSignedURL(curl)
+
+	// Inline headers read from files so they can be minimized individually
+	for _, skipped := range curl.ExpandHeaderFiles() {
+		m.warn("can't expand header file, minimizing it as a whole: " + skipped)
+	}
+
+	// Make sure there's a shell and a curl binary to run the command with
+	if !m.options.Native && m.options.Executor == nil {
+		if _, err := exec.LookPath(m.shell()); err != nil {
+			return "", nil, fmt.Errorf("shell %q not found: %w", m.shell(), err)
+		}
+		if _, err := exec.LookPath("curl"); err != nil {
+			return "", nil, fmt.Errorf("%w: %w", ErrCurlNotFound, err)
+		}
+	}
+
+	// Let curl generate multipart framing for -F forms
+	var multipartHeader string
+	if m.options.MinimizeHeaders {
+		multipartHeader = m.dropStaleMultipartHeader(curl)
+	}
+
+	// Drop known noise before the baseline, so it's never sent
+	var irrelevant []string
+	if m.options.MinimizeParams && !curl.isDataURL() {
+		irrelevant = m.stripIrrelevantParams(curl)
+		for _, name := range irrelevant {
+			m.warn("removed param without testing it, since it's listed as irrelevant: " + name)
+		}
 	}
 
 	// Get the baseline response to compare against
 	baselineCmd, err := curl.ToString()
 	if err != nil {
-		return "", fmt.Errorf("failed to convert curl command to string: %w", err)
+		return "", nil, fmt.Errorf("failed to convert curl command to string: %w", err)
 	}
 
 	baselineResp, err := m.executeCurlCommand(baselineCmd)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", nil, ctxErr
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to get baseline response: %w", err)
+		return "", nil, fmt.Errorf("%w: %w", ErrBaseline, err)
+	}
+
+	if m.options.ReportDir != "" {
+		if err := writeReport(m.options.ReportDir, "baseline", baselineCmd, baselineResp); err != nil {
+			return "", nil, err
+		}
+	}
+
+	// Refuse to minimize toward an error response if asked to. Redirects
+	// pass, since plenty of requests (form posts, logins, OAuth steps)
+	// legitimately answer with one, and curl doesn't follow them without -L.
+	if baselineResp.StatusCode >= 400 && m.options.RequireSuccess && !m.options.Reproduce {
+		return "", nil, fmt.Errorf("%w: baseline request returned status %d; use reproduce mode to minimize an error response", ErrBaseline, baselineResp.StatusCode)
+	}
+
+	// A marker missing from the baseline can't tell candidates apart
+	if m.options.MatchPattern != "" && !m.bodyMatches(baselineResp.Body) {
+		return "", nil, fmt.Errorf("%w: baseline response body doesn't match %q", ErrBaseline, m.options.MatchPattern)
+	}
+
+	// Nor can a jq expression that doesn't work on the baseline
+	if m.options.JQExpression != "" {
+		if _, err := m.jqOutput(baselineResp.Body); err != nil {
+			return "", nil, fmt.Errorf("%w: jq expression %q fails on the baseline response: %v", ErrBaseline, m.options.JQExpression, err)
+		}
+	}
+
+	// Pick how to compare responses from the baseline itself, unless a
+	// match pattern already decides
+	if m.options.AutoCompare && m.options.MatchPattern == "" {
+		m.comparison, err = m.chooseComparison(baselineCmd, baselineResp)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", nil, ctxErr
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %w", ErrBaseline, err)
+		}
+	}
+	if m.cache != nil {
+		m.cacheScope = m.comparisonScope()
+	}
+
+	m.firstRequired = ""
+	m.decisions = nil
+	m.testTime = 0
+	for _, name := range irrelevant {
+		decision := Decision{Kind: KindQueryParam, Name: name, Removed: true, Reason: "listed as irrelevant, not tested"}
+		m.decisions = append(m.decisions, decision)
+		m.progressDecision(decision)
+	}
+	if multipartHeader != "" {
+		decision := Decision{Kind: KindHeader, Name: multipartHeader, Removed: true, Reason: "stale multipart boundary for -F, not tested"}
+		m.decisions = append(m.decisions, decision)
+		m.progressDecision(decision)
 	}
 
+	// Start the checkpoint from whatever an earlier run already completed
+	m.mu.Lock()
+	m.checkpoint = Checkpoint{
+		Command:   baselineCmd,
+		Completed: slices.Clone(m.options.CompletedPasses),
+	}
+	m.mu.Unlock()
+
 	// Minimize headers first
-	if m.options.MinimizeHeaders {
+	if m.options.MinimizeHeaders && !m.skipPass(PassHeaders) {
+		m.startPass(PassHeaders)
 		m.minimizeHeaders(curl, baselineResp)
+		if m.firstRequired == "" {
+			m.passCompleted(PassHeaders, curl)
+		}
 	}
 
-	// Minimize cookies next
-	if m.options.MinimizeCookies {
+	// Minimize cookies next, collapsing cookies sent more than once first
+	if m.options.MinimizeCookies && m.firstRequired == "" && !m.skipPass(PassCookies) {
+		m.startPass(PassCookies)
+		m.collapseDuplicateCookies(curl, baselineResp)
 		m.minimizeCookies(curl, baselineResp)
+		if m.firstRequired == "" {
+			m.passCompleted(PassCookies, curl)
+		}
+		if m.options.Verbose {
+			m.logf("Cookies kept: %s\n", strings.Join(curl.cookieSet(), ", "))
+		}
+	}
+
+	// data: URLs never reach a server, so there's nothing to learn from their parameters
+	if m.options.MinimizeParams && curl.isDataURL() {
+		if m.options.Verbose {
+			m.logf("Skipping query parameters: data: URLs aren't sent to a server\n")
+		}
 	}
 
-	// Minimize query parameters last
-	if m.options.MinimizeParams {
+	// Minimize query parameters last, collapsing obvious duplicates first
+	if m.options.MinimizeParams && m.firstRequired == "" && !m.skipPass(PassParams) && !curl.isDataURL() {
+		m.startPass(PassParams)
+		m.collapseDuplicateParams(curl, baselineResp)
 		m.minimizeQueryParams(curl, baselineResp)
+		if m.firstRequired == "" {
+			m.minimizeURLQueryArgs(curl, baselineResp)
+		}
+		if m.firstRequired == "" {
+			m.passCompleted(PassParams, curl)
+		}
+	}
+
+	// Minimize the fields of a JSON or form body
+	if m.options.MinimizeBody && !m.options.KeepBody && m.firstRequired == "" && !m.skipPass(PassBody) {
+		m.startPass(PassBody)
+		m.minimizeBody(curl, baselineResp)
+		if m.firstRequired == "" {
+			m.passCompleted(PassBody, curl)
+		}
+	}
+
+	// Minimize a GraphQL body field by field
+	if m.options.MinimizeGraphQL && !m.options.KeepBody && m.firstRequired == "" && !m.skipPass(PassGraphQL) {
+		m.startPass(PassGraphQL)
+		m.minimizeGraphQL(curl, baselineResp)
+		if m.firstRequired == "" {
+			m.passCompleted(PassGraphQL, curl)
+		}
+	}
+
+	// Decisions made after ctx was done are based on requests that were
+	// never sent
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
+	if context.Cause(runCtx) == errBudgetExhausted {
+		m.budgetExhausted("took longer than %v", m.options.MaxDuration)
+	}
+
+	if m.options.Verbose {
+		m.printSlowestDecisions()
 	}
 
 	// Convert the minimized curl command back to a string
 	minimizedCmd, err := curl.ToString()
 	if err != nil {
-		return "", fmt.Errorf("failed to convert minimized curl command to string: %w", err)
+		return "", nil, fmt.Errorf("failed to convert minimized curl command to string: %w", err)
+	}
+
+	// Make sure the rewritten command reflects every decision made
+	if err := verifyDecisions(curl, m.decisions); err != nil {
+		return "", nil, err
+	}
+
+	// Capture the final command's response alongside the baseline, unless
+	// the budget doesn't allow another request
+	if m.options.ReportDir != "" && m.Partial() {
+		m.warn("not writing the final response to the report directory, since the request budget is exhausted")
+	} else if m.options.ReportDir != "" {
+		finalResp, err := m.executeCurlCommand(minimizedCmd)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get final response: %w", err)
+		}
+		if err := writeReport(m.options.ReportDir, "final", minimizedCmd, finalResp); err != nil {
+			return "", nil, err
+		}
 	}
 
-	return minimizedCmd, nil
+	report := newReport(m.decisions, int(m.requests.Load()))
+	report.Partial = m.Partial()
+	report.Cookies = curl.cookieSet()
+	return minimizedCmd, report, nil
+}
+
+// context returns the context of the current run, or a background context
+// outside of one
+func (m *Minimizer) context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+// FirstRequired returns the first required element found by the last run when
+// StopAtFirstRequired is set, or an empty string if none was found
+func (m *Minimizer) FirstRequired() string {
+	return m.firstRequired
+}
+
+// shell returns the shell used to run commands
+func (m *Minimizer) shell() string {
+	if m.options.Shell == "" {
+		return "sh"
+	}
+	return m.options.Shell
+}
+
+// Warnings returns problems noticed by the last run that didn't stop it,
+// e.g. a signed URL that's about to expire
+func (m *Minimizer) Warnings() []string {
+	return slices.Clone(m.warnings)
+}
+
+// Comparison describes the comparison AutoCompare chose for the last run and
+// why, or returns "" if AutoCompare isn't set
+func (m *Minimizer) Comparison() string {
+	return m.comparison
+}
+
+// warn records a warning, printing it right away in verbose mode
+func (m *Minimizer) warn(msg string) {
+	if m.options.Verbose {
+		m.logf("Warning: %s\n", msg)
+	}
+	m.warnings = append(m.warnings, msg)
+}
+
+// stopAtRequired records a required element and reports whether minimization
+// should halt because StopAtFirstRequired is set
+func (m *Minimizer) stopAtRequired(kind, name string) bool {
+	// An element the budget didn't leave time to test isn't known to be required
+	if !m.options.StopAtFirstRequired || m.untested {
+		return false
+	}
+	if m.firstRequired == "" {
+		m.firstRequired = kind + " " + name
+	}
+	return true
+}
+
+// logHeader reports whether a verbose decision about a header should be logged,
+// applying LogFilter to the header's name
+func (m *Minimizer) logHeader(header string) bool {
+	if !m.options.Verbose {
+		return false
+	}
+	if m.logFilter == nil {
+		return true
+	}
+	name, _, _ := strings.Cut(header, ":")
+	return m.logFilter.MatchString(strings.TrimSpace(name))
 }
 
 // Response represents an HTTP response with its status code and body
 type Response struct {
 	StatusCode int
+	Status     string // e.g. "200 OK"
 	Body       string
+	// Header holds the response headers; RawHeaders is the header block as received
+	Header     http.Header
+	RawHeaders string
+	// Trailer holds any trailer fields sent after a chunked body. They're
+	// kept out of Header and RawHeaders since they often vary (checksums,
+	// timings) and are only compared with CompareTrailers.
+	Trailer http.Header
+	// SentHeaders holds the request line and headers curl sent, when TraceRequests is set
+	SentHeaders []string
+	// RedirectChain lists the Location of each redirect response received,
+	// in order (several when following redirects with -L)
+	RedirectChain []string
+	// TimedOut is set when the request timed out and TimeoutComparison is
+	// TimeoutMatchesTimeout; the other fields are empty
+	TimedOut bool
 }
 
+// curlExitTimeout is curl's exit code for an operation that timed out
+const curlExitTimeout = 28
+
+// TimeoutComparison controls how a request that times out (e.g. from the
+// command's own -m/--max-time) is compared against the baseline
+type TimeoutComparison int
+
+const (
+	// TimeoutDiffers treats a timed-out request as different from any
+	// baseline, keeping whatever element was removed
+	TimeoutDiffers TimeoutComparison = iota
+	// TimeoutMatchesTimeout treats a timed-out request as a response that
+	// matches a baseline that also timed out, so slow baselines can be
+	// minimized
+	TimeoutMatchesTimeout
+)
+
 func (m *Minimizer) executeCurlCommand(curlCmd string) (Response, error) {
+	// Refresh short-lived values before sending the request
+	if m.options.PreRequestHook != "" {
+		var err error
+		curlCmd, err = m.runPreRequestHook(curlCmd)
+		if err != nil {
+			return Response{}, err
+		}
+	}
+
+	if err := m.waitToSend(); err != nil {
+		return Response{}, err
+	}
+	if err := m.spendRequest(); err != nil {
+		return Response{}, err
+	}
+
+	start := time.Now()
+	var resp Response
+	var err error
+	switch {
+	case m.options.Executor != nil:
+		resp, err = m.executeCustom(curlCmd)
+	case m.options.Native:
+		resp, err = m.executeNative(curlCmd)
+	default:
+		resp, err = m.executeBinary(curlCmd)
+	}
+	if m.har != nil {
+		m.har.add(curlCmd, start, time.Since(start), resp, err)
+	}
+	return resp, err
+}
+
+// executeBinary runs a curl command with the curl binary
+func (m *Minimizer) executeBinary(curlCmd string) (Response, error) {
 	// Create a temporary file to store the response body
 	tmpFile, err := os.CreateTemp("", "curlmin-response-*.txt")
 	if err != nil {
@@ -124,17 +941,41 @@ func (m *Minimizer) executeCurlCommand(curlCmd string) (Response, error) {
 	// -D writes headers to a file, -o writes body to a file, -s is silent mode
 	curlCmd = fmt.Sprintf("%s -D %s -o %s -s", curlCmd, tmpHeaderFile.Name(), tmpFile.Name())
 
+	// Create a temporary file to store the request trace if enabled
+	var traceFile string
+	if m.options.TraceRequests {
+		tmpTraceFile, err := os.CreateTemp("", "curlmin-trace-*.txt")
+		if err != nil {
+			return Response{}, fmt.Errorf("failed to create temporary trace file: %w", err)
+		}
+		defer os.Remove(tmpTraceFile.Name())
+		tmpTraceFile.Close()
+
+		traceFile = tmpTraceFile.Name()
+		curlCmd = fmt.Sprintf("%s --trace-ascii %s", curlCmd, traceFile)
+	}
+
 	// Log the curl command if verbose mode is enabled
 	if m.options.Verbose {
-		fmt.Printf("Executing: %s\n", curlCmd)
+		m.logf("Executing: %s\n", curlCmd)
 	}
 
 	// Execute the curl command
-	cmd := exec.Command("sh", "-c", curlCmd)
+	cmd := exec.CommandContext(m.context(), m.shell(), "-c", curlCmd)
+	killGroupOnCancel(m.context(), cmd)
+	// Don't wait on a curl that outlives its shell after a cancellation
+	cmd.WaitDelay = time.Second
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 	err = cmd.Run()
 	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == curlExitTimeout && m.options.TimeoutComparison == TimeoutMatchesTimeout {
+			if m.options.Verbose {
+				m.logf("Request timed out\n")
+			}
+			return Response{TimedOut: true}, nil
+		}
 		return Response{}, fmt.Errorf("failed to execute curl command: %w, stderr: %s", err, stderr.String())
 	}
 
@@ -150,11 +991,15 @@ func (m *Minimizer) executeCurlCommand(curlCmd string) (Response, error) {
 		return Response{}, fmt.Errorf("failed to read headers from temporary file: %w", err)
 	}
 
-	// Parse the status code from the headers
+	// Separate any trailers from the header blocks
+	headerDump, trailer := splitTrailers(string(headerBytes))
+
+	// Parse the status code and reason phrase from the headers
 	statusCode := 0
-	headerLines := strings.Split(string(headerBytes), "\n")
+	status := ""
+	headerLines := strings.Split(headerDump, "\n")
 	if len(headerLines) > 0 {
-		statusLine := headerLines[0]
+		statusLine := strings.TrimSpace(headerLines[0])
 		parts := strings.Split(statusLine, " ")
 		if len(parts) >= 2 {
 			_, err := fmt.Sscanf(parts[1], "%d", &statusCode)
@@ -162,244 +1007,1066 @@ func (m *Minimizer) executeCurlCommand(curlCmd string) (Response, error) {
 				// If we can't parse the status code, default to 0
 				statusCode = 0
 			}
+			status = strings.Join(parts[1:], " ")
+		}
+	}
+
+	// Parse the sent request headers from the trace
+	var sentHeaders []string
+	if traceFile != "" {
+		traceBytes, err := os.ReadFile(traceFile)
+		if err != nil {
+			return Response{}, fmt.Errorf("failed to read trace from temporary file: %w", err)
+		}
+		sentHeaders = parseSentHeaders(string(traceBytes))
+
+		// Print in one go so concurrent requests' output doesn't interleave
+		if m.options.Verbose {
+			var out strings.Builder
+			out.WriteString("Sent request headers:\n")
+			for _, line := range sentHeaders {
+				fmt.Fprintf(&out, "  %s\n", line)
+			}
+			m.logf("%s", out.String())
 		}
 	}
 
 	// Return the response
 	return Response{
-		StatusCode: statusCode,
-		Body:       string(respBytes),
+		StatusCode:    statusCode,
+		Status:        status,
+		Body:          string(respBytes),
+		Header:        parseHeaders(headerDump),
+		Trailer:       trailer,
+		RedirectChain: parseRedirectChain(headerDump),
+		RawHeaders:    headerDump,
+		SentHeaders:   sentHeaders,
 	}, nil
 }
 
+// parseRedirectChain collects the Location header from every block of a -D
+// header dump, giving the redirect hops in the order they were followed
+func parseRedirectChain(dump string) []string {
+	var chain []string
+	for _, line := range strings.Split(dump, "\n") {
+		name, value, ok := strings.Cut(strings.TrimRight(line, "\r"), ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Location") {
+			chain = append(chain, strings.TrimSpace(value))
+		}
+	}
+	return chain
+}
+
+// splitTrailers separates the trailer fields that curl writes to a -D header
+// dump after the blank line ending the last header block, returning the
+// header blocks alone and the parsed trailers (nil if there are none)
+func splitTrailers(dump string) (string, http.Header) {
+	lines := strings.SplitAfter(dump, "\n")
+
+	// Find the blank line ending the last header block
+	end := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "HTTP/") {
+			end = -1
+		} else if end < 0 && strings.TrimRight(line, "\r\n") == "" {
+			end = i
+		}
+	}
+	if end < 0 {
+		return dump, nil
+	}
+
+	var trailer http.Header
+	for _, line := range lines[end+1:] {
+		name, value, ok := strings.Cut(strings.TrimRight(line, "\r\n"), ":")
+		if ok && name != "" {
+			if trailer == nil {
+				trailer = make(http.Header)
+			}
+			trailer.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	}
+	return strings.Join(lines[:end+1], ""), trailer
+}
+
+// parseHeaders parses the last header block of a -D header dump. Earlier
+// blocks belong to interim (1xx) or redirect responses.
+func parseHeaders(dump string) http.Header {
+	header := make(http.Header)
+	for _, line := range strings.Split(dump, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		// A status line starts a new block
+		if strings.HasPrefix(line, "HTTP/") {
+			header = make(http.Header)
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if ok && name != "" {
+			header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	}
+	return header
+}
+
+// trailersEqual reports whether two responses sent the same trailer fields
+func trailersEqual(a, b http.Header) bool {
+	return maps.EqualFunc(a, b, slices.Equal)
+}
+
+// mediaType returns the lowercased media type of a response's Content-Type,
+// without parameters
+func mediaType(resp Response) string {
+	contentType := resp.Header.Get("Content-Type")
+	if parsed, _, err := mime.ParseMediaType(contentType); err == nil {
+		return parsed
+	}
+	parsed, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(parsed))
+}
+
+// traceLineWidth is the number of data bytes curl's --trace-ascii prints per line
+const traceLineWidth = 64
+
+// parseSentHeaders extracts the request line and headers from the "Send header"
+// sections of a --trace-ascii dump
+func parseSentHeaders(trace string) []string {
+	var headers []string
+	inSendHeader := false
+	wrapped := false
+
+	for _, line := range strings.Split(trace, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		// Section markers start with "=>", "<=", or "=="
+		if strings.HasPrefix(line, "=>") || strings.HasPrefix(line, "<=") || strings.HasPrefix(line, "==") {
+			inSendHeader = strings.HasPrefix(line, "=> Send header")
+			wrapped = false
+			continue
+		}
+		if !inSendHeader {
+			continue
+		}
+
+		// Data lines look like "0000: GET / HTTP/1.1"
+		_, data, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+
+		// Lines longer than the trace width are split across multiple data lines
+		if wrapped && len(headers) > 0 {
+			headers[len(headers)-1] += data
+		} else if data != "" {
+			headers = append(headers, data)
+		}
+		wrapped = len(data) == traceLineWidth
+	}
+
+	return headers
+}
+
 func (m *Minimizer) compareResponses(resp1, resp2 Response) bool {
+	if m.assumeRemovable != nil {
+		return *m.assumeRemovable
+	}
+	if m.cache == nil {
+		return m.compareResponsesUncached(resp1, resp2)
+	}
+
+	// Reuse the result for a pair of responses compared before
+	key := comparisonKey{responseHash(resp1), responseHash(resp2), m.cacheScope}
+	if result, ok := m.cache.get(key); ok {
+		return result
+	}
+	result := m.compareResponsesUncached(resp1, resp2)
+	m.cache.put(key, result)
+	return result
+}
+
+func (m *Minimizer) compareResponsesUncached(resp1, resp2 Response) bool {
+	// A timed-out request only matches another timed-out request
+	if resp1.TimedOut || resp2.TimedOut {
+		return resp1.TimedOut && resp2.TimedOut
+	}
+
+	// A candidate that gains a forbidden pattern never matches
+	if m.gainsForbidden(resp1, resp2) {
+		return false
+	}
+
+	// Ignore HTML that commonly changes between identical page loads
+	if m.options.StripHTMLNoise {
+		resp1.Body = stripHTMLNoise(resp1.Body)
+		resp2.Body = stripHTMLNoise(resp2.Body)
+	}
+
+	if m.options.BodyNormalizer != nil {
+		resp1.Body = string(m.options.BodyNormalizer([]byte(resp1.Body)))
+		resp2.Body = string(m.options.BodyNormalizer([]byte(resp2.Body)))
+	}
+
+	// A match pattern only looks for its marker in the candidate's body
+	if m.options.MatchPattern != "" {
+		return m.bodyMatches(resp2.Body)
+	}
+
 	// Define comparison functions
 	comparisons := map[string]func(Response, Response) bool{
 		"status": func(r1, r2 Response) bool {
 			return r1.StatusCode == r2.StatusCode
 		},
+		"statustext": func(r1, r2 Response) bool {
+			return r1.Status == r2.Status
+		},
 		"body": func(r1, r2 Response) bool {
 			hash1 := md5.Sum([]byte(r1.Body))
 			hash2 := md5.Sum([]byte(r2.Body))
 			return hex.EncodeToString(hash1[:]) == hex.EncodeToString(hash2[:])
 		},
 		"words": func(r1, r2 Response) bool {
-			return len(strings.Fields(r1.Body)) == len(strings.Fields(r2.Body))
+			return m.countsMatch(len(strings.Fields(r1.Body)), len(strings.Fields(r2.Body)))
 		},
 		"lines": func(r1, r2 Response) bool {
-			return len(strings.Split(r1.Body, "\n")) == len(strings.Split(r2.Body, "\n"))
+			return m.countsMatch(len(strings.Split(r1.Body, "\n")), len(strings.Split(r2.Body, "\n")))
 		},
 		"bytes": func(r1, r2 Response) bool {
-			return len(r1.Body) == len(r2.Body)
+			return m.countsMatch(len(r1.Body), len(r2.Body))
+		},
+		"bodylines": func(r1, r2 Response) bool {
+			return slices.Equal(m.filterLines(r1.Body), m.filterLines(r2.Body))
+		},
+		"json": func(r1, r2 Response) bool {
+			return jsonEqualIgnoring(r1.Body, r2.Body, m.ignoreJSONPaths)
+		},
+		"redirects": func(r1, r2 Response) bool {
+			return slices.Equal(r1.RedirectChain, r2.RedirectChain)
 		},
+		"contenttype": func(r1, r2 Response) bool {
+			return mediaType(r1) == mediaType(r2)
+		},
+		"trailers": func(r1, r2 Response) bool {
+			return trailersEqual(r1.Trailer, r2.Trailer)
+		},
+		"headers": m.headersMatch,
+		"jq":      m.jqEqual,
+		"similarity": func(r1, r2 Response) bool {
+			score, ok := similarity(r1.Body, r2.Body, m.options.MinSimilarity)
+			if m.options.Verbose {
+				if ok {
+					m.logf("Similarity: %.4f (min %.4f)\n", score, m.options.MinSimilarity)
+				} else {
+					m.logf("Similarity: below %.4f\n", m.options.MinSimilarity)
+				}
+			}
+			return ok
+		},
+		"diffscore": func(r1, r2 Response) bool {
+			score, ok := diffScore(r1.Body, r2.Body, m.options.MaxDiffScore)
+			if m.options.Verbose {
+				if ok {
+					m.logf("Diff score: %.4f (max %.4f)\n", score, m.options.MaxDiffScore)
+				} else {
+					m.logf("Diff score: above %.4f\n", m.options.MaxDiffScore)
+				}
+			}
+			return ok
+		},
+	}
+
+	// Run all enabled comparisons
+	for key, enabled := range m.enabledComparisons() {
+		if enabled {
+			if !comparisons[key](resp1, resp2) {
+				return false
+			}
+		}
 	}
 
+	// If all selected comparisons pass, return true
+	return true
+}
+
+// enabledComparisons maps each comparison key to whether the options enable
+// it, falling back to comparing the body
+func (m *Minimizer) enabledComparisons() map[string]bool {
 	// Map options to comparison keys
 	optionsMap := map[string]bool{
-		"status": m.options.CompareStatusCode,
-		"body":   m.options.CompareBodyContent,
-		"words":  m.options.CompareWordCount,
-		"lines":  m.options.CompareLineCount,
-		"bytes":  m.options.CompareByteCount,
+		"status":      m.options.CompareStatusCode,
+		"statustext":  m.options.CompareStatusText,
+		"body":        m.options.CompareBodyContent,
+		"words":       m.options.CompareWordCount,
+		"lines":       m.options.CompareLineCount,
+		"bytes":       m.options.CompareByteCount,
+		"bodylines":   m.options.CompareBodyLines,
+		"json":        m.options.CompareJSON,
+		"redirects":   m.options.CompareRedirectChain,
+		"contenttype": m.options.CompareContentType,
+		"trailers":    m.options.CompareTrailers,
+		"headers":     len(m.options.CompareHeaders) > 0,
+		"jq":          m.options.JQExpression != "",
+		"diffscore":   m.options.MaxDiffScore > 0,
+		"similarity":  m.options.MinSimilarity > 0,
+	}
+
+	// Check if any comparison is enabled
+	anyEnabled := false
+	for _, enabled := range optionsMap {
+		if enabled {
+			anyEnabled = true
+			break
+		}
+	}
+
+	// If no comparison options are selected, default to body content
+	if !anyEnabled {
+		optionsMap["body"] = true
+	}
+
+	// When reproducing an error, the status code must always match too
+	if m.options.Reproduce {
+		optionsMap["status"] = true
+	}
+	return optionsMap
+}
+
+// countsMatch reports whether a response's count (of words, lines, or bytes)
+// is within CountTolerancePercent of the baseline's
+func (m *Minimizer) countsMatch(baseline, count int) bool {
+	diff := count - baseline
+	if diff < 0 {
+		diff = -diff
+	}
+	return float64(diff) <= float64(baseline)*m.options.CountTolerancePercent/100
+}
+
+// filterLines splits a body into lines, dropping any that match an ignore-line pattern
+func (m *Minimizer) filterLines(body string) []string {
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		ignored := false
+		for _, re := range m.ignoreLines {
+			if re.MatchString(line) {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func (m *Minimizer) minimizeQueryParams(curl *CurlCommand, baselineResp Response) {
+	// Parameters whose values have been tried already
+	valueTried := make(map[string]bool)
+	ddmin := m.options.Strategy == StrategyDDMin
+
+	// Process query parameters iteratively
+	for {
+		// Get the URL index
+		urlIndex, err := curl.FindURLArg()
+		if err != nil {
+			return
+		}
+
+		// Get the current URL
+		var buf bytes.Buffer
+		printer := syntax.NewPrinter()
+		printer.Print(&buf, curl.Command.Args[urlIndex])
+		urlStr := buf.String()
+		urlStr = strings.Trim(urlStr, "'\"")
+
+		// Parse the URL
+		parsedURL, err := url.Parse(urlStr)
+		if err != nil {
+			return
+		}
+
+		// If there are no query parameters, return
+		if parsedURL.RawQuery == "" {
+			return
+		}
+
+		// Parse the query parameters
+		query, err := url.ParseQuery(parsedURL.RawQuery)
+		if err != nil {
+			return
+		}
+
+		foundRemovable := false
+
+		// Signed URL parameters only work as a group
+		var signed map[string]string
+		if !m.options.MinimizeSignedParams {
+			signed = m.signedParams(query)
+		}
+
+		params := make([]string, 0, len(query))
+		for param := range query {
+			params = append(params, param)
+		}
+		if m.options.PreferShortest {
+			sortLongestFirst(params, func(param string) int {
+				return len(param) + len(strings.Join(query[param], ""))
+			})
+		}
+
+		// Pick the parameters to try removing
+		var candidates []string
+		for _, param := range params {
+			if reason := m.keepReason(KindQueryParam, param); reason != "" {
+				m.preserve(KindQueryParam, param, reason)
+				continue
+			}
+
+			if family, ok := signed[param]; ok {
+				if m.options.Verbose {
+					m.logf("Query parameter preserved for %s signed URL: %s\n", family, param)
+				}
+				continue
+			}
+			candidates = append(candidates, param)
+		}
+
+		// Remove groups of parameters at once before testing them one by one
+		if ddmin {
+			ddmin = false
+			removeParams := func(c *CurlCommand, names []string) error {
+				newURL := *parsedURL
+				for _, name := range names {
+					newURL.RawQuery = removeRawQueryParam(newURL.RawQuery, name)
+					if cookie, ok := m.pairedCookie(name); ok {
+						c.RemoveCookie(cookie)
+					}
+				}
+				return c.SetURLArg(newURL.String())
+			}
+			if removed := ddminRemovable(m, curl, baselineResp, candidates, removeParams); len(removed) > 0 {
+				for _, param := range removed {
+					if m.options.Verbose {
+						m.logf("Query parameter not needed: %s\n", param)
+					}
+					m.decide(KindQueryParam, param, true)
+					if cookie, ok := m.pairedCookie(param); ok && slices.Contains(curl.cookieSet(), cookie) {
+						if m.options.Verbose {
+							m.logf("Paired cookie not needed: %s\n", cookie)
+						}
+						m.decide(KindCookie, cookie, true)
+					}
+				}
+				removeParams(curl, removed)
+				continue
+			}
+		}
+
+		// newTests prepares the removal tests against the current URL
+		newTests := func() *candidateTests {
+			return m.newCandidateTests(curl, baselineResp, candidateFuncs(candidates, func(param string) func(*CurlCommand) error {
+				// Create a copy of the URL without this parameter, leaving the
+				// other parameters' encoding untouched
+				testURL := *parsedURL
+				testURL.RawQuery = removeRawQueryParam(parsedURL.RawQuery, param)
+
+				return func(c *CurlCommand) error {
+					// Find the URL index in the copy
+					copyUrlIndex, err := c.FindURLArg()
+					if err != nil {
+						return err
+					}
+
+					// Update the URL in the copy
+					word := &syntax.Word{
+						Parts: []syntax.WordPart{
+							&syntax.Lit{
+								Value: "'" + testURL.String() + "'",
+							},
+						},
+					}
+					c.Command.Args[copyUrlIndex] = word
+
+					// Remove any paired cookie along with the parameter
+					if cookie, ok := m.pairedCookie(param); ok {
+						c.RemoveCookie(cookie)
+					}
+					return nil
+				}
+			}))
+		}
+		tests := newTests()
+
+		// Try removing each parameter one by one
+		for k, param := range candidates {
+			// Test if this parameter can be removed
+			canRemove, err := tests.test(k)
+
+			if err == nil && canRemove {
+				if m.options.Verbose {
+					m.logf("Query parameter not needed: %s\n", param)
+				}
+				m.decide(KindQueryParam, param, true)
+				// If the response is the same, update the original curl command
+				// Create a new URL with the parameter removed
+				newURL := *parsedURL
+				newURL.RawQuery = removeRawQueryParam(parsedURL.RawQuery, param)
+				newQuery := maps.Clone(query)
+				delete(newQuery, param)
+
+				// Update the URL in the original command
+				word := &syntax.Word{
+					Parts: []syntax.WordPart{
+						&syntax.Lit{
+							Value: "'" + newURL.String() + "'",
+						},
+					},
+				}
+				curl.Command.Args[urlIndex] = word
+
+				// Remove any paired cookie along with the parameter
+				if cookie, ok := m.pairedCookie(param); ok && curl.RemoveCookie(cookie) {
+					if m.options.Verbose {
+						m.logf("Paired cookie not needed: %s\n", cookie)
+					}
+					m.decide(KindCookie, cookie, true)
+				}
+
+				// Update our working URL and query for the next iteration
+				parsedURL = &newURL
+				query = newQuery
+
+				foundRemovable = true
+				break
+			} else {
+				if m.options.Verbose {
+					m.logNeeded("Query parameter", param)
+				}
+				m.decide(KindQueryParam, param, false)
+				if m.stopAtRequired("query parameter", param) {
+					return
+				}
+				if m.options.MinimizeValues && !valueTried[param] {
+					valueTried[param] = true
+					if m.minimizeParamValue(curl, param, query.Get(param), baselineResp) {
+						// Continue from the URL with the new value
+						parsedURL, _ = url.Parse(curl.argString(urlIndex))
+						tests = newTests()
+					}
+				}
+			}
+		}
+
+		// If we didn't find any removable parameters in this iteration, we're done
+		if !foundRemovable {
+			return
+		}
+	}
+}
+
+// collapseDuplicateCookies tries dropping the extra copies of cookies sent by
+// more than one cookie argument (e.g. both -b and a Cookie header), keeping
+// the first copy of each
+func (m *Minimizer) collapseDuplicateCookies(curl *CurlCommand, baselineResp Response) {
+	// Cookies whose copies turned out to be needed
+	needed := make(map[string]bool)
+
+	for {
+		// Find the first later copy of a cookie that's worth testing
+		seen := make(map[string]bool)
+		dupIndex, dupName := -1, ""
+		for _, cookieIndex := range curl.FindCookieArgs() {
+			for _, name := range cookieNames(cookieValue(curl.argString(cookieIndex + 1))) {
+				if seen[name] && !needed[name] && dupIndex < 0 {
+					dupIndex, dupName = cookieIndex, name
+				}
+				seen[name] = true
+			}
+		}
+		if dupIndex < 0 {
+			return
+		}
+
+		isHeader := strings.HasPrefix(strings.ToLower(curl.argString(dupIndex+1)), "cookie:")
+		canRemove, err := m.testCookieRemoval(curl, dupIndex, dupName, isHeader, baselineResp)
+		if err == nil && canRemove {
+			if m.options.Verbose {
+				m.logf("Collapsed duplicate cookie: %s\n", dupName)
+			}
+			curl.RemoveCookieFromArg(dupIndex, dupName, isHeader)
+			m.decide(KindCookie, duplicateName(dupName), true)
+		} else {
+			if m.options.Verbose {
+				m.logNeeded("Duplicate cookie", dupName)
+			}
+			needed[dupName] = true
+		}
+	}
+}
+
+// trivialParamValues are tried, in order, in place of the value of a
+// required numeric or boolean query parameter
+var trivialParamValues = []string{"", "0", "1", "true"}
+
+// numericOrBoolPattern matches parameter values worth replacing with a trivial value
+var numericOrBoolPattern = regexp.MustCompile(`(?i)^(-?[0-9]+(\.[0-9]+)?|true|false)$`)
+
+// minimizeParamValue tries replacing the value of a required numeric or
+// boolean query parameter with a trivial one. If one is accepted, the
+// parameter only needs to be present and its value doesn't matter; the
+// parameter keeps the trivial value and is marked as such in its decision.
+func (m *Minimizer) minimizeParamValue(curl *CurlCommand, param, value string, baselineResp Response) bool {
+	if !numericOrBoolPattern.MatchString(value) {
+		return false
+	}
+
+	for _, candidate := range trivialParamValues {
+		if candidate == value {
+			continue
+		}
+
+		canReplace, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+			c.SetQueryParamValue(param, candidate)
+			return nil
+		})
+
+		if err == nil && canReplace {
+			if m.options.Verbose {
+				m.logf("Query parameter value irrelevant: %s (%q -> %q)\n", param, value, candidate)
+			}
+			curl.SetQueryParamValue(param, candidate)
+			m.markAnyValue(KindQueryParam, param)
+			return true
+		}
+	}
+
+	if m.options.Verbose {
+		m.logf("Query parameter value required: %s=%s\n", param, value)
+	}
+	return false
+}
+
+// minimizeURLQueryArgs tries removing each --url-query argument, keeping the
+// ones that are needed in their --url-query form
+func (m *Minimizer) minimizeURLQueryArgs(curl *CurlCommand, baselineResp Response) {
+	// Process --url-query arguments iteratively
+	for {
+		queryIndices := curl.FindURLQueryArgs()
+		if len(queryIndices) == 0 {
+			return
+		}
+
+		if m.options.PreferShortest {
+			sortLongestFirst(queryIndices, func(i int) int {
+				return len(curl.argString(i + 1))
+			})
+		}
+
+		foundRemovable := false
+
+		// Pick the --url-query arguments to try removing
+		var candidates []int
+		for _, queryIndex := range queryIndices {
+			name, _, _ := strings.Cut(strings.TrimPrefix(curl.literalArg(queryIndex+1), "+"), "=")
+			if unescaped, err := url.QueryUnescape(name); err == nil {
+				name = unescaped
+			}
+			if reason := m.keepReason(KindQueryParam, name); reason != "" {
+				m.preserve(KindQueryParam, curl.argString(queryIndex+1), reason)
+				continue
+			}
+			candidates = append(candidates, queryIndex)
+		}
+
+		tests := m.newCandidateTests(curl, baselineResp, candidateFuncs(candidates, func(queryIndex int) func(*CurlCommand) error {
+			return func(c *CurlCommand) error {
+				c.RemoveArg(queryIndex)
+				return nil
+			}
+		}))
+
+		// Try removing each --url-query argument one by one
+		for k, queryIndex := range candidates {
+			param := curl.argString(queryIndex + 1)
+
+			canRemove, err := tests.test(k)
+
+			if err == nil && canRemove {
+				if m.options.Verbose {
+					m.logf("Query parameter not needed: %s\n", param)
+				}
+				m.decide(KindQueryParam, param, true)
+				curl.RemoveArg(queryIndex)
+				foundRemovable = true
+				break
+			} else {
+				if m.options.Verbose {
+					m.logNeeded("Query parameter", param)
+				}
+				m.decide(KindQueryParam, param, false)
+				if m.stopAtRequired("query parameter", param) {
+					return
+				}
+			}
+		}
+
+		// If we didn't find any removable arguments in this iteration, we're done
+		if !foundRemovable {
+			return
+		}
+	}
+}
+
+// collapseDuplicateParams removes exact-duplicate query parameters (e.g. ?a=1&a=1)
+// in a single test, and reports parameters that also appear in a -d request body
+func (m *Minimizer) collapseDuplicateParams(curl *CurlCommand, baselineResp Response) {
+	urlIndex, err := curl.FindURLArg()
+	if err != nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	printer := syntax.NewPrinter()
+	printer.Print(&buf, curl.Command.Args[urlIndex])
+	urlStr := strings.Trim(buf.String(), "'\"")
+
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil || parsedURL.RawQuery == "" {
+		return
 	}
 
-	// Check if any comparison is enabled
-	anyEnabled := false
-	for _, enabled := range optionsMap {
-		if enabled {
-			anyEnabled = true
-			break
+	// Keep the first occurrence of each name=value pair
+	seen := make(map[string]bool)
+	var kept, collapsed []string
+	for _, pair := range strings.Split(parsedURL.RawQuery, "&") {
+		if seen[pair] {
+			collapsed = append(collapsed, pair)
+			continue
 		}
+		seen[pair] = true
+		kept = append(kept, pair)
 	}
 
-	// If no comparison options are selected, default to body content
-	if !anyEnabled {
-		optionsMap["body"] = true
-	}
+	if len(collapsed) > 0 {
+		dedupedURL := *parsedURL
+		dedupedURL.RawQuery = strings.Join(kept, "&")
 
-	// Run all enabled comparisons
-	for key, enabled := range optionsMap {
-		if enabled {
-			if !comparisons[key](resp1, resp2) {
-				return false
+		canRemove, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+			return c.SetURLArg(dedupedURL.String())
+		})
+
+		if err == nil && canRemove {
+			curl.SetURLArg(dedupedURL.String())
+			for _, pair := range collapsed {
+				if m.options.Verbose {
+					m.logf("Collapsed duplicate query parameter: %s\n", pair)
+				}
+				name, _, _ := strings.Cut(pair, "=")
+				m.decide(KindQueryParam, duplicateName(name), true)
 			}
+		} else if m.options.Verbose {
+			m.logNeeded("Duplicate query parameters", strings.Join(collapsed, ", "))
 		}
 	}
 
-	// If all selected comparisons pass, return true
-	return true
-}
-
-func (m *Minimizer) minimizeQueryParams(curl *CurlCommand, baselineResp Response) {
-	// Process query parameters iteratively
-	for {
-		// Get the URL index
-		urlIndex, err := curl.FindURLArg()
+	// Flag parameters that are sent in both the query string and the body
+	query, err := url.ParseQuery(parsedURL.RawQuery)
+	if err != nil {
+		return
+	}
+	for _, dataIndex := range curl.FindDataArgs() {
+		var dataBuf bytes.Buffer
+		printer.Print(&dataBuf, curl.Command.Args[dataIndex+1])
+		body, err := url.ParseQuery(strings.Trim(dataBuf.String(), "'\""))
 		if err != nil {
-			return
+			continue
+		}
+		for name := range body {
+			if _, ok := query[name]; ok && m.options.Verbose {
+				m.logf("Query parameter also present in request body: %s\n", name)
+			}
 		}
+	}
+}
 
-		// Get the current URL
-		var buf bytes.Buffer
-		printer := syntax.NewPrinter()
-		printer.Print(&buf, curl.Command.Args[urlIndex])
-		urlStr := buf.String()
-		urlStr = strings.Trim(urlStr, "'\"")
+// dropStaleMultipartHeader removes a manually-specified multipart Content-Type
+// header from commands that build their body with -F. curl appends its own
+// boundary to such a header, so a copied boundary would conflict with the one
+// curl actually uses (and go stale as soon as form fields change). It
+// returns the header it removed, if any.
+func (m *Minimizer) dropStaleMultipartHeader(curl *CurlCommand) string {
+	if len(curl.FindFormArgs()) == 0 {
+		return ""
+	}
 
-		// Parse the URL
-		parsedURL, err := url.Parse(urlStr)
-		if err != nil {
-			return
+	for _, headerIndex := range curl.FindHeaderArgs() {
+		headerStr := curl.argString(headerIndex + 1)
+		name, value, _ := strings.Cut(headerStr, ":")
+		if !strings.EqualFold(strings.TrimSpace(name), "content-type") {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(value)), "multipart/") {
+			continue
 		}
 
-		// If there are no query parameters, return
-		if parsedURL.RawQuery == "" {
-			return
+		if m.options.Verbose {
+			m.logf("Dropping multipart Content-Type header so curl computes the boundary for -F: %s\n", headerStr)
 		}
+		curl.RemoveArg(headerIndex)
+		return headerStr
+	}
+	return ""
+}
 
-		// Parse the query parameters
-		query, err := url.ParseQuery(parsedURL.RawQuery)
-		if err != nil {
+// minimizeHeaderValue tries replacing a required header's value with shorter
+// equivalents, keeping the first one that doesn't change the response
+func (m *Minimizer) minimizeHeaderValue(curl *CurlCommand, headerIndex int, baselineResp Response) {
+	headerStr := curl.argString(headerIndex + 1)
+
+	// Split the argument into a header name and value
+	name, isFlag := headerFlags[curl.argString(headerIndex)]
+	value := headerStr
+	if !isFlag {
+		var ok bool
+		name, value, ok = strings.Cut(headerStr, ":")
+		if !ok {
 			return
 		}
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+	}
 
-		foundRemovable := false
+	for _, candidate := range shorterHeaderValues(name, value) {
+		newArg := candidate
+		if !isFlag {
+			newArg = name + ": " + candidate
+		}
 
-		// Try removing each parameter one by one
-		for param := range query {
-			// Skip the auth_key parameter as it's required
-			if param == "auth_key" {
-				continue
-			}
+		canShorten, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+			return c.SetArg(headerIndex+1, newArg)
+		})
 
-			// Create a copy of the query parameters without this parameter
-			testQuery := make(url.Values)
-			for k, v := range query {
-				if k != param {
-					testQuery[k] = v
-				}
+		if err == nil && canShorten {
+			if m.logHeader(name) {
+				m.logf("Header value shortened: %s: %s -> %s\n", name, value, candidate)
 			}
+			curl.SetArg(headerIndex+1, newArg)
+			return
+		}
+	}
+}
 
-			// Create a copy of the URL with the updated query parameters
-			testURL := *parsedURL
-			testURL.RawQuery = testQuery.Encode()
-
-			// Test if this parameter can be removed
-			canRemove, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
-				// Find the URL index in the copy
-				copyUrlIndex, err := c.FindURLArg()
-				if err != nil {
-					return err
-				}
-
-				// Update the URL in the copy
-				word := &syntax.Word{
-					Parts: []syntax.WordPart{
-						&syntax.Lit{
-							Value: "'" + testURL.String() + "'",
-						},
-					},
-				}
-				c.Command.Args[copyUrlIndex] = word
-				return nil
-			})
-
-			if err == nil && canRemove {
-				if m.options.Verbose {
-					fmt.Printf("Query parameter not needed: %s\n", param)
-				}
-				// If the response is the same, update the original curl command
-				// Create a new URL with the parameter removed
-				newURL := *parsedURL
-				newQuery := make(url.Values)
-				for k, v := range query {
-					if k != param {
-						newQuery[k] = v
-					}
-				}
-				newURL.RawQuery = newQuery.Encode()
-
-				// Update the URL in the original command
-				word := &syntax.Word{
-					Parts: []syntax.WordPart{
-						&syntax.Lit{
-							Value: "'" + newURL.String() + "'",
-						},
-					},
-				}
-				curl.Command.Args[urlIndex] = word
+// shorterHeaderValues returns shorter candidate values for a header, in order
+// of preference
+func shorterHeaderValues(name, value string) []string {
+	switch strings.ToLower(name) {
+	case "referer":
+		// Servers that check the referer usually only care about its origin
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return nil
+		}
+		origin := parsed.Scheme + "://" + parsed.Host + "/"
+		if origin != value {
+			return []string{origin}
+		}
+	case "authorization":
+		// See whether the server checks the password, or the user at all
+		user, password, ok := parseBasicAuth(value)
+		if !ok || password == "" {
+			return nil
+		}
+		if user == "" {
+			return []string{basicAuthValue("", "")}
+		}
+		return []string{basicAuthValue("", ""), basicAuthValue(user, "")}
+	case "user-agent":
+		// Servers that sniff the user agent usually only look at the first
+		// product (e.g. Mozilla/5.0)
+		product, _, ok := strings.Cut(value, " ")
+		if ok && product != "" {
+			return []string{product}
+		}
+	}
+	return nil
+}
 
-				// Update our working URL and query for the next iteration
-				parsedURL = &newURL
-				query = newQuery
+// jointlyRemovable narrows down elements that are each removable on their
+// own to ones that can be removed together: all of them if possible (e.g.
+// tracking headers), otherwise those that can be added one at a time in order
+// (e.g. only one of two interchangeable tokens)
+func jointlyRemovable[T any](m *Minimizer, curl *CurlCommand, baselineResp Response, candidates []T, remove func(*CurlCommand, []T) error) []T {
+	canRemove := func(subset []T) bool {
+		ok, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+			return remove(c, subset)
+		})
+		return err == nil && ok
+	}
 
-				foundRemovable = true
-				break
-			} else if m.options.Verbose {
-				fmt.Printf("Query parameter needed: %s\n", param)
-			}
-		}
+	if len(candidates) < 2 || canRemove(candidates) {
+		return candidates
+	}
+	if m.options.Verbose {
+		m.logf("Not removable together, verifying one at a time\n")
+	}
 
-		// If we didn't find any removable parameters in this iteration, we're done
-		if !foundRemovable {
-			return
+	// The first is known to be removable on its own
+	removable := []T{candidates[0]}
+	for _, candidate := range candidates[1:] {
+		if canRemove(append(slices.Clone(removable), candidate)) {
+			removable = append(removable, candidate)
 		}
 	}
+	return removable
+}
+
+// framingHeaders are the headers curl relies on to transmit a request body
+// intact; they're preserved rather than tested when the command has a body
+var framingHeaders = map[string]bool{
+	"content-length":    true,
+	"transfer-encoding": true,
 }
 
 func (m *Minimizer) minimizeHeaders(curl *CurlCommand, baselineResp Response) {
+	hasBody := curl.HasBody()
+
+	if m.options.CosmeticHeadersFirst {
+		m.removeCosmeticHeaders(curl, baselineResp)
+	}
+
+	ddmin := m.options.Strategy == StrategyDDMin
+
 	// Process headers iteratively
 	for {
-		// Find header arguments
-		headerIndices := curl.FindHeaderArgs()
+		// Find header arguments, including flags that set a header (e.g. -A)
+		// and auth flags (e.g. --oauth2-bearer)
+		headerIndices := append(curl.FindHeaderArgs(), curl.FindHeaderFlagArgs()...)
+		headerIndices = append(headerIndices, curl.FindAuthArgs()...)
+		if m.options.MinimizeTLS {
+			headerIndices = append(headerIndices, curl.FindTLSArgs()...)
+		}
 		if len(headerIndices) == 0 {
 			return
 		}
+		slices.Sort(headerIndices)
 
 		foundRemovable := false
 
-		// Try removing each header one by one
+		// Headers removable on their own, when removing them all at once
+		var removable []int
+		stop := false
+
+		if m.options.PreferShortest {
+			sortLongestFirst(headerIndices, func(i int) int {
+				return len(curl.argString(i + 1))
+			})
+		}
+
+		// Pick the headers to try removing
+		var candidates []int
 		for _, headerIndex := range headerIndices {
+			headerName := curl.headerArgName(headerIndex)
+
 			// Skip cookie headers as they are handled separately
-			var headerBuf bytes.Buffer
-			printer := syntax.NewPrinter()
-			if headerIndex+1 < len(curl.Command.Args) {
-				printer.Print(&headerBuf, curl.Command.Args[headerIndex+1])
-				headerStr := headerBuf.String()
-				headerStr = strings.Trim(headerStr, "'\"")
-				if strings.HasPrefix(strings.ToLower(headerStr), "cookie:") {
+			if strings.HasPrefix(strings.ToLower(headerName), "cookie:") {
+				continue
+			}
+
+			if reason := m.keepReason(KindHeader, headerElementName(headerName)); reason != "" {
+				m.preserve(KindHeader, headerName, reason)
+				continue
+			}
+
+			// Skip body framing headers so the body always transmits correctly
+			if hasBody {
+				name, _, _ := strings.Cut(headerName, ":")
+				if framingHeaders[strings.ToLower(strings.TrimSpace(name))] {
+					if m.logHeader(headerName) {
+						m.logf("Header preserved for body framing: %s\n", headerName)
+					}
 					continue
 				}
 			}
+			candidates = append(candidates, headerIndex)
+		}
 
-			// Get the header name for logging
-			var headerName string
-			if headerIndex+1 < len(curl.Command.Args) {
-				var headerBuf bytes.Buffer
-				printer := syntax.NewPrinter()
-				printer.Print(&headerBuf, curl.Command.Args[headerIndex+1])
-				headerStr := headerBuf.String()
-				headerStr = strings.Trim(headerStr, "'\"")
-				headerName = headerStr
+		// Remove groups of headers at once before testing them one by one
+		if ddmin {
+			ddmin = false
+			if removed := ddminRemovable(m, curl, baselineResp, candidates, removeArgs); len(removed) > 0 {
+				for _, headerIndex := range removed {
+					headerName := curl.headerArgName(headerIndex)
+					if m.logHeader(headerName) {
+						m.logf("Header not needed: %s\n", headerName)
+					}
+					m.decide(KindHeader, headerName, true)
+				}
+				removeArgs(curl, removed)
+				continue
 			}
+		}
 
-			// Test if this header can be removed
-			canRemove, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+		tests := m.newCandidateTests(curl, baselineResp, candidateFuncs(candidates, func(headerIndex int) func(*CurlCommand) error {
+			return func(c *CurlCommand) error {
 				c.RemoveArg(headerIndex)
 				return nil
-			})
+			}
+		}))
 
-			if err == nil && canRemove {
+		// Try removing each header one by one
+		for k, headerIndex := range candidates {
+			flag := curl.argString(headerIndex)
+			isFlag := authFlags[flag] || tlsFlags[flag]
+
+			// Get the header name for logging
+			headerName := curl.headerArgName(headerIndex)
+
+			// Test if this header can be removed
+			canRemove, err := tests.test(k)
+
+			if err == nil && canRemove && m.options.GreedyPass {
+				removable = append(removable, headerIndex)
+			} else if err == nil && canRemove {
 				// If the response is the same, update the original curl command
-				if m.options.Verbose {
-					fmt.Printf("Header not needed: %s\n", headerName)
+				if m.logHeader(headerName) {
+					m.logf("Header not needed: %s\n", headerName)
 				}
+				m.decide(KindHeader, headerName, true)
 				curl.RemoveArg(headerIndex)
 				foundRemovable = true
 				break
-			} else if m.options.Verbose {
-				fmt.Printf("Header needed: %s\n", headerName)
+			} else {
+				if m.logHeader(headerName) {
+					m.logNeeded("Header", headerName)
+				}
+				m.decide(KindHeader, headerName, false)
+				m.noteBasicAuth(headerName)
+				if m.stopAtRequired("header", headerName) {
+					stop = true
+					break
+				}
+				if m.options.MinimizeValues && !isFlag {
+					m.minimizeHeaderValue(curl, headerIndex, baselineResp)
+					tests.invalidate(k)
+				}
+			}
+		}
+
+		if len(removable) > 0 {
+			removable = jointlyRemovable(m, curl, baselineResp, removable, removeArgs)
+			slices.Sort(removable)
+			for _, headerIndex := range slices.Backward(removable) {
+				headerName := curl.headerArgName(headerIndex)
+				if m.logHeader(headerName) {
+					m.logf("Header not needed: %s\n", headerName)
+				}
+				m.decide(KindHeader, headerName, true)
+				curl.RemoveArg(headerIndex)
 			}
+			foundRemovable = true
+		}
+		if stop {
+			return
 		}
 
 		// If we didn't find any removable headers in this iteration, we're done
@@ -409,43 +2076,169 @@ func (m *Minimizer) minimizeHeaders(curl *CurlCommand, baselineResp Response) {
 	}
 }
 
+// sortLongestFirst orders removal candidates by descending length, keeping the
+// original order for candidates of equal length
+func sortLongestFirst[T any](candidates []T, length func(T) int) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return length(candidates[i]) > length(candidates[j])
+	})
+}
+
+// pairedCookie returns the cookie paired with a query parameter, if any
+func (m *Minimizer) pairedCookie(param string) (string, bool) {
+	for _, pair := range m.options.PairedParamCookie {
+		if pair[0] == param {
+			return pair[1], true
+		}
+	}
+	return "", false
+}
+
+// pairedParam returns the query parameter paired with a cookie, if that
+// parameter is present in the command's URL
+func (m *Minimizer) pairedParam(curl *CurlCommand, cookieName string) (string, bool) {
+	if len(m.options.PairedParamCookie) == 0 {
+		return "", false
+	}
+
+	params, _ := curl.FindQueryParams()
+	for _, pair := range m.options.PairedParamCookie {
+		if pair[1] != cookieName {
+			continue
+		}
+		if _, ok := params[pair[0]]; ok {
+			return pair[0], true
+		}
+	}
+	return "", false
+}
+
+// containsPairedCookie reports whether a cookie argument's value includes a
+// cookie paired with a query parameter present in the URL
+func (m *Minimizer) containsPairedCookie(curl *CurlCommand, cookieStr string) bool {
+	if strings.HasPrefix(strings.ToLower(cookieStr), "cookie:") {
+		cookieStr = cookieStr[len("cookie:"):]
+	}
+	for _, name := range cookieNames(cookieStr) {
+		if _, ok := m.pairedParam(curl, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// containsKeptCookie reports whether a cookie argument's value includes a
+// cookie that's always kept
+func (m *Minimizer) containsKeptCookie(cookieStr string) bool {
+	return slices.ContainsFunc(cookieNames(cookieValue(cookieStr)), func(name string) bool {
+		return m.keeps(KindCookie, name)
+	})
+}
+
 // testCookieRemoval tests if removing a specific cookie affects the response
 // Returns true if the cookie can be removed, false if it's needed
 // testModification tests if a modification to the curl command affects the response
 // The modifyFunc is called on a copy of the curl command to make the modification
 // Returns true if the modification doesn't affect the response, false if it does
 func (m *Minimizer) testModification(curl *CurlCommand, baselineResp Response, modifyFunc func(*CurlCommand) error) (bool, error) {
+	return m.record(m.runTest(curl, baselineResp, modifyFunc))
+}
+
+// testResult is the outcome of testing a modification of the command
+type testResult struct {
+	canRemove bool
+	err       error
+	// difference describes how the response differed, if it did
+	difference string
+	// elapsed is how long the test request took
+	elapsed time.Duration
+	// command is the candidate command that was tested
+	command string
+}
+
+// runTest tests a modification like testModification, but leaves recording
+// the result to the caller, so that tests can run concurrently
+func (m *Minimizer) runTest(curl *CurlCommand, baselineResp Response, modifyFunc func(*CurlCommand) error) testResult {
 	// Create a copy of the curl command
 	originalCmd, err := curl.ToString()
 	if err != nil {
-		return false, err
+		return testResult{err: err}
 	}
 
+	// The command under test is always a safe point to resume from
+	m.setCheckpointCommand(originalCmd)
+
 	curlCopy, err := ParseCurlCommand(originalCmd)
 	if err != nil {
-		return false, err
+		return testResult{err: err}
 	}
 
 	// Apply the modification
 	err = modifyFunc(curlCopy)
 	if err != nil {
-		return false, err
+		return testResult{err: err}
 	}
 
 	// Convert to string and test
 	testCmd, err := curlCopy.ToString()
 	if err != nil {
-		return false, err
+		return testResult{err: err}
 	}
 
-	// Execute the test command
-	testResp, err := m.executeCurlCommand(testCmd)
-	if err != nil {
-		return false, err
+	// Execute the test command, timing it for the element being tested, and
+	// again for each confirmation run once it matches
+	start := time.Now()
+	for run := 0; run <= m.options.ConfirmRuns; run++ {
+		execute := m.executeCurlCommand
+		if run == 0 {
+			execute = m.executeCandidate
+		}
+		testResp, err := execute(testCmd)
+		if err != nil {
+			return testResult{err: err, elapsed: time.Since(start), difference: fmt.Sprintf("removing makes the request fail: %v", err), command: testCmd}
+		}
+
+		// Compare responses
+		if !m.compareResponses(baselineResp, testResp) {
+			if run > 0 && m.options.Verbose {
+				m.logf("Confirmation run %d of %d didn't match\n", run, m.options.ConfirmRuns)
+			}
+			return testResult{elapsed: time.Since(start), difference: m.describeRejection(baselineResp, testResp), command: testCmd}
+		}
+	}
+	return testResult{canRemove: true, elapsed: time.Since(start), command: testCmd}
+}
+
+// describeRejection explains why a candidate response doesn't match the
+// baseline, using the most specific explanation for the comparison in use
+func (m *Minimizer) describeRejection(baselineResp, testResp Response) string {
+	if m.gainsForbidden(baselineResp, testResp) {
+		return fmt.Sprintf("removing adds %q to the body", m.forbid.FindString(testResp.Body))
+	}
+	if m.options.MatchPattern != "" {
+		return m.describeMatchLoss(baselineResp, testResp)
+	}
+	if change := m.describeHeaderChange(baselineResp, testResp); change != "" {
+		return change
 	}
+	if change := m.describeJQChange(baselineResp, testResp); change != "" {
+		return change
+	}
+	return describeDifference(baselineResp, testResp)
+}
 
-	// Compare responses
-	return m.compareResponses(baselineResp, testResp), nil
+// record charges a test's time to the next decision and keeps how its
+// response differed for the decision's reason
+func (m *Minimizer) record(result testResult) (bool, error) {
+	m.testTime += result.elapsed
+	m.untested = m.outOfBudget(result.err)
+	if result.difference != "" {
+		m.lastDifference = result.difference
+	}
+	if result.command != "" {
+		m.progress(ProgressEvent{Type: ProgressCandidateTested, Command: result.command, Removable: result.canRemove})
+	}
+	return result.canRemove, result.err
 }
 
 func (m *Minimizer) testCookieRemoval(curl *CurlCommand, cookieIndex int, cookieName string, isHeader bool, baselineResp Response) (bool, error) {
@@ -455,6 +2248,9 @@ func (m *Minimizer) testCookieRemoval(curl *CurlCommand, cookieIndex int, cookie
 }
 
 func (m *Minimizer) minimizeCookies(curl *CurlCommand, baselineResp Response) {
+	// Cookies already part of a ddmin search
+	ddminTried := make(map[string]bool)
+
 	// Process cookies iteratively
 	for {
 		// Find cookie arguments
@@ -465,6 +2261,12 @@ func (m *Minimizer) minimizeCookies(curl *CurlCommand, baselineResp Response) {
 
 		foundRemovable := false
 
+		if m.options.PreferShortest {
+			sortLongestFirst(cookieIndices, func(i int) int {
+				return len(curl.argString(i + 1))
+			})
+		}
+
 		// Process each cookie header
 		for _, cookieIndex := range cookieIndices {
 			var headerBuf bytes.Buffer
@@ -485,45 +2287,57 @@ func (m *Minimizer) minimizeCookies(curl *CurlCommand, baselineResp Response) {
 				// Determine if this is a Cookie header or a cookie flag
 				isHeader := strings.HasPrefix(strings.ToLower(headerStr), "cookie:")
 
-				// First, try removing the entire cookie argument
-				canRemove, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
-					c.RemoveArg(cookieIndex)
-					return nil
-				})
+				// First, try removing the entire cookie argument, unless it carries a
+				// cookie paired with a query parameter (tested with it in the param pass)
+				canRemove, err := false, error(nil)
+				if !m.containsPairedCookie(curl, headerStr) && !m.containsKeptCookie(headerStr) {
+					canRemove, err = m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+						c.RemoveArg(cookieIndex)
+						return nil
+					})
+				}
 
 				if err == nil && canRemove {
 					// If the response is the same, update the original curl command
 					if m.options.Verbose {
 						if isHeader {
-							fmt.Printf("Cookie header not needed: %s\n", flagName)
+							m.logf("Cookie header not needed: %s\n", flagName)
 						} else {
-							fmt.Printf("Cookie flag not needed: %s\n", flagName)
+							m.logf("Cookie flag not needed: %s\n", flagName)
 						}
 					}
+					for _, name := range cookieNames(cookieValue(headerStr)) {
+						m.decide(KindCookie, name, true)
+					}
 					curl.RemoveArg(cookieIndex)
 					foundRemovable = true
 					break
 				} else if m.options.Verbose {
 					if isHeader {
-						fmt.Printf("Cookie header needed, testing individual cookies\n")
+						m.logf("Cookie header needed, testing individual cookies\n")
 					} else {
-						fmt.Printf("Cookie flag needed, testing individual cookies\n")
+						m.logf("Cookie flag needed, testing individual cookies\n")
 					}
 				}
 
 				// If we can't remove the entire argument, try removing individual cookies
-				var cookieStr string
-				if isHeader {
-					cookieStr = strings.TrimPrefix(headerStr, "Cookie:")
-					cookieStr = strings.TrimPrefix(cookieStr, "cookie:")
-				} else {
-					cookieStr = headerStr
-				}
+				cookieStr := cookieValue(headerStr)
+
+				// Cookies removable on their own, when removing them all at once
+				var removableCookies []string
+				stop := false
 
 				cookies := strings.Split(cookieStr, ";")
+				if m.options.PreferShortest {
+					sortLongestFirst(cookies, func(cookie string) int {
+						return len(strings.TrimSpace(cookie))
+					})
+				}
+				// Pick the cookies to try removing
+				var candidates []string
 				for _, cookie := range cookies {
 					cookie = strings.TrimSpace(cookie)
-					if cookie == "" {
+					if cookie == "" || isCookieAttribute(cookie) {
 						continue
 					}
 
@@ -531,28 +2345,105 @@ func (m *Minimizer) minimizeCookies(curl *CurlCommand, baselineResp Response) {
 					if len(parts) == 2 {
 						cookieName := strings.TrimSpace(parts[0])
 
-						// Test if this cookie can be removed
-						canRemove, err := m.testCookieRemoval(curl, cookieIndex, cookieName, isHeader, baselineResp)
-						if err != nil {
+						if reason := m.keepReason(KindCookie, cookieName); reason != "" {
+							m.preserve(KindCookie, cookieName, reason)
+							continue
+						}
+
+						// Paired cookies are tested together with their query parameter
+						if param, ok := m.pairedParam(curl, cookieName); ok {
+							if m.options.Verbose {
+								m.logf("Cookie paired with query parameter %s: %s\n", param, cookieName)
+							}
 							continue
 						}
+						candidates = append(candidates, cookieName)
+					}
+				}
 
-						if canRemove {
-							// If the response is the same, update the original curl command
+				// Remove groups of cookies at once before testing them one by
+				// one, once per cookie argument
+				untried := slices.DeleteFunc(slices.Clone(candidates), func(name string) bool { return ddminTried[name] })
+				if m.options.Strategy == StrategyDDMin && len(untried) > 0 {
+					for _, name := range untried {
+						ddminTried[name] = true
+					}
+					removeCookies := func(c *CurlCommand, names []string) error {
+						return c.removeCookiesFromArg(cookieIndex, names, isHeader)
+					}
+					if removed := ddminRemovable(m, curl, baselineResp, untried, removeCookies); len(removed) > 0 {
+						for _, name := range removed {
 							if m.options.Verbose {
-								fmt.Printf("Cookie not needed: %s\n", cookieName)
+								m.logf("Cookie not needed: %s\n", name)
 							}
+							m.decide(KindCookie, name, true)
+						}
+						removeCookies(curl, removed)
+						foundRemovable = true
+						break
+					}
+				}
+
+				tests := m.newCandidateTests(curl, baselineResp, candidateFuncs(candidates, func(cookieName string) func(*CurlCommand) error {
+					return func(c *CurlCommand) error {
+						return c.RemoveCookieFromArg(cookieIndex, cookieName, isHeader)
+					}
+				}))
+
+				for k, cookieName := range candidates {
+					// Test if this cookie can be removed
+					canRemove, err := tests.test(k)
+					if err != nil {
+						continue
+					}
 
-							curl.RemoveCookieFromArg(cookieIndex, cookieName, isHeader)
+					if canRemove && m.options.GreedyPass {
+						removableCookies = append(removableCookies, cookieName)
+					} else if canRemove {
+						// If the response is the same, update the original curl command
+						if m.options.Verbose {
+							m.logf("Cookie not needed: %s\n", cookieName)
+						}
+						m.decide(KindCookie, cookieName, true)
+
+						curl.RemoveCookieFromArg(cookieIndex, cookieName, isHeader)
 
-							foundRemovable = true
+						foundRemovable = true
+						break
+					} else {
+						if m.options.Verbose {
+							m.logNeeded("Cookie", cookieName)
+						}
+						m.decide(KindCookie, cookieName, false)
+						if m.stopAtRequired("cookie", cookieName) {
+							stop = true
 							break
-						} else if m.options.Verbose {
-							fmt.Printf("Cookie needed: %s\n", cookieName)
 						}
 					}
 				}
 
+				if len(removableCookies) > 0 {
+					removableCookies = jointlyRemovable(m, curl, baselineResp, removableCookies, func(c *CurlCommand, names []string) error {
+						for _, name := range names {
+							if err := c.RemoveCookieFromArg(cookieIndex, name, isHeader); err != nil {
+								return err
+							}
+						}
+						return nil
+					})
+					for _, name := range removableCookies {
+						if m.options.Verbose {
+							m.logf("Cookie not needed: %s\n", name)
+						}
+						m.decide(KindCookie, name, true)
+						curl.RemoveCookieFromArg(cookieIndex, name, isHeader)
+					}
+					foundRemovable = true
+				}
+				if stop {
+					return
+				}
+
 				if foundRemovable {
 					break
 				}
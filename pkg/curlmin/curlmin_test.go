@@ -1,11 +1,22 @@
 package curlmin
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestMinimizeCurlCommand(t *testing.T) {
@@ -165,3 +176,1108 @@ func TestMinimizeCurlCommand(t *testing.T) {
 		t.Errorf("Params-only minimized command is missing the required auth_key parameter")
 	}
 }
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestNativeTransport(t *testing.T) {
+	// Answer requests in-process instead of over the network
+	requests := 0
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		recorder := httptest.NewRecorder()
+		if r.Header.Get("Authorization") == "Bearer xyz789" {
+			fmt.Fprint(recorder, "Success")
+		} else {
+			recorder.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(recorder, "Unauthorized")
+		}
+		return recorder.Result(), nil
+	})
+
+	minimizer := New(Options{
+		MinimizeHeaders: true,
+		Native:          true,
+		Transport:       transport,
+	})
+
+	minimizedCmd, err := minimizer.MinimizeCurlCommand(`curl -H 'Authorization: Bearer xyz789' -H 'Accept: text/html' 'http://example.invalid/api/test'`)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	if requests == 0 {
+		t.Errorf("Custom transport was not used")
+	}
+
+	if !strings.Contains(minimizedCmd, "Authorization: Bearer xyz789") {
+		t.Errorf("Minimized command is missing the required Authorization header")
+	}
+
+	if strings.Contains(minimizedCmd, "Accept: text/html") {
+		t.Errorf("Minimized command contains unnecessary header: Accept: text/html")
+	}
+}
+
+func TestToHTTPRequestData(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "body.txt")
+	if err := os.WriteFile(file, []byte("a=1\nb=2 3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args string
+		want string
+	}{
+		{`-d @` + file, "a=1b=2 3"},
+		{`--data-binary @` + file, "a=1\nb=2 3\n"},
+		{`--data-raw @` + file, "@" + file},
+		{`--data-urlencode 'q=a b&c'`, "q=a%20b%26c"},
+		{`--data-urlencode q@` + file, "q=a%3D1%0Ab%3D2%203%0A"},
+	}
+	for _, tt := range tests {
+		curl, err := ParseCurlCommand("curl " + tt.args + " http://example.com/")
+		if err != nil {
+			t.Fatalf("ParseCurlCommand() error = %v", err)
+		}
+		req, err := curl.ToHTTPRequest()
+		if err != nil {
+			t.Fatalf("ToHTTPRequest(%s) error = %v", tt.args, err)
+		}
+		body, _ := io.ReadAll(req.Body)
+		if string(body) != tt.want {
+			t.Errorf("ToHTTPRequest(%s) body = %q, want %q", tt.args, body, tt.want)
+		}
+	}
+
+	curl, _ := ParseCurlCommand("curl -d @missing.txt http://example.com/")
+	if _, err := curl.ToHTTPRequest(); err == nil {
+		t.Error("Expected an error for a missing data file")
+	}
+}
+
+func TestToHTTPRequestUnsupported(t *testing.T) {
+	for _, args := range []string{
+		`-F 'file=@upload.txt'`,
+		`--form-string 'a=1'`,
+		`-T upload.txt`,
+		`-x http://proxy.invalid:8080`,
+		`-b cookies.txt`,
+	} {
+		curl, err := ParseCurlCommand("curl " + args + " http://example.com/")
+		if err != nil {
+			t.Fatalf("ParseCurlCommand() error = %v", err)
+		}
+		if _, err := curl.ToHTTPRequest(); err == nil {
+			t.Errorf("ToHTTPRequest(%s) succeeded, want an error", args)
+		}
+	}
+
+	// A native run fails up front instead of dropping the form parts
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		recorder := httptest.NewRecorder()
+		fmt.Fprint(recorder, "Success")
+		return recorder.Result(), nil
+	})
+	minimizer := New(Options{MinimizeHeaders: true, Native: true, Transport: transport})
+	if _, err := minimizer.MinimizeCurlCommand(`curl -F 'a=1' -F 'b=2' 'http://example.invalid/upload'`); err == nil {
+		t.Error("Expected an error minimizing a multipart form natively")
+	}
+}
+
+func TestMinimizeTLSPinnedCommand(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer xyz789" {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "Unauthorized")
+		}
+	}))
+	defer server.Close()
+
+	tlsFlags := []string{"--tlsv1.2", "--tls-max 1.2", "--ciphers ECDHE-RSA-AES128-GCM-SHA256"}
+	curlCmd := fmt.Sprintf(`curl -k --tlsv1.2 --tls-max 1.2 --ciphers ECDHE-RSA-AES128-GCM-SHA256 -H 'Authorization: Bearer xyz789' -H 'Accept: text/html' '%s/api/test'`, server.URL)
+
+	// TLS flags are preserved by default
+	minimizedCmd, err := New(Options{MinimizeHeaders: true}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	for _, flag := range tlsFlags {
+		if !strings.Contains(minimizedCmd, flag) {
+			t.Errorf("Minimized command is missing the TLS flag: %s", flag)
+		}
+	}
+	if strings.Contains(minimizedCmd, "Accept: text/html") {
+		t.Errorf("Minimized command contains unnecessary header: Accept: text/html")
+	}
+
+	// With MinimizeTLS, they're removed when the server doesn't need them
+	minimizedCmd, err = New(Options{MinimizeHeaders: true, MinimizeTLS: true}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	for _, flag := range tlsFlags {
+		if strings.Contains(minimizedCmd, flag) {
+			t.Errorf("Minimized command contains unnecessary TLS flag: %s", flag)
+		}
+	}
+	if !strings.Contains(minimizedCmd, "Authorization: Bearer xyz789") {
+		t.Errorf("Minimized command is missing the required Authorization header")
+	}
+}
+
+func TestCompareContentType(t *testing.T) {
+	response := func(contentType, body string) Response {
+		return Response{StatusCode: 200, Header: http.Header{"Content-Type": {contentType}}, Body: body}
+	}
+
+	minimizer := New(Options{CompareContentType: true})
+	baseline := response("application/json; charset=utf-8", `{"user":"admin"}`)
+
+	if !minimizer.compareResponses(baseline, response("Application/JSON", `{"user":"guest"}`)) {
+		t.Error("compareResponses() = false for the same media type with different parameters")
+	}
+	if minimizer.compareResponses(baseline, response("text/html; charset=utf-8", "<html>Log in</html>")) {
+		t.Error("compareResponses() = true for a different media type")
+	}
+}
+
+func TestBodyNormalizer(t *testing.T) {
+	timestamp := regexp.MustCompile(`"ts":\d+`)
+	minimizer := New(Options{BodyNormalizer: func(body []byte) []byte {
+		return timestamp.ReplaceAll(body, []byte(`"ts":0`))
+	}})
+
+	baseline := Response{StatusCode: 200, Body: `{"user":"admin","ts":1700000000}`}
+	if !minimizer.compareResponses(baseline, Response{StatusCode: 200, Body: `{"user":"admin","ts":1700000042}`}) {
+		t.Error("compareResponses() = false for bodies that differ only where normalized")
+	}
+	if minimizer.compareResponses(baseline, Response{StatusCode: 200, Body: `{"user":"guest","ts":1700000042}`}) {
+		t.Error("compareResponses() = true for bodies that differ after normalizing")
+	}
+}
+
+func TestMinimizeKeepsArgOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessionCookie, err := r.Cookie("session")
+		if r.Header.Get("Authorization") == "Bearer xyz789" && r.URL.Query().Get("auth_key") == "def456" && err == nil && sessionCookie.Value == "abc123" {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "Unauthorized")
+		}
+	}))
+	defer server.Close()
+
+	// Interleave the elements that survive with ones that don't, and with
+	// flags that are never minimized
+	curlCmd := fmt.Sprintf(`curl -s -H 'Accept: text/html' -b 'session=abc123' --compressed -H 'Authorization: Bearer xyz789' '%s/api/test?utm_source=test&auth_key=def456' -b '_ga=GA1.2.123' -X GET -H 'Cache-Control: max-age=0' --max-time 10`, server.URL)
+
+	minimizedCmd, err := New(Options{
+		MinimizeHeaders: true,
+		MinimizeCookies: true,
+		MinimizeParams:  true,
+	}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	// Compare argument sequences, treating the URL (whose query changes) as
+	// a single placeholder
+	args := func(cmd string) []string {
+		curl, err := ParseCurlCommand(cmd)
+		if err != nil {
+			t.Fatalf("Failed to parse curl command: %v", err)
+		}
+		urlIndex, err := curl.FindURLArg()
+		if err != nil {
+			t.Fatalf("Failed to find URL: %v", err)
+		}
+		var args []string
+		for i := range curl.Command.Args {
+			if i == urlIndex {
+				args = append(args, "URL")
+			} else {
+				args = append(args, curl.argString(i))
+			}
+		}
+		return args
+	}
+	original, minimized := args(curlCmd), args(minimizedCmd)
+
+	// Every surviving argument must appear in the original, in the same order
+	next := 0
+	for _, arg := range minimized {
+		for next < len(original) && original[next] != arg {
+			next++
+		}
+		if next == len(original) {
+			t.Fatalf("Minimized command %q doesn't keep the order of %q", minimizedCmd, curlCmd)
+		}
+		next++
+	}
+
+	want := []string{"curl", "-s", "-b", "session=abc123", "--compressed", "-H", "Authorization: Bearer xyz789", "URL", "-X", "GET", "--max-time", "10"}
+	if strings.Join(minimized, " ") != strings.Join(want, " ") {
+		t.Errorf("Minimized command has args %q, want %q", minimized, want)
+	}
+}
+
+func TestMinimizeUserAgentFlag(t *testing.T) {
+	// Only browsers are let in, except to public pages
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/public" || strings.HasPrefix(r.UserAgent(), "Mozilla/") {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "Forbidden")
+		}
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -A 'Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0' '%s/'`, server.URL)
+	minimizedCmd, err := New(Options{MinimizeHeaders: true, MinimizeValues: true}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if want := fmt.Sprintf(`curl -A 'Mozilla/5.0' '%s/'`, server.URL); strings.TrimSpace(minimizedCmd) != want {
+		t.Errorf("Minimized command is %q, want %q", minimizedCmd, want)
+	}
+
+	// An unneeded user agent is removed like any header
+	curlCmd = fmt.Sprintf(`curl --user-agent 'Mozilla/5.0' '%s/public'`, server.URL)
+	minimizedCmd, err = New(Options{MinimizeHeaders: true}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if strings.Contains(minimizedCmd, "--user-agent") {
+		t.Errorf("Minimized command %q contains the unnecessary --user-agent", minimizedCmd)
+	}
+}
+
+func TestErrorBaseline(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/")
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+	curlCmd := fmt.Sprintf(`curl -H 'X-A: 1' '%s/'`, server.URL)
+
+	// Error baselines are only refused with RequireSuccess
+	if _, err := New(Options{MinimizeHeaders: true}).MinimizeCurlCommand(curlCmd); err != nil {
+		t.Errorf("Failed on an error baseline: %v", err)
+	}
+	if _, err := New(Options{MinimizeHeaders: true, RequireSuccess: true}).MinimizeCurlCommand(curlCmd); !errors.Is(err, ErrBaseline) {
+		t.Errorf("Error for an error baseline with RequireSuccess is %v, want ErrBaseline", err)
+	}
+	if _, err := New(Options{MinimizeHeaders: true, RequireSuccess: true, Reproduce: true}).MinimizeCurlCommand(curlCmd); err != nil {
+		t.Errorf("Reproduce mode failed on an error baseline: %v", err)
+	}
+
+	// Redirects are fine
+	status.Store(http.StatusFound)
+	if _, err := New(Options{MinimizeHeaders: true, RequireSuccess: true}).MinimizeCurlCommand(curlCmd); err != nil {
+		t.Errorf("Failed on a redirect baseline: %v", err)
+	}
+}
+
+func TestGreedyPass(t *testing.T) {
+	// Either X-Token or X-Alt-Token works, but one of them is needed
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		session, err := r.Cookie("session")
+		if r.Header.Get("Authorization") == "Bearer xyz789" && (r.Header.Get("X-Token") != "" || r.Header.Get("X-Alt-Token") != "") && err == nil && session.Value == "abc123" {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "Unauthorized")
+		}
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz789' -H 'X-Token: a' -H 'X-Alt-Token: b' -H 'Accept: text/html' -H 'Accept-Language: en-US' -H 'Cache-Control: max-age=0' -H 'Connection: keep-alive' -H 'Upgrade-Insecure-Requests: 1' -b 'session=abc123; _ga=1; _gid=2; _fbp=3; _gat=4' '%s/'`, server.URL)
+
+	minimize := func(greedy bool) (string, int) {
+		requests.Store(0)
+		minimizedCmd, err := New(Options{
+			MinimizeHeaders: true,
+			MinimizeCookies: true,
+			GreedyPass:      greedy,
+		}).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		return strings.TrimSpace(minimizedCmd), int(requests.Load())
+	}
+
+	sequentialCmd, sequentialRequests := minimize(false)
+	greedyCmd, greedyRequests := minimize(true)
+
+	// Only one of the interchangeable tokens may be removed
+	if want := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz789' -H 'X-Alt-Token: b' -b 'session=abc123' '%s/'`, server.URL); greedyCmd != want {
+		t.Errorf("Greedy minimized command is %q, want %q", greedyCmd, want)
+	}
+	if greedyCmd != sequentialCmd {
+		t.Errorf("Greedy minimized command %q differs from sequential %q", greedyCmd, sequentialCmd)
+	}
+	if greedyRequests >= sequentialRequests {
+		t.Errorf("Greedy pass made %d requests, sequential %d", greedyRequests, sequentialRequests)
+	}
+}
+
+func TestTrailers(t *testing.T) {
+	dump := "HTTP/1.1 200 OK\r\nTrailer: X-Checksum\r\nTransfer-Encoding: chunked\r\n\r\nX-Checksum: abc\r\n"
+	headers, trailer := splitTrailers(dump)
+	if want := "HTTP/1.1 200 OK\r\nTrailer: X-Checksum\r\nTransfer-Encoding: chunked\r\n\r\n"; headers != want {
+		t.Errorf("splitTrailers() headers = %q, want %q", headers, want)
+	}
+	if got := trailer.Get("X-Checksum"); got != "abc" {
+		t.Errorf("splitTrailers() trailer X-Checksum = %q, want %q", got, "abc")
+	}
+	if _, trailer := splitTrailers("HTTP/1.1 302 Found\r\nLocation: /\r\n\r\nHTTP/1.1 200 OK\r\n\r\n"); trailer != nil {
+		t.Errorf("splitTrailers() found trailers %v in a dump without any", trailer)
+	}
+
+	// The trailer echoes a request header that doesn't affect the body
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Debug-Id")
+		fmt.Fprint(w, "Success")
+		w.(http.Flusher).Flush()
+		w.Header().Set("X-Debug-Id", r.Header.Get("X-Debug-Id"))
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'X-Debug-Id: 42' '%s/'`, server.URL)
+
+	m := New(Options{})
+	resp, err := m.executeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to execute curl command: %v", err)
+	}
+	if got := resp.Trailer.Get("X-Debug-Id"); got != "42" {
+		t.Errorf("Trailer X-Debug-Id = %q, want %q", got, "42")
+	}
+	if resp.Header.Get("X-Debug-Id") != "" || strings.Contains(resp.RawHeaders, "X-Debug-Id:") {
+		t.Errorf("trailer leaked into the response headers: %q", resp.RawHeaders)
+	}
+
+	// Trailers are ignored by default, and compared on request
+	for _, compareTrailers := range []bool{false, true} {
+		minimizedCmd, err := New(Options{MinimizeHeaders: true, CompareTrailers: compareTrailers}).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		if kept := strings.Contains(minimizedCmd, "X-Debug-Id"); kept != compareTrailers {
+			t.Errorf("with CompareTrailers %v, minimized command is %q", compareTrailers, minimizedCmd)
+		}
+	}
+}
+
+func TestCountTolerance(t *testing.T) {
+	minimizer := New(Options{CompareByteCount: true, CountTolerancePercent: 5})
+	baseline := Response{StatusCode: 200, Body: strings.Repeat("a", 100)}
+
+	if !minimizer.compareResponses(baseline, Response{StatusCode: 200, Body: strings.Repeat("a", 105)}) {
+		t.Error("compareResponses() = false for a body size within the tolerance")
+	}
+	if minimizer.compareResponses(baseline, Response{StatusCode: 200, Body: strings.Repeat("a", 94)}) {
+		t.Error("compareResponses() = true for a body size outside the tolerance")
+	}
+}
+
+func TestCompareHeaders(t *testing.T) {
+	response := func(status, body string) Response {
+		return Response{StatusCode: 200, Header: http.Header{"X-Request-Status": {status}}, Body: body}
+	}
+
+	minimizer := New(Options{CompareHeaders: []string{"x-request-status"}})
+	baseline := response("ok", `{"ts":1700000000}`)
+
+	if !minimizer.compareResponses(baseline, response("ok", `{"ts":1700000042}`)) {
+		t.Error("compareResponses() = false for the same header value with a different body")
+	}
+	if minimizer.compareResponses(baseline, response("denied", `{"ts":1700000000}`)) {
+		t.Error("compareResponses() = true for a different header value")
+	}
+	if got, want := minimizer.describeHeaderChange(baseline, response("denied", "")), `removing changes header x-request-status "ok" -> "denied"`; got != want {
+		t.Errorf("describeHeaderChange() = %q, want %q", got, want)
+	}
+}
+
+func TestConfirmRuns(t *testing.T) {
+	// Without the Authorization header, every other response happens to
+	// look like a success
+	var unauthorized atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xyz" && unauthorized.Add(1)%2 == 0 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz' '%s/'`, server.URL)
+	for _, tc := range []struct {
+		confirmRuns int
+		want        string
+	}{
+		{0, fmt.Sprintf(`curl '%s/'`, server.URL)},
+		{1, curlCmd},
+	} {
+		unauthorized.Store(0)
+		minimizedCmd, err := New(Options{MinimizeHeaders: true, ConfirmRuns: tc.confirmRuns}).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		if got := strings.TrimSpace(minimizedCmd); got != tc.want {
+			t.Errorf("With ConfirmRuns %d, minimized command is %q, want %q", tc.confirmRuns, got, tc.want)
+		}
+	}
+}
+
+func TestCollapseDuplicateCookies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("session"); err == nil && cookie.Value == "abc123" {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "Unauthorized")
+		}
+	}))
+	defer server.Close()
+
+	// The session cookie is sent by both -b and a Cookie header
+	m := New(Options{MinimizeCookies: true})
+	minimizedCmd, report, err := m.MinimizeCurlCommandWithReport(fmt.Sprintf(`curl -b 'session=abc123; theme=dark' -H 'Cookie: session=abc123' '%s/'`, server.URL))
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	want := fmt.Sprintf(`curl -b 'session=abc123' '%s/'`, server.URL)
+	if got := strings.TrimSpace(minimizedCmd); got != want {
+		t.Errorf("Minimized command is %q, want %q", got, want)
+	}
+	if want := []string{"session (duplicate)", "theme"}; !slices.Equal(report.RemovedCookies, want) {
+		t.Errorf("Removed cookies are %v, want %v", report.RemovedCookies, want)
+	}
+	if want := []string{"session"}; !slices.Equal(report.Cookies, want) {
+		t.Errorf("Cookie set is %v, want %v", report.Cookies, want)
+	}
+}
+
+func TestCollapseDuplicateParams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") == "42" {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Bad request")
+		}
+	}))
+	defer server.Close()
+
+	m := New(Options{MinimizeParams: true})
+	minimizedCmd, report, err := m.MinimizeCurlCommandWithReport(fmt.Sprintf(`curl '%s/?id=42&ref=home&id=42'`, server.URL))
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	want := fmt.Sprintf(`curl '%s/?id=42'`, server.URL)
+	if got := strings.TrimSpace(minimizedCmd); got != want {
+		t.Errorf("Minimized command is %q, want %q", got, want)
+	}
+	if want := []string{"id (duplicate)", "ref"}; !slices.Equal(report.RemovedParams, want) {
+		t.Errorf("Removed params are %v, want %v", report.RemovedParams, want)
+	}
+}
+
+func TestDropStaleMultipartHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		fmt.Fprintf(w, "token=%s", r.FormValue("token"))
+	}))
+	defer server.Close()
+
+	header := "Content-Type: multipart/form-data; boundary=----stale"
+	curlCmd := fmt.Sprintf(`curl -H '%s' -F 'token=abc' '%s/'`, header, server.URL)
+
+	m := New(Options{MinimizeHeaders: true})
+	minimizedCmd, err := m.MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if want := fmt.Sprintf(`curl -F 'token=abc' '%s/'`, server.URL); strings.TrimSpace(minimizedCmd) != want {
+		t.Errorf("Minimized command is %q, want %q", minimizedCmd, want)
+	}
+	if i := slices.IndexFunc(m.Decisions(), func(d Decision) bool { return d.Name == header }); i < 0 || !m.Decisions()[i].Removed {
+		t.Errorf("Decisions %+v don't record removing %q", m.Decisions(), header)
+	}
+
+	// The header is left alone when headers aren't minimized
+	minimizedCmd, err = New(Options{MinimizeCookies: true}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if !strings.Contains(minimizedCmd, header) {
+		t.Errorf("Minimized command %q is missing %q", minimizedCmd, header)
+	}
+}
+
+func TestParseSentHeaders(t *testing.T) {
+	tests := []struct {
+		name  string
+		trace string
+		want  []string
+	}{
+		{
+			name: "request line and headers",
+			trace: `== Info: Connected to example.com (127.0.0.1) port 80
+=> Send header, 57 bytes (0x39)
+0000: GET /api HTTP/1.1
+0013: Host: example.com
+0026: Accept: */*
+0033: 
+<= Recv header, 17 bytes (0x11)
+0000: HTTP/1.1 200 OK
+`,
+			want: []string{"GET /api HTTP/1.1", "Host: example.com", "Accept: */*"},
+		},
+		{
+			name: "header wrapped across lines",
+			trace: `=> Send header, 80 bytes (0x50)
+0000: GET / HTTP/1.1
+0010: X-Long: aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+0050: bbb
+0053: 
+`,
+			want: []string{"GET / HTTP/1.1", "X-Long: aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaabbb"},
+		},
+		{
+			name: "body isn't a header",
+			trace: `=> Send header, 16 bytes (0x10)
+0000: POST / HTTP/1.1
+=> Send data, 7 bytes (0x7)
+0000: a=1&b=2
+`,
+			want: []string{"POST / HTTP/1.1"},
+		},
+		{
+			name:  "no trace",
+			trace: "",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := parseSentHeaders(tt.trace); !slices.Equal(got, tt.want) {
+			t.Errorf("parseSentHeaders() for %s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestKeepBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("a") == "1" {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Bad request")
+		}
+	}))
+	defer server.Close()
+
+	// Content-Length frames the body, so it's kept along with it
+	curlCmd := fmt.Sprintf(`curl -H 'X-Requested-With: XMLHttpRequest' -H 'Content-Length: 7' -d 'a=1&b=2' '%s/'`, server.URL)
+	tests := []struct {
+		keepBody bool
+		want     string
+	}{
+		{false, fmt.Sprintf(`curl -H 'Content-Length: 3' -d 'a=1' '%s/'`, server.URL)},
+		{true, fmt.Sprintf(`curl -H 'Content-Length: 7' -d 'a=1&b=2' '%s/'`, server.URL)},
+	}
+	for _, tt := range tests {
+		minimizedCmd, err := New(Options{MinimizeHeaders: true, MinimizeBody: true, KeepBody: tt.keepBody}).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		if got := strings.TrimSpace(minimizedCmd); got != tt.want {
+			t.Errorf("Minimized command with KeepBody %v is %q, want %q", tt.keepBody, got, tt.want)
+		}
+	}
+}
+
+func TestCompareStatusText(t *testing.T) {
+	// The reason phrase says whether the request was authenticated
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reason := "OK"
+		if r.Header.Get("X-Auth") == "1" {
+			reason = "Authenticated"
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(conn, "HTTP/1.1 200 %s\r\nContent-Length: 7\r\nConnection: close\r\n\r\nSuccess", reason)
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'X-Auth: 1' -H 'Accept: text/html' '%s/'`, server.URL)
+	tests := []struct {
+		compareStatusText bool
+		want              string
+	}{
+		{false, fmt.Sprintf(`curl '%s/'`, server.URL)},
+		{true, fmt.Sprintf(`curl -H 'X-Auth: 1' '%s/'`, server.URL)},
+	}
+	for _, tt := range tests {
+		minimizedCmd, err := New(Options{MinimizeHeaders: true, CompareStatusCode: true, CompareStatusText: tt.compareStatusText}).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		if got := strings.TrimSpace(minimizedCmd); got != tt.want {
+			t.Errorf("Minimized command with CompareStatusText %v is %q, want %q", tt.compareStatusText, got, tt.want)
+		}
+	}
+}
+
+func TestStopAtFirstRequired(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer xyz789" && r.Header.Get("X-Api-Key") == "k" {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "Unauthorized")
+		}
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'Accept: text/html' -H 'Authorization: Bearer xyz789' -H 'X-Api-Key: k' -H 'Cache-Control: no-cache' '%s/'`, server.URL)
+
+	// The full run removes both unneeded headers
+	minimizedCmd, err := New(Options{MinimizeHeaders: true}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if want := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz789' -H 'X-Api-Key: k' '%s/'`, server.URL); strings.TrimSpace(minimizedCmd) != want {
+		t.Errorf("Minimized command is %q, want %q", minimizedCmd, want)
+	}
+
+	// Stopping at the Authorization header never gets to Cache-Control
+	m := New(Options{MinimizeHeaders: true, StopAtFirstRequired: true})
+	minimizedCmd, err = m.MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if want := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz789' -H 'X-Api-Key: k' -H 'Cache-Control: no-cache' '%s/'`, server.URL); strings.TrimSpace(minimizedCmd) != want {
+		t.Errorf("Minimized command is %q, want %q", minimizedCmd, want)
+	}
+	if got, want := m.FirstRequired(), "Authorization: Bearer xyz789"; !strings.Contains(got, want) {
+		t.Errorf("FirstRequired() = %q, want it to name %q", got, want)
+	}
+}
+
+func TestPreferShortest(t *testing.T) {
+	// Either token works, but one of them is needed
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-T") != "" || r.Header.Get("X-Session-Token") != "" {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "Unauthorized")
+		}
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'X-T: s' -H 'X-Session-Token: 0123456789abcdef0123456789abcdef' '%s/'`, server.URL)
+	tests := []struct {
+		preferShortest bool
+		want           string
+	}{
+		{false, fmt.Sprintf(`curl -H 'X-Session-Token: 0123456789abcdef0123456789abcdef' '%s/'`, server.URL)},
+		{true, fmt.Sprintf(`curl -H 'X-T: s' '%s/'`, server.URL)},
+	}
+	for _, tt := range tests {
+		minimizedCmd, err := New(Options{MinimizeHeaders: true, PreferShortest: tt.preferShortest}).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		if got := strings.TrimSpace(minimizedCmd); got != tt.want {
+			t.Errorf("Minimized command with PreferShortest %v is %q, want %q", tt.preferShortest, got, tt.want)
+		}
+	}
+}
+
+func TestLogFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	minimizedCmd, err := New(Options{
+		MinimizeHeaders: true,
+		Verbose:         true,
+		Logger:          log.New(&buf, "", 0),
+		LogFilter:       "^X-",
+	}).MinimizeCurlCommand(fmt.Sprintf(`curl -H 'X-Api-Key: abc' -H 'Accept: */*' -H 'X-Debug: 1' '%s/'`, server.URL))
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	// Only the logging is filtered, not the minimization
+	if want := fmt.Sprintf(`curl -H 'X-Api-Key: abc' '%s/'`, server.URL); strings.TrimSpace(minimizedCmd) != want {
+		t.Errorf("Minimized command is %q, want %q", minimizedCmd, want)
+	}
+	for _, want := range []string{"Header needed: X-Api-Key: abc\n", "Header not needed: X-Debug: 1\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Log doesn't contain %q:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "Header not needed: Accept") {
+		t.Errorf("Log has a decision on the filtered out Accept header:\n%s", buf.String())
+	}
+}
+
+func TestCompareBodyLines(t *testing.T) {
+	// Every response carries a fresh timestamp line
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := "denied"
+		if r.Header.Get("X-Api-Key") == "abc" {
+			status = "ok"
+		}
+		fmt.Fprintf(w, "status: %s\ntimestamp: %d\n", status, requests.Add(1))
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'X-Api-Key: abc' -H 'Accept: */*' '%s/'`, server.URL)
+	tests := []struct {
+		ignoreLines []string
+		want        string
+	}{
+		// Nothing matches the baseline, so everything is kept
+		{nil, curlCmd},
+		{[]string{`^timestamp:`}, fmt.Sprintf(`curl -H 'X-Api-Key: abc' '%s/'`, server.URL)},
+	}
+	for _, tt := range tests {
+		minimizedCmd, err := New(Options{MinimizeHeaders: true, CompareBodyLines: true, IgnoreLinePatterns: tt.ignoreLines}).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		if got := strings.TrimSpace(minimizedCmd); got != tt.want {
+			t.Errorf("Minimized command ignoring %q is %q, want %q", tt.ignoreLines, got, tt.want)
+		}
+	}
+}
+
+func TestPairedParamCookie(t *testing.T) {
+	// A double-submit check: the csrf param must match the csrf cookie
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var cookie string
+		if c, err := r.Cookie("csrf"); err == nil {
+			cookie = c.Value
+		}
+		if r.URL.Query().Get("csrf") == cookie {
+			fmt.Fprint(w, "Success")
+		} else {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "Forbidden")
+		}
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -b 'csrf=t0k3n; theme=dark' '%s/?csrf=t0k3n&page=1'`, server.URL)
+	tests := []struct {
+		pairs [][2]string
+		want  string
+	}{
+		// Removing either one alone breaks the check
+		{nil, fmt.Sprintf(`curl -b 'csrf=t0k3n' '%s/?csrf=t0k3n'`, server.URL)},
+		// Removed together, the check passes
+		{[][2]string{{"csrf", "csrf"}}, fmt.Sprintf(`curl '%s/'`, server.URL)},
+	}
+	for _, tt := range tests {
+		minimizedCmd, err := New(Options{MinimizeCookies: true, MinimizeParams: true, PairedParamCookie: tt.pairs}).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		if got := strings.TrimSpace(minimizedCmd); got != tt.want {
+			t.Errorf("Minimized command with pairs %v is %q, want %q", tt.pairs, got, tt.want)
+		}
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Success")
+	}))
+	serverURL := server.URL
+
+	if _, err := New(Options{MinimizeHeaders: true}).MinimizeCurlCommand(fmt.Sprintf(`curl -H 'X-A: 1' '%s/`, serverURL)); !errors.Is(err, ErrParse) {
+		t.Errorf("Error for an unterminated quote is %v, want ErrParse", err)
+	}
+	if _, err := New(Options{MinimizeHeaders: true}).MinimizeCurlCommand(fmt.Sprintf(`curl -H 'X-A: 1' '%s/'`, serverURL)); err != nil {
+		t.Errorf("Failed to minimize curl command: %v", err)
+	}
+
+	// Once the server is gone, the baseline request fails
+	server.Close()
+	if _, err := New(Options{MinimizeHeaders: true}).MinimizeCurlCommand(fmt.Sprintf(`curl -H 'X-A: 1' '%s/'`, serverURL)); !errors.Is(err, ErrBaseline) {
+		t.Errorf("Error for an unreachable server is %v, want ErrBaseline", err)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := New(Options{MinimizeHeaders: true, Shell: "/bin/sh"}).MinimizeCurlCommand(fmt.Sprintf(`curl -H 'X-A: 1' '%s/'`, serverURL)); !errors.Is(err, ErrCurlNotFound) {
+		t.Errorf("Error without a curl binary is %v, want ErrCurlNotFound", err)
+	}
+}
+
+func TestMinimizeReferer(t *testing.T) {
+	// The server checks the referer's origin, except on /public
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/public" && !strings.HasPrefix(r.Referer(), "https://app.example.com") {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "Forbidden")
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", fmt.Sprintf(`curl -e 'https://app.example.com/' '%s/'`, server.URL)},
+		{"/public", fmt.Sprintf(`curl '%s/public'`, server.URL)},
+	}
+	for _, tt := range tests {
+		curlCmd := fmt.Sprintf(`curl -e 'https://app.example.com/dashboard?tab=billing' '%s%s'`, server.URL, tt.path)
+		minimizedCmd, err := New(Options{MinimizeHeaders: true, MinimizeValues: true}).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		if got := strings.TrimSpace(minimizedCmd); got != tt.want {
+			t.Errorf("Minimized command is %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestReportDir(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Seen-Accept", r.Header.Get("Accept"))
+		if r.Header.Get("Authorization") != "Bearer xyz789" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "Unauthorized")
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "report")
+	curlCmd := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz789' -H 'Accept: text/html' '%s/'`, server.URL)
+	minimizedCmd, err := New(Options{MinimizeHeaders: true, ReportDir: dir}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	want := map[string]string{
+		"baseline-command.sh": curlCmd,
+		"baseline-body.txt":   "Success",
+		"final-command.sh":    minimizedCmd,
+		"final-body.txt":      "Success",
+	}
+	for file, contents := range want {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("Failed to read report file: %v", err)
+		}
+		if strings.TrimSpace(string(data)) != strings.TrimSpace(contents) {
+			t.Errorf("%s is %q, want %q", file, data, contents)
+		}
+	}
+
+	// The headers show the final command falls back to curl's own Accept
+	for file, want := range map[string]string{"baseline-headers.txt": "X-Seen-Accept: text/html", "final-headers.txt": "X-Seen-Accept: */*"} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("Failed to read report file: %v", err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s doesn't contain %q:\n%s", file, want, data)
+		}
+	}
+}
+
+func TestResumeFromCheckpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err != nil || r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "Unauthorized")
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz789' -H 'Accept: text/html' -b 'session=abc123; theme=dark' '%s/'`, server.URL)
+
+	// A run that only got through the headers
+	m := New(Options{MinimizeHeaders: true})
+	if _, err := m.MinimizeCurlCommand(curlCmd); err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	checkpoint := m.Checkpoint()
+	if !slices.Equal(checkpoint.Completed, []string{PassHeaders}) {
+		t.Errorf("Checkpoint completed %v, want [%s]", checkpoint.Completed, PassHeaders)
+	}
+
+	// Resuming from the checkpoint finishes with the cookies
+	resumed, err := New(Options{MinimizeHeaders: true, MinimizeCookies: true, CompletedPasses: checkpoint.Completed}).MinimizeCurlCommand(checkpoint.Command)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if want := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz789' -b 'session=abc123' '%s/'`, server.URL); strings.TrimSpace(resumed) != want {
+		t.Errorf("Resumed command is %q, want %q", resumed, want)
+	}
+
+	// A completed pass is skipped, so Accept stays in a command whose headers
+	// were never minimized
+	skipped, err := New(Options{MinimizeHeaders: true, MinimizeCookies: true, CompletedPasses: []string{PassHeaders}}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if want := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz789' -H 'Accept: text/html' -b 'session=abc123' '%s/'`, server.URL); strings.TrimSpace(skipped) != want {
+		t.Errorf("Command with the headers pass skipped is %q, want %q", skipped, want)
+	}
+}
+
+func TestTimeoutIsMatch(t *testing.T) {
+	// The server hangs whenever X-Cache-Key is missing
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Cache-Key") == "" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	// A baseline that times out is an error, unless timeouts match
+	curlCmd := fmt.Sprintf(`curl -m 0.5 -H 'Accept: text/html' -H 'X-Trace: 1' '%s/'`, server.URL)
+	if _, err := New(Options{MinimizeHeaders: true}).MinimizeCurlCommand(curlCmd); err == nil {
+		t.Errorf("Minimizing a command that times out succeeded, want an error")
+	}
+	minimizedCmd, err := New(Options{MinimizeHeaders: true, TimeoutComparison: TimeoutMatchesTimeout}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if want := fmt.Sprintf(`curl -m 0.5 '%s/'`, server.URL); strings.TrimSpace(minimizedCmd) != want {
+		t.Errorf("Minimized command is %q, want %q", minimizedCmd, want)
+	}
+
+	// A timeout still doesn't match a baseline that got a response
+	curlCmd = fmt.Sprintf(`curl -m 0.5 -H 'X-Cache-Key: k' '%s/'`, server.URL)
+	minimizedCmd, err = New(Options{MinimizeHeaders: true, TimeoutComparison: TimeoutMatchesTimeout}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	if strings.TrimSpace(minimizedCmd) != curlCmd {
+		t.Errorf("Minimized command is %q, want %q", minimizedCmd, curlCmd)
+	}
+}
+
+func TestCompareRedirectChain(t *testing.T) {
+	// X-Beta sends the login through an extra hop that ends in the same place
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Beta") == "1" {
+			http.Redirect(w, r, "/beta", http.StatusFound)
+		} else {
+			http.Redirect(w, r, "/home", http.StatusFound)
+		}
+	})
+	mux.HandleFunc("/beta", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/home", http.StatusFound)
+	})
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Welcome")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -L -H 'X-Beta: 1' '%s/login'`, server.URL)
+	tests := []struct {
+		compareRedirectChain bool
+		want                 string
+	}{
+		{false, fmt.Sprintf(`curl -L '%s/login'`, server.URL)},
+		{true, curlCmd},
+	}
+	for _, tt := range tests {
+		minimizedCmd, err := New(Options{MinimizeHeaders: true, CompareRedirectChain: tt.compareRedirectChain}).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		if got := strings.TrimSpace(minimizedCmd); got != tt.want {
+			t.Errorf("Minimized command with CompareRedirectChain %v is %q, want %q", tt.compareRedirectChain, got, tt.want)
+		}
+	}
+}
+
+func TestAnyValueParams(t *testing.T) {
+	// limit must be a number, but any number works
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := strconv.Atoi(r.URL.Query().Get("limit")); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Bad request")
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl '%s/?limit=20&utm_source=x'`, server.URL)
+	tests := []struct {
+		minimizeValues bool
+		want           string
+	}{
+		{false, fmt.Sprintf(`curl '%s/?limit=20'`, server.URL)},
+		{true, fmt.Sprintf(`curl '%s/?limit=0'`, server.URL)},
+	}
+	for _, tt := range tests {
+		m := New(Options{MinimizeParams: true, MinimizeValues: tt.minimizeValues})
+		minimizedCmd, err := m.MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		if got := strings.TrimSpace(minimizedCmd); got != tt.want {
+			t.Errorf("Minimized command with MinimizeValues %v is %q, want %q", tt.minimizeValues, got, tt.want)
+		}
+
+		i := slices.IndexFunc(m.Decisions(), func(d Decision) bool { return d.Name == "limit" })
+		if i < 0 || m.Decisions()[i].AnyValue != tt.minimizeValues {
+			t.Errorf("Decisions with MinimizeValues %v are %+v, want limit with AnyValue %v", tt.minimizeValues, m.Decisions(), tt.minimizeValues)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package curlmin
+
+import (
+	"slices"
+)
+
+// Strategy is how a pass searches for the elements it can remove
+type Strategy int
+
+const (
+	// StrategyLinear tests removing each element on its own, restarting
+	// after each removal
+	StrategyLinear Strategy = iota
+	// StrategyDDMin first tests removing groups of elements, delta-debugging
+	// style: all of them, then halves, quarters, and so on down to pairs.
+	// Only the elements left after that are tested on their own, which
+	// takes far fewer requests when most elements are noise.
+	StrategyDDMin
+)
+
+// ddminRemovable finds groups of candidates that can be removed together,
+// halving the group size down to pairs, and returns the candidates in the
+// removed groups. The command itself isn't changed: each test removes the
+// groups found so far along with the group under test.
+func ddminRemovable[T any](m *Minimizer, curl *CurlCommand, baselineResp Response, candidates []T, remove func(*CurlCommand, []T) error) []T {
+	var removed []T
+	remaining := slices.Clone(candidates)
+	for chunk := len(remaining); chunk >= 2; chunk /= 2 {
+		for start := 0; start < len(remaining); {
+			end := min(start+chunk, len(remaining))
+			if end-start < 2 {
+				break
+			}
+
+			group := remaining[start:end]
+			canRemove, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+				return remove(c, slices.Concat(removed, group))
+			})
+			if err == nil && canRemove {
+				if m.options.Verbose {
+					m.logf("Removed a group of %d elements together\n", len(group))
+				}
+				removed = slices.Concat(removed, group)
+				remaining = slices.Delete(remaining, start, end)
+				continue
+			}
+			start = end
+		}
+		chunk = min(chunk, len(remaining))
+	}
+	return removed
+}
+
+// removeArgs removes the arguments at indices (and their values), from the
+// end so earlier indices stay valid
+func removeArgs(c *CurlCommand, indices []int) error {
+	for _, i := range slices.Backward(slices.Sorted(slices.Values(indices))) {
+		c.RemoveArg(i)
+	}
+	return nil
+}
+
+// removeCookiesFromArg removes several cookies from the cookie argument at
+// argIndex, removing the whole argument if none would be left
+func (c *CurlCommand) removeCookiesFromArg(argIndex int, names []string, isHeader bool) error {
+	remaining := cookieNames(cookieValue(c.argString(argIndex + 1)))
+	remaining = slices.DeleteFunc(remaining, func(name string) bool { return slices.Contains(names, name) })
+	if len(remaining) == 0 {
+		c.RemoveArg(argIndex)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := c.RemoveCookieFromArg(argIndex, name, isHeader); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package curlmin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStrategyDDMin(t *testing.T) {
+	// The server needs one header, one cookie, and one param out of many
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if r.Header.Get("Authorization") != "Bearer xyz" || err != nil || cookie.Value != "abc" || r.URL.Query().Get("id") == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	var headers, cookies, params []string
+	for i := range 12 {
+		headers = append(headers, fmt.Sprintf("-H 'X-Noise-%d: %d'", i, i))
+		cookies = append(cookies, fmt.Sprintf("c%d=%d", i, i))
+		params = append(params, fmt.Sprintf("p%d=%d", i, i))
+	}
+	curlCmd := fmt.Sprintf(`curl %s -H 'Authorization: Bearer xyz' %s -b '%s; session=abc; %s' '%s/?%s&id=42&%s'`,
+		strings.Join(headers[:6], " "), strings.Join(headers[6:], " "),
+		strings.Join(cookies[:6], "; "), strings.Join(cookies[6:], "; "),
+		server.URL, strings.Join(params[:6], "&"), strings.Join(params[6:], "&"))
+
+	minimize := func(strategy Strategy) (string, int) {
+		_, report, err := New(Options{
+			MinimizeHeaders: true,
+			MinimizeCookies: true,
+			MinimizeParams:  true,
+			Strategy:        strategy,
+		}).MinimizeCurlCommandWithReport(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		return strings.Join(append(report.KeptHeaders, append(report.KeptCookies, report.KeptParams...)...), ","), report.Requests
+	}
+
+	wantKept, linearRequests := minimize(StrategyLinear)
+	gotKept, ddminRequests := minimize(StrategyDDMin)
+	if gotKept != wantKept || wantKept != "Authorization,session,id" {
+		t.Errorf("Kept elements are %q with ddmin and %q with linear, want Authorization,session,id", gotKept, wantKept)
+	}
+	if ddminRequests >= linearRequests {
+		t.Errorf("ddmin took %d requests, linear %d; want fewer with ddmin", ddminRequests, linearRequests)
+	}
+}
@@ -0,0 +1,180 @@
+package curlmin
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Element kinds used in decisions
+const (
+	KindHeader     = "header"
+	KindCookie     = "cookie"
+	KindQueryParam = "query parameter"
+	KindBodyField  = "body field"
+)
+
+// Decision records whether an element of the command was removed and, for
+// elements that were kept, how the response changed without them
+type Decision struct {
+	Kind    string
+	Name    string
+	Removed bool
+	// Reason explains why a kept element is required, e.g.
+	// "removing changes status 200 -> 401", or why an element was removed
+	// without testing
+	Reason string
+	// AnyValue marks a kept element that only needs to be present: a
+	// trivial value (e.g. an empty string or 0) works in place of its own
+	AnyValue bool
+	// Duration is the time spent on the test requests that led to the
+	// decision, which points out elements that trigger slow server paths
+	Duration time.Duration
+}
+
+// Decisions returns the final decision for each element tested by the last
+// run, in the order the elements were first tested
+func (m *Minimizer) Decisions() []Decision {
+	return slices.Clone(m.decisions)
+}
+
+// decide records the outcome of testing an element, replacing any earlier
+// decision for the same element (passes retest kept elements as they go)
+func (m *Minimizer) decide(kind, name string, removed bool) {
+	decision := Decision{Kind: kind, Name: name, Removed: removed, Duration: m.testTime}
+	if !removed {
+		decision.Reason = m.lastDifference
+		if m.untested {
+			decision.Reason = untestedReason
+		}
+	}
+	m.testTime = 0
+
+	for i, d := range m.decisions {
+		if d.Kind == kind && d.Name == name {
+			decision.AnyValue = d.AnyValue && !removed
+			decision.Duration += d.Duration
+			m.decisions[i] = decision
+			m.progressDecision(decision)
+			return
+		}
+	}
+	m.decisions = append(m.decisions, decision)
+	m.progressDecision(decision)
+}
+
+// duplicateName names an extra copy of an element, which is decided apart
+// from the element itself
+func duplicateName(name string) string {
+	return name + " (duplicate)"
+}
+
+// slowestDecisions is how many of the slowest elements to test verbose mode lists
+const slowestDecisions = 5
+
+// printSlowestDecisions lists the elements whose tests took the longest
+func (m *Minimizer) printSlowestDecisions() {
+	decisions := slices.Clone(m.decisions)
+	slices.SortStableFunc(decisions, func(a, b Decision) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+	decisions = slices.DeleteFunc(decisions, func(d Decision) bool { return d.Duration == 0 })
+	if len(decisions) == 0 {
+		return
+	}
+
+	m.logf("Slowest elements to test:\n")
+	for _, d := range decisions[:min(len(decisions), slowestDecisions)] {
+		m.logf("  %s %s: %s\n", d.Duration.Round(time.Millisecond), d.Kind, d.Name)
+	}
+}
+
+// markAnyValue records that a kept element's value doesn't matter
+func (m *Minimizer) markAnyValue(kind, name string) {
+	for i, d := range m.decisions {
+		if d.Kind == kind && d.Name == name {
+			m.decisions[i].AnyValue = true
+		}
+	}
+}
+
+// verifyDecisions checks the minimized command against the recorded
+// decisions: every removed element must be absent and every kept element
+// present. A mismatch means a rewrite of the command went wrong.
+func verifyDecisions(curl *CurlCommand, decisions []Decision) error {
+	// Collect the elements the command still carries
+	present := make(map[string]bool)
+	for _, i := range slices.Concat(curl.FindHeaderArgs(), curl.FindHeaderFlagArgs(), curl.FindAuthArgs(), curl.FindTLSArgs()) {
+		name := curl.headerArgName(i)
+		present[KindHeader+"\x00"+name] = true
+		present[annotationKey(KindHeader, name)] = true
+	}
+	for _, name := range curl.cookieSet() {
+		present[annotationKey(KindCookie, name)] = true
+	}
+	if urlIndex, err := curl.FindURLArg(); err == nil {
+		if parsedURL, err := url.Parse(curl.argString(urlIndex)); err == nil {
+			for name := range parsedURL.Query() {
+				present[annotationKey(KindQueryParam, name)] = true
+			}
+		}
+	}
+	for _, i := range curl.FindURLQueryArgs() {
+		present[annotationKey(KindQueryParam, curl.argString(i+1))] = true
+	}
+	for _, name := range curl.bodyFields() {
+		present[annotationKey(KindBodyField, name)] = true
+	}
+
+	var mismatches []string
+	for _, d := range decisions {
+		switch {
+		case d.Removed && d.Kind == KindHeader && present[KindHeader+"\x00"+d.Name]:
+			// Headers are matched exactly when removed, since a header with
+			// the same name may legitimately remain
+			mismatches = append(mismatches, fmt.Sprintf("removed %s still present: %s", d.Kind, d.Name))
+		case d.Removed && d.Kind == KindBodyField && strings.HasSuffix(d.Name, "]"):
+			// Removed array elements are named by their original index,
+			// which a remaining element may have moved into
+		case d.Removed && d.Kind != KindHeader && present[annotationKey(d.Kind, d.Name)]:
+			mismatches = append(mismatches, fmt.Sprintf("removed %s still present: %s", d.Kind, d.Name))
+		case !d.Removed && !present[annotationKey(d.Kind, d.Name)]:
+			mismatches = append(mismatches, fmt.Sprintf("kept %s missing: %s", d.Kind, d.Name))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("minimized command doesn't match minimization decisions: %s", strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+// describeDifference explains how a candidate response differs from the baseline
+func describeDifference(baseline, resp Response) string {
+	switch {
+	case baseline.TimedOut != resp.TimedOut:
+		if resp.TimedOut {
+			return "removing makes the request time out"
+		}
+		return "removing stops the request timing out"
+	case baseline.StatusCode != resp.StatusCode:
+		return fmt.Sprintf("removing changes status %d -> %d", baseline.StatusCode, resp.StatusCode)
+	case baseline.Status != resp.Status:
+		return fmt.Sprintf("removing changes status line %q -> %q", baseline.Status, resp.Status)
+	case !slices.Equal(baseline.RedirectChain, resp.RedirectChain):
+		return fmt.Sprintf("removing changes redirects %v -> %v", baseline.RedirectChain, resp.RedirectChain)
+	case mediaType(baseline) != mediaType(resp):
+		return fmt.Sprintf("removing changes content type %q -> %q", mediaType(baseline), mediaType(resp))
+	case len(baseline.Body) != len(resp.Body):
+		return fmt.Sprintf("removing changes body size %d -> %d bytes", len(baseline.Body), len(resp.Body))
+	case baseline.Body != resp.Body:
+		return "removing changes body content"
+	case !trailersEqual(baseline.Trailer, resp.Trailer):
+		return "removing changes trailers"
+	default:
+		return "removing changes the response"
+	}
+}
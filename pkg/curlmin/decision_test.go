@@ -0,0 +1,58 @@
+package curlmin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifyDecisions(t *testing.T) {
+	curl, err := ParseCurlCommand(`curl -H 'Authorization: Bearer xyz789' -H 'Accept: */*' -b 'session=abc123' 'http://example.com/?auth_key=def456'`)
+	if err != nil {
+		t.Fatalf("Failed to parse curl command: %v", err)
+	}
+
+	consistent := []Decision{
+		{Kind: KindHeader, Name: "Authorization: Bearer shortened"},
+		{Kind: KindHeader, Name: "Accept: text/html", Removed: true},
+		{Kind: KindCookie, Name: "session"},
+		{Kind: KindCookie, Name: "_ga", Removed: true},
+		{Kind: KindQueryParam, Name: "auth_key"},
+		{Kind: KindQueryParam, Name: "utm_source", Removed: true},
+	}
+	if err := verifyDecisions(curl, consistent); err != nil {
+		t.Errorf("verifyDecisions() with consistent decisions: %v", err)
+	}
+
+	inconsistent := [][]Decision{
+		{{Kind: KindHeader, Name: "Accept: */*", Removed: true}},
+		{{Kind: KindHeader, Name: "X-Api-Key: abc"}},
+		{{Kind: KindCookie, Name: "session", Removed: true}},
+		{{Kind: KindQueryParam, Name: "page"}},
+	}
+	for _, decisions := range inconsistent {
+		if err := verifyDecisions(curl, decisions); err == nil {
+			t.Errorf("verifyDecisions(%+v) found no mismatch", decisions[0])
+		}
+	}
+}
+
+func TestDecisionDuration(t *testing.T) {
+	m := New(Options{})
+
+	// Test time is charged to the next decision, and adds up when an
+	// element is decided again
+	m.testTime = 3 * time.Second
+	m.decide(KindHeader, "Accept: */*", false)
+	m.testTime = 2 * time.Second
+	m.decide(KindCookie, "_ga", true)
+	m.testTime = time.Second
+	m.decide(KindHeader, "Accept: */*", true)
+
+	decisions := m.Decisions()
+	if got := decisions[0].Duration; got != 4*time.Second {
+		t.Errorf("header decision took %v, want 4s", got)
+	}
+	if got := decisions[1].Duration; got != 2*time.Second {
+		t.Errorf("cookie decision took %v, want 2s", got)
+	}
+}
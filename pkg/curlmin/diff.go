@@ -0,0 +1,79 @@
+package curlmin
+
+import (
+	"regexp"
+	"strings"
+)
+
+// similarityToken matches the words and single punctuation characters that
+// bodies are split into for similarity
+var similarityToken = regexp.MustCompile(`[\p{L}\p{N}_]+|\S`)
+
+// diffScore measures how much two bodies differ as the number of lines that
+// must be inserted or deleted to turn one into the other, divided by the
+// total number of lines in both: 0 means identical and 1 means nothing in
+// common. The search gives up once the score exceeds maxScore, in which case
+// ok is false and score is only a lower bound.
+func diffScore(a, b string, maxScore float64) (score float64, ok bool) {
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+	total := len(linesA) + len(linesB)
+
+	distance, ok := editDistance(linesA, linesB, int(maxScore*float64(total)))
+	return float64(distance) / float64(total), ok
+}
+
+// similarity measures how alike two bodies are as 1 minus the number of
+// tokens (words or punctuation) that must be inserted or deleted to turn one
+// into the other, divided by the tokens in both: 1 means identical. Unlike
+// diffScore, a nonce on a long minified line only costs its own tokens. The
+// search gives up once the similarity drops below minSimilarity, in which
+// case ok is false and the result is only an upper bound.
+func similarity(a, b string, minSimilarity float64) (float64, bool) {
+	tokensA, tokensB := similarityToken.FindAllString(a, -1), similarityToken.FindAllString(b, -1)
+	total := len(tokensA) + len(tokensB)
+	if total == 0 {
+		return 1, true
+	}
+
+	distance, ok := editDistance(tokensA, tokensB, int((1-minSimilarity)*float64(total)))
+	return 1 - float64(distance)/float64(total), ok
+}
+
+// editDistance returns the shortest edit script length between a and b using
+// Myers' O(ND) algorithm, stopping early if it's longer than limit
+func editDistance(a, b []string, limit int) (int, bool) {
+	// Lines shared at either end never need editing
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	n, m := len(a), len(b)
+	maxD := min(n+m, limit)
+
+	// v[offset+k] is the furthest x reached on diagonal k
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Insertion
+			} else {
+				x = v[offset+k-1] + 1 // Deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return d, true
+			}
+		}
+	}
+	return maxD + 1, false
+}
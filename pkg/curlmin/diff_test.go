@@ -0,0 +1,70 @@
+package curlmin
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDiffScore(t *testing.T) {
+	page := func(lines ...string) string {
+		return strings.Join(lines, "\n")
+	}
+
+	tests := []struct {
+		name     string
+		a, b     string
+		maxScore float64
+		want     float64
+		wantOK   bool
+	}{
+		{"identical", page("a", "b", "c"), page("a", "b", "c"), 0.1, 0, true},
+		{"one line changed", page("a", "b", "c", "d", "e"), page("a", "b", "X", "d", "e"), 0.5, 0.2, true},
+		{"one line inserted", page("a", "b", "c"), page("a", "X", "b", "c"), 0.5, 1.0 / 7, true},
+		{"over the limit", page("a", "b", "c", "d", "e"), page("a", "b", "X", "d", "e"), 0.1, 0, false},
+		{"nothing in common", page("a", "b"), page("c", "d"), 1, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, ok := diffScore(tt.a, tt.b, tt.maxScore)
+			if ok != tt.wantOK {
+				t.Fatalf("diffScore() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && math.Abs(score-tt.want) > 1e-9 {
+				t.Errorf("diffScore() = %v, want %v", score, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	page := func(nonce string) string {
+		return `<html><head><script nonce="` + nonce + `">init()</script></head><body><h1>Welcome back, admin</h1><p>You have 3 new messages.</p></body></html>`
+	}
+
+	tests := []struct {
+		name          string
+		a, b          string
+		minSimilarity float64
+		want          bool
+	}{
+		{"identical", page("abc"), page("abc"), 0.95, true},
+		{"different nonce on one line", page("abc"), page("xyz"), 0.95, true},
+		{"different page", page("abc"), `<html><body><h1>Please log in</h1></body></html>`, 0.95, false},
+		{"both empty", "", "", 0.95, true},
+		{"strict threshold", page("abc"), page("xyz"), 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := similarity(tt.a, tt.b, tt.minSimilarity); got != tt.want {
+				t.Errorf("similarity() ok = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := New(Options{MinSimilarity: 95}).MinimizeCurlCommand("curl http://example.invalid/"); err == nil {
+		t.Error("Expected an error for a similarity above 1")
+	}
+}
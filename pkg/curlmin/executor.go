@@ -0,0 +1,30 @@
+package curlmin
+
+import (
+	"context"
+)
+
+// Executor sends the request described by a curl command and returns the
+// response, in place of running the curl binary. Implementations can route
+// requests through their own client, replay recorded responses, or stand in
+// for a server in tests. Execute may be called from several goroutines at
+// once when Options.Concurrency is above 1.
+type Executor interface {
+	Execute(ctx context.Context, curlCmd string) (Response, error)
+}
+
+// ExecutorFunc adapts an ordinary function to an Executor
+type ExecutorFunc func(ctx context.Context, curlCmd string) (Response, error)
+
+// Execute calls f(ctx, curlCmd)
+func (f ExecutorFunc) Execute(ctx context.Context, curlCmd string) (Response, error) {
+	return f(ctx, curlCmd)
+}
+
+// executeCustom sends a request with Options.Executor
+func (m *Minimizer) executeCustom(curlCmd string) (Response, error) {
+	if m.options.Verbose {
+		m.logf("Executing (executor): %s\n", curlCmd)
+	}
+	return m.options.Executor.Execute(m.context(), curlCmd)
+}
@@ -0,0 +1,92 @@
+package curlmin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecutor(t *testing.T) {
+	// Stand in for a server that only needs the Authorization header
+	var requests atomic.Int32
+	executor := ExecutorFunc(func(ctx context.Context, curlCmd string) (Response, error) {
+		requests.Add(1)
+		curl, err := ParseCurlCommand(curlCmd)
+		if err != nil {
+			return Response{}, err
+		}
+		req, err := curl.ToHTTPRequest()
+		if err != nil {
+			return Response{}, err
+		}
+		if req.Header.Get("Authorization") != "Bearer xyz" {
+			return Response{StatusCode: 401, Status: "401 Unauthorized", Body: "Unauthorized"}, nil
+		}
+		return Response{StatusCode: 200, Status: "200 OK", Body: "Success"}, nil
+	})
+
+	curlCmd := `curl -H 'Accept: */*' -H 'Authorization: Bearer xyz' -H 'X-Requested-With: XMLHttpRequest' 'http://example.invalid/'`
+	minimizedCmd, err := New(Options{
+		MinimizeHeaders: true,
+		Executor:        executor,
+		Shell:           "no-such-shell",
+	}).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	want := `curl -H 'Authorization: Bearer xyz' 'http://example.invalid/'`
+	if got := strings.TrimSpace(minimizedCmd); got != want {
+		t.Errorf("Minimized command is %q, want %q", got, want)
+	}
+	if requests.Load() == 0 {
+		t.Errorf("Executor wasn't called")
+	}
+}
+
+func TestMinimizeCurlCommandContext(t *testing.T) {
+	// The server hangs as soon as the Authorization header goes missing
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xyz" {
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+	curlCmd := fmt.Sprintf(`curl -H 'Accept: */*' -H 'Authorization: Bearer xyz' '%s/'`, server.URL)
+
+	for _, native := range []bool{false, true} {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		start := time.Now()
+		_, err := New(Options{MinimizeHeaders: true, Native: native}).MinimizeCurlCommandContext(ctx, curlCmd)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("With Native %v, error is %v, want context.DeadlineExceeded", native, err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("With Native %v, minimizing took %v after the deadline", native, elapsed)
+		}
+	}
+
+	// A context that's already done sends nothing
+	var requests atomic.Int32
+	executor := ExecutorFunc(func(ctx context.Context, curlCmd string) (Response, error) {
+		requests.Add(1)
+		return Response{StatusCode: 200, Body: "Success"}, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := New(Options{MinimizeHeaders: true, Executor: executor}).MinimizeCurlCommandContext(ctx, curlCmd); !errors.Is(err, context.Canceled) {
+		t.Errorf("Error with a canceled context is %v, want context.Canceled", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("Sent %d requests with a canceled context, want 0", n)
+	}
+}
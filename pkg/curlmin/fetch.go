@@ -0,0 +1,95 @@
+package curlmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// fetchCall matches the start of a fetch() call, as in "await fetch("
+var fetchCall = regexp.MustCompile(`\bfetch\s*\(`)
+
+// fetchInit is the part of fetch()'s options a request is built from
+type fetchInit struct {
+	Method   string          `json:"method"`
+	Headers  json.RawMessage `json:"headers"`
+	Body     *string         `json:"body"`
+	Referrer string          `json:"referrer"`
+}
+
+// FetchToCurl converts a JavaScript fetch() call, as copied from a browser's
+// developer tools with "Copy as fetch", into a curl command. The copy holds
+// the URL and an options object in JSON, whose method, headers, body, and
+// referrer carry over; headers keep their order. Browsers leave cookies out
+// of the copy, so a request that needs them will need a Cookie header added.
+func FetchToCurl(snippet string) (string, error) {
+	loc := fetchCall.FindStringIndex(snippet)
+	if loc == nil {
+		return "", fmt.Errorf("no fetch() call found")
+	}
+	decoder := json.NewDecoder(strings.NewReader(snippet[loc[1]:]))
+
+	var target string
+	if err := decoder.Decode(&target); err != nil {
+		return "", fmt.Errorf("invalid fetch() URL: %w", err)
+	}
+
+	// The options object follows a comma, if there is one
+	var init fetchInit
+	rest := strings.TrimSpace(snippet[loc[1]:][decoder.InputOffset():])
+	if rest, ok := strings.CutPrefix(rest, ","); ok && !strings.HasPrefix(strings.TrimSpace(rest), ")") {
+		if err := json.NewDecoder(strings.NewReader(rest)).Decode(&init); err != nil {
+			return "", fmt.Errorf("invalid fetch() options: %w", err)
+		}
+	}
+
+	var headers []string
+	if len(init.Headers) > 0 && string(init.Headers) != "null" {
+		obj, err := parseJSONObject(string(init.Headers))
+		if err != nil {
+			return "", fmt.Errorf("invalid fetch() headers: %w", err)
+		}
+		for _, name := range obj.keys {
+			var value string
+			if err := json.Unmarshal(obj.values[name], &value); err != nil {
+				return "", fmt.Errorf("invalid value for header %s: %w", name, err)
+			}
+			headers = append(headers, name+": "+value)
+		}
+	}
+	if init.Referrer != "" {
+		headers = append(headers, "Referer: "+init.Referrer)
+	}
+
+	return buildCurlCommand(init.Method, target, nil, headers, init.Body)
+}
+
+// buildCurlCommand prints a curl command for a request taken from another
+// tool's format: its method (GET if empty), URL, any other flags, headers
+// (as "Name: value"), and body, if it has one. The method is only given
+// when curl wouldn't use it anyway.
+func buildCurlCommand(method, target string, flags, headers []string, body *string) (string, error) {
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	args := append([]string{"curl"}, flags...)
+	switch {
+	case method == http.MethodHead:
+		args = append(args, "-I")
+	case method == http.MethodGet && body == nil, method == http.MethodPost && body != nil:
+	default:
+		args = append(args, "-X", method)
+	}
+	for _, header := range headers {
+		args = append(args, "-H", header)
+	}
+	if body != nil {
+		args = append(args, "--data-raw", *body)
+	}
+	args = append(args, target)
+	return shellCommand(args)
+}
@@ -0,0 +1,61 @@
+package curlmin
+
+import "testing"
+
+func TestFetchToCurl(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "copied from chrome",
+			snippet: `fetch("https://example.com/api/items?page=2", {
+  "headers": {
+    "accept": "application/json",
+    "x-api-key": "abc"
+  },
+  "referrer": "https://example.com/items",
+  "referrerPolicy": "strict-origin-when-cross-origin",
+  "body": null,
+  "method": "GET",
+  "mode": "cors",
+  "credentials": "include"
+});`,
+			want: `curl -H 'accept: application/json' -H 'x-api-key: abc' -H 'Referer: https://example.com/items' 'https://example.com/api/items?page=2'`,
+		},
+		{
+			name:    "post with a body",
+			snippet: `await fetch("https://example.com/login", {"headers": {"content-type": "application/json"}, "body": "{\"user\":\"o'k\"}", "method": "POST"});`,
+			want:    `curl -H 'content-type: application/json' --data-raw '{"user":"o'\''k"}' 'https://example.com/login'`,
+		},
+		{
+			name:    "other method without options",
+			snippet: `fetch("https://example.com/items/1", {"method": "DELETE"})`,
+			want:    `curl -X DELETE 'https://example.com/items/1'`,
+		},
+		{
+			name:    "url only",
+			snippet: `fetch("https://example.com/")`,
+			want:    `curl 'https://example.com/'`,
+		},
+		{
+			name:    "not fetch",
+			snippet: `curl https://example.com/`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FetchToCurl(tt.snippet)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchToCurl() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FetchToCurl() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
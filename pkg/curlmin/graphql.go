@@ -0,0 +1,530 @@
+package curlmin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// jsonObject is a JSON object that remembers the order of its keys, so a
+// rewritten request body reads like the original
+type jsonObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// parseJSONObject decodes a single JSON object
+func parseJSONObject(data string) (*jsonObject, error) {
+	dec := json.NewDecoder(strings.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("not a JSON object")
+	}
+
+	obj := &jsonObject{values: make(map[string]json.RawMessage)}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if _, ok := obj.values[key]; !ok {
+			obj.keys = append(obj.keys, key)
+		}
+		obj.values[key] = value
+	}
+
+	// Consume the closing brace and reject trailing data
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("trailing data after JSON object")
+	}
+	return obj, nil
+}
+
+// with returns a copy of the object with key set to value
+func (o *jsonObject) with(key string, value json.RawMessage) *jsonObject {
+	clone := &jsonObject{values: make(map[string]json.RawMessage)}
+	for _, k := range o.keys {
+		clone.keys = append(clone.keys, k)
+		clone.values[k] = o.values[k]
+	}
+	if _, ok := clone.values[key]; !ok {
+		clone.keys = append(clone.keys, key)
+	}
+	clone.values[key] = value
+	return clone
+}
+
+// without returns a copy of the object without key
+func (o *jsonObject) without(key string) *jsonObject {
+	clone := &jsonObject{values: make(map[string]json.RawMessage)}
+	for _, k := range o.keys {
+		if k != key {
+			clone.keys = append(clone.keys, k)
+			clone.values[k] = o.values[k]
+		}
+	}
+	return clone
+}
+
+// String encodes the object with its keys in their original order
+func (o *jsonObject) String() string {
+	var buf strings.Builder
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(marshalJSONString(key))
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// marshalJSONString encodes a string as JSON without escaping HTML characters
+func marshalJSONString(s string) json.RawMessage {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+// findGraphQLBody finds a request body that's a GraphQL request: a JSON
+// object with a "query" string, sent with a JSON or GraphQL content type. It
+// returns the index of the body's flag and the decoded body.
+func (c *CurlCommand) findGraphQLBody() (int, *jsonObject, bool) {
+	contentType := c.requestContentType()
+
+	for _, i := range c.FindDataArgs() {
+		flag := c.literalArg(i)
+		isJSON := flag == "--json" || strings.Contains(contentType, "json") || strings.Contains(contentType, "graphql")
+		if !isJSON || flag == "--data-urlencode" {
+			continue
+		}
+
+		// Bodies read from files (@file) aren't JSON, so they're skipped too
+		body, err := parseJSONObject(c.literalArg(i + 1))
+		if err != nil {
+			continue
+		}
+		var query string
+		if json.Unmarshal(body.values["query"], &query) != nil {
+			continue
+		}
+		return i, body, true
+	}
+	return 0, nil, false
+}
+
+// graphQLToken is a lexical token of a GraphQL document and its byte offsets
+type graphQLToken struct {
+	text       string
+	start, end int
+}
+
+// lexGraphQL splits a GraphQL document into tokens, dropping whitespace,
+// commas, and comments
+func lexGraphQL(doc string) ([]graphQLToken, error) {
+	var tokens []graphQLToken
+	for i := 0; i < len(doc); {
+		start := i
+		ch := doc[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == ',':
+			i++
+			continue
+		case ch == '#':
+			for i < len(doc) && doc[i] != '\n' && doc[i] != '\r' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(doc[i:], "..."):
+			i += 3
+		case strings.HasPrefix(doc[i:], `"""`):
+			end := strings.Index(doc[i+3:], `"""`)
+			for end >= 0 && doc[i+3+end-1] == '\\' {
+				next := strings.Index(doc[i+3+end+3:], `"""`)
+				if next < 0 {
+					end = -1
+					break
+				}
+				end += 3 + next
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated block string at offset %d", start)
+			}
+			i += 3 + end + 3
+		case ch == '"':
+			i++
+			for i < len(doc) && doc[i] != '"' {
+				if doc[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(doc) {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			i++
+		case ch == '_' || ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z':
+			for i < len(doc) && (doc[i] == '_' || doc[i] >= 'A' && doc[i] <= 'Z' || doc[i] >= 'a' && doc[i] <= 'z' || doc[i] >= '0' && doc[i] <= '9') {
+				i++
+			}
+		case ch == '-' || ch >= '0' && ch <= '9':
+			i++
+			for i < len(doc) && strings.IndexByte("0123456789.eE+-", doc[i]) >= 0 {
+				i++
+			}
+		case strings.IndexByte("!$&()/:=@[]{}|", ch) >= 0:
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", ch, start)
+		}
+		tokens = append(tokens, graphQLToken{text: doc[start:i], start: start, end: i})
+	}
+	return tokens, nil
+}
+
+// graphQLSelection is a field, fragment spread, or inline fragment in a
+// selection set
+type graphQLSelection struct {
+	// key identifies the selection across rewrites of the query, and path
+	// names it for logging (e.g. user.friends.name)
+	key, path string
+	// start and end are its byte offsets in the query
+	start, end int
+	// siblings is the number of selections in its selection set, including
+	// itself
+	siblings int
+}
+
+// graphQLParser finds the selections in a GraphQL document
+type graphQLParser struct {
+	tokens     []graphQLToken
+	pos        int
+	selections []graphQLSelection
+}
+
+// parseGraphQLSelections returns every selection in a GraphQL document, each
+// before the selections nested inside it
+func parseGraphQLSelections(doc string) ([]graphQLSelection, error) {
+	tokens, err := lexGraphQL(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &graphQLParser{tokens: tokens}
+	for definition := 0; p.pos < len(p.tokens); {
+		switch p.peek() {
+		case "(":
+			// Variable definitions can hold object defaults with braces
+			p.skipBalanced("(", ")")
+		case "{":
+			if err := p.selectionSet(fmt.Sprint(definition), ""); err != nil {
+				return nil, err
+			}
+			definition++
+		default:
+			p.pos++
+		}
+	}
+	return p.selections, nil
+}
+
+// peek returns the current token's text, or "" at the end of the document
+func (p *graphQLParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].text
+	}
+	return ""
+}
+
+// skipBalanced skips from an opening token past its matching closing token
+func (p *graphQLParser) skipBalanced(open, close string) {
+	depth := 0
+	for ; p.pos < len(p.tokens); p.pos++ {
+		switch p.tokens[p.pos].text {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				p.pos++
+				return
+			}
+		}
+	}
+}
+
+// name consumes a name token and returns it
+func (p *graphQLParser) name() (string, error) {
+	name := p.peek()
+	if !isGraphQLName(name) {
+		if p.pos >= len(p.tokens) {
+			return "", fmt.Errorf("unexpected end of query")
+		}
+		return "", fmt.Errorf("unexpected %q in selection set", name)
+	}
+	p.pos++
+	return name, nil
+}
+
+// skipDirectives skips any directives (e.g. @include(if: $flag))
+func (p *graphQLParser) skipDirectives() error {
+	for p.peek() == "@" {
+		p.pos++
+		if _, err := p.name(); err != nil {
+			return err
+		}
+		if p.peek() == "(" {
+			p.skipBalanced("(", ")")
+		}
+	}
+	return nil
+}
+
+// selectionSet parses a selection set starting at its opening brace
+func (p *graphQLParser) selectionSet(key, path string) error {
+	p.pos++ // {
+	first := len(p.selections)
+	var children []int
+
+	for p.peek() != "}" {
+		if p.pos >= len(p.tokens) {
+			return fmt.Errorf("unterminated selection set")
+		}
+		start := p.tokens[p.pos].start
+
+		var name string
+		if p.peek() == "..." {
+			// Fragment spread or inline fragment
+			p.pos++
+			name = "..."
+			if p.peek() == "on" {
+				p.pos++
+				typeName, err := p.name()
+				if err != nil {
+					return err
+				}
+				name += "on " + typeName
+			} else if isGraphQLName(p.peek()) {
+				name += p.peek()
+				p.pos++
+			}
+		} else {
+			var err error
+			if name, err = p.name(); err != nil {
+				return err
+			}
+			if p.peek() == ":" {
+				// Keep the alias, which is what the response is keyed by
+				p.pos++
+				if _, err := p.name(); err != nil {
+					return err
+				}
+			}
+			if p.peek() == "(" {
+				p.skipBalanced("(", ")")
+			}
+		}
+		if err := p.skipDirectives(); err != nil {
+			return err
+		}
+
+		childPath := name
+		if path != "" {
+			childPath = path + "." + name
+		}
+		index := len(p.selections)
+		children = append(children, index)
+		p.selections = append(p.selections, graphQLSelection{key: key + ":" + childPath, path: childPath, start: start})
+
+		if p.peek() == "{" {
+			if err := p.selectionSet(key, childPath); err != nil {
+				return err
+			}
+		}
+		p.selections[index].end = p.tokens[p.pos-1].end
+	}
+	p.pos++ // }
+
+	if len(p.selections) == first {
+		return fmt.Errorf("empty selection set")
+	}
+	for _, index := range children {
+		p.selections[index].siblings = len(children)
+	}
+	return nil
+}
+
+// graphQLFieldName names a selection in decisions as a field of the body's
+// query, e.g. query.user.name
+func graphQLFieldName(sel graphQLSelection) string {
+	return "query." + sel.path
+}
+
+// graphQLFieldNames names the selections of a GraphQL request body's query
+// as graphQLFieldName does, or returns nil if the body has no query
+func graphQLFieldNames(body *jsonObject) []string {
+	var query string
+	if json.Unmarshal(body.values["query"], &query) != nil {
+		return nil
+	}
+	selections, err := parseGraphQLSelections(query)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, sel := range selections {
+		names = append(names, graphQLFieldName(sel))
+	}
+	return names
+}
+
+// isGraphQLName reports whether a token is a GraphQL name
+func isGraphQLName(token string) bool {
+	if token == "" {
+		return false
+	}
+	ch := token[0]
+	return ch == '_' || ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z'
+}
+
+// removeGraphQLSelection cuts a selection out of a query, along with the
+// whitespace that separated it from its neighbors
+func removeGraphQLSelection(query string, sel graphQLSelection) string {
+	start, end := sel.start, sel.end
+	isBlank := func(ch byte) bool { return ch == ' ' || ch == '\t' || ch == ',' }
+
+	// A selection on a line of its own takes the whole line with it
+	lineStart := start
+	for lineStart > 0 && isBlank(query[lineStart-1]) {
+		lineStart--
+	}
+	lineEnd := end
+	for lineEnd < len(query) && isBlank(query[lineEnd]) {
+		lineEnd++
+	}
+	if (lineStart == 0 || query[lineStart-1] == '\n') && lineEnd < len(query) && query[lineEnd] == '\n' {
+		return query[:lineStart] + query[lineEnd+1:]
+	}
+
+	// Otherwise take the separator after it, or before it if it's the last
+	// in its selection set
+	if lineEnd < len(query) && query[lineEnd] == '}' {
+		return query[:lineStart] + query[end:]
+	}
+	return query[:start] + query[lineEnd:]
+}
+
+// minimizeGraphQL tries removing the selected fields and the variables of a
+// GraphQL request body one at a time
+func (m *Minimizer) minimizeGraphQL(curl *CurlCommand, baselineResp Response) {
+	if _, _, ok := curl.findGraphQLBody(); !ok {
+		if m.options.Verbose {
+			m.logf("Skipping GraphQL: no GraphQL request body\n")
+		}
+		return
+	}
+
+	// Fields, restarting after each removal since the offsets change
+	needed := make(map[string]bool)
+	for {
+		dataIndex, body, _ := curl.findGraphQLBody()
+		var query string
+		json.Unmarshal(body.values["query"], &query)
+
+		selections, err := parseGraphQLSelections(query)
+		if err != nil {
+			if m.options.Verbose {
+				m.logf("Skipping GraphQL fields: can't parse query: %v\n", err)
+			}
+			break
+		}
+
+		foundRemovable := false
+		for _, sel := range selections {
+			// A selection set can't be empty
+			if needed[sel.key] || sel.siblings < 2 {
+				continue
+			}
+
+			newBody := body.with("query", marshalJSONString(removeGraphQLSelection(query, sel))).String()
+			canRemove, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+				return c.SetArg(dataIndex+1, newBody)
+			})
+			if err == nil && canRemove {
+				if m.options.Verbose {
+					m.logf("GraphQL field not needed: %s\n", sel.path)
+				}
+				m.decide(KindBodyField, graphQLFieldName(sel), true)
+				// Selections nested in a removed one go with it
+				for _, nested := range selections {
+					if nested.start >= sel.start && nested.end <= sel.end && nested.key != sel.key {
+						m.decide(KindBodyField, graphQLFieldName(nested), true)
+					}
+				}
+				curl.SetArg(dataIndex+1, newBody)
+				foundRemovable = true
+				break
+			}
+
+			if m.options.Verbose {
+				m.logNeeded("GraphQL field", sel.path)
+			}
+			m.decide(KindBodyField, graphQLFieldName(sel), false)
+			needed[sel.key] = true
+			if m.stopAtRequired("GraphQL field", sel.path) {
+				return
+			}
+		}
+
+		if !foundRemovable {
+			break
+		}
+	}
+
+	// Variables
+	dataIndex, body, _ := curl.findGraphQLBody()
+	variables, err := parseJSONObject(string(body.values["variables"]))
+	if err != nil {
+		return
+	}
+	for _, name := range variables.keys {
+		remaining := variables.without(name)
+		newBody := body.with("variables", json.RawMessage(remaining.String())).String()
+		canRemove, err := m.testModification(curl, baselineResp, func(c *CurlCommand) error {
+			return c.SetArg(dataIndex+1, newBody)
+		})
+		if err == nil && canRemove {
+			if m.options.Verbose {
+				m.logf("GraphQL variable not needed: %s\n", name)
+			}
+			m.decide(KindBodyField, jsonPathName([]string{"variables", name}), true)
+			curl.SetArg(dataIndex+1, newBody)
+			body = body.with("variables", json.RawMessage(remaining.String()))
+			variables = remaining
+			continue
+		}
+
+		if m.options.Verbose {
+			m.logNeeded("GraphQL variable", name)
+		}
+		m.decide(KindBodyField, jsonPathName([]string{"variables", name}), false)
+		if m.stopAtRequired("GraphQL variable", name) {
+			return
+		}
+	}
+}
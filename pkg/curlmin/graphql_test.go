@@ -0,0 +1,99 @@
+package curlmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseGraphQLSelections(t *testing.T) {
+	query := `query User($id: ID!, $filter: F = {a: 1}) {
+  user(id: $id) {
+    id
+    handle: name @include(if: true)
+    friends(first: 10) { name }
+    ... on Admin { level }
+  }
+}`
+
+	selections, err := parseGraphQLSelections(query)
+	if err != nil {
+		t.Fatalf("parseGraphQLSelections() failed: %v", err)
+	}
+
+	var paths []string
+	for _, sel := range selections {
+		paths = append(paths, fmt.Sprintf("%s(%d)", sel.path, sel.siblings))
+	}
+	want := "user(1) user.id(4) user.handle(4) user.friends(4) user.friends.name(1) user....on Admin(4) user....on Admin.level(1)"
+	if got := strings.Join(paths, " "); got != want {
+		t.Errorf("parseGraphQLSelections() found %s, want %s", got, want)
+	}
+
+	// Removing a field on its own line drops the line
+	got := removeGraphQLSelection(query, selections[3])
+	if strings.Contains(got, "friends") || strings.Contains(got, "\n\n") {
+		t.Errorf("removeGraphQLSelection() left %q", got)
+	}
+
+	// Removing inline fields keeps the rest readable
+	selections, _ = parseGraphQLSelections("{ user { id name email } }")
+	if got := removeGraphQLSelection("{ user { id name email } }", selections[2]); got != "{ user { id email } }" {
+		t.Errorf("removeGraphQLSelection() = %q", got)
+	}
+	if got := removeGraphQLSelection("{ user { id name email } }", selections[3]); got != "{ user { id name } }" {
+		t.Errorf("removeGraphQLSelection() = %q", got)
+	}
+
+	// Truncated queries are errors
+	for _, query := range []string{"{", "{ a:", "{ ... on", "{ a @", "{ a(b: 1"} {
+		if _, err := parseGraphQLSelections(query); err == nil {
+			t.Errorf("parseGraphQLSelections(%q) succeeded, want an error", query)
+		}
+	}
+}
+
+func TestMinimizeGraphQL(t *testing.T) {
+	// The user's name is all that matters, looked up by the id variable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(req.Query, "user(id: $id)") && strings.Contains(req.Query, "name") && req.Variables["id"] == "1" {
+			fmt.Fprint(w, `{"data":{"user":{"name":"admin"}}}`)
+		} else {
+			fmt.Fprint(w, `{"errors":[{"message":"bad request"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'Content-Type: application/json' --data-raw '{"operationName":"User","query":"query User($id: ID!) { user(id: $id) { id name email avatar { url } } }","variables":{"id":"1","locale":"en"}}' '%s/graphql'`, server.URL)
+	min := New(Options{MinimizeGraphQL: true})
+	minimizedCmd, err := min.MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	want := fmt.Sprintf(`curl -H 'Content-Type: application/json' --data-raw '{"operationName":"User","query":"query User($id: ID!) { user(id: $id) { name } }","variables":{"id":"1"}}' '%s/graphql'`, server.URL)
+	if strings.TrimSpace(minimizedCmd) != want {
+		t.Errorf("Minimized command is %q, want %q", minimizedCmd, want)
+	}
+
+	var decisions []string
+	for _, d := range min.Decisions() {
+		if d.Kind == KindBodyField {
+			decisions = append(decisions, fmt.Sprintf("%s=%t", d.Name, d.Removed))
+		}
+	}
+	slices.Sort(decisions)
+	wantDecisions := "query.user.avatar.url=true query.user.avatar=true query.user.email=true query.user.id=true query.user.name=false variables.id=false variables.locale=true"
+	if got := strings.Join(decisions, " "); got != wantDecisions {
+		t.Errorf("Decisions() = %s, want %s", got, wantDecisions)
+	}
+}
@@ -0,0 +1,244 @@
+package curlmin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// harRecorder collects the requests sent during a run, for HARFile
+type harRecorder struct {
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// The subset of the HAR 1.2 format (http://www.softwareishard.com/blog/har-12-spec/)
+// written by harRecorder
+type (
+	harLog struct {
+		Log harContent `json:"log"`
+	}
+	harContent struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	}
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	harEntry struct {
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+		// Comment is the curl command that was run
+		Comment string `json:"comment"`
+	}
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *harPostData   `json:"postData,omitempty"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+	harResponse struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		Content     harBody        `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+		// Comment says why there's no response, if there isn't one
+		Comment string `json:"comment,omitempty"`
+		// RedirectChain and Trailers aren't part of HAR, but are needed to
+		// replay the response
+		RedirectChain []string       `json:"_redirectChain,omitempty"`
+		Trailers      []harNameValue `json:"_trailers,omitempty"`
+	}
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+	harBody struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+		Encoding string `json:"encoding,omitempty"`
+	}
+	harTimings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
+
+func newHARRecorder() *harRecorder {
+	return &harRecorder{}
+}
+
+// add records a request sent for curlCmd at start, and the response or
+// error it got after elapsed
+func (r *harRecorder) add(curlCmd string, start time.Time, elapsed time.Duration, resp Response, err error) {
+	ms := float64(elapsed.Microseconds()) / 1000
+	entry := harEntry{
+		StartedDateTime: start.Format("2006-01-02T15:04:05.000Z07:00"),
+		Time:            ms,
+		Request:         harRequestFor(curlCmd),
+		Response:        harResponseFor(resp),
+		Timings:         harTimings{Wait: ms},
+		Comment:         strings.TrimSpace(curlCmd),
+	}
+	switch {
+	case err != nil:
+		entry.Response.Comment = err.Error()
+	case resp.TimedOut:
+		entry.Response.Comment = "timed out"
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// write saves the recorded requests to a HAR file at path
+func (r *harRecorder) write(path string) error {
+	r.mu.Lock()
+	entries := slices.Clone(r.entries)
+	r.mu.Unlock()
+	if entries == nil {
+		entries = []harEntry{}
+	}
+
+	data, err := json.MarshalIndent(harLog{Log: harContent{
+		Version: "1.2",
+		Creator: harCreator{Name: "curlmin", Version: "1"},
+		Entries: entries,
+	}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode HAR: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write HAR file: %w", err)
+	}
+	return nil
+}
+
+// harRequestFor describes the request a curl command sends, as far as
+// httpRequest understands it
+func harRequestFor(curlCmd string) harRequest {
+	request := harRequest{
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     []harNameValue{},
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+	}
+
+	curl, err := ParseCurlCommand(curlCmd)
+	if err != nil {
+		return request
+	}
+	req, err := curl.httpRequest()
+	if err != nil {
+		return request
+	}
+
+	request.Method = req.Method
+	request.URL = req.URL.String()
+	request.Headers = harHeaders(req.Header)
+	for _, cookie := range req.Cookies() {
+		request.Cookies = append(request.Cookies, harNameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	for _, pair := range strings.Split(req.URL.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		request.QueryString = append(request.QueryString, harNameValue{Name: name, Value: value})
+	}
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: string(body)}
+		request.BodySize = len(body)
+	}
+	return request
+}
+
+// harResponseFor describes a response
+func harResponseFor(resp Response) harResponse {
+	response := harResponse{
+		Status:      resp.StatusCode,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(resp.Header),
+		Content: harBody{
+			Size:     len(resp.Body),
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     resp.Body,
+		},
+		RedirectURL:   resp.Header.Get("Location"),
+		HeadersSize:   -1,
+		BodySize:      len(resp.Body),
+		RedirectChain: resp.RedirectChain,
+	}
+	if !utf8.ValidString(resp.Body) {
+		response.Content.Text = base64.StdEncoding.EncodeToString([]byte(resp.Body))
+		response.Content.Encoding = "base64"
+	}
+	if len(resp.Trailer) > 0 {
+		response.Trailers = harHeaders(resp.Trailer)
+	}
+
+	// Status is the first status line without its version, which starts
+	// RawHeaders
+	_, response.StatusText, _ = strings.Cut(resp.Status, " ")
+	if version, _, ok := strings.Cut(resp.RawHeaders, " "); ok && strings.HasPrefix(version, "HTTP/") {
+		response.HTTPVersion = version
+	}
+
+	for _, cookie := range (&http.Response{Header: resp.Header}).Cookies() {
+		response.Cookies = append(response.Cookies, harNameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	return response
+}
+
+// harHeaders lists headers sorted by name
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[name] {
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
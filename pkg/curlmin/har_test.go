@@ -0,0 +1,84 @@
+package curlmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHARFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xyz" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "seen", Value: "1"})
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	harFile := filepath.Join(t.TempDir(), "out.har")
+	curlCmd := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz' -H 'X-A: 1' -b 'session=abc' '%s/?id=1&q=a+b'`, server.URL)
+	_, report, err := New(Options{
+		MinimizeHeaders:    true,
+		MinimizeCookies:    true,
+		MinimizeParams:     true,
+		CompareBodyContent: true,
+		HARFile:            harFile,
+	}).MinimizeCurlCommandWithReport(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	data, err := os.ReadFile(harFile)
+	if err != nil {
+		t.Fatalf("Failed to read HAR file: %v", err)
+	}
+	var har harLog
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("HAR file isn't valid JSON: %v", err)
+	}
+	entries := har.Log.Entries
+	if len(entries) != report.Requests {
+		t.Fatalf("HAR file has %d entries, want one for each of the %d requests sent", len(entries), report.Requests)
+	}
+
+	// The first entry is the baseline
+	baseline := entries[0]
+	if baseline.Comment != curlCmd {
+		t.Errorf("Baseline comment = %q, want the command %q", baseline.Comment, curlCmd)
+	}
+	if baseline.Request.Method != "GET" || baseline.Request.URL != server.URL+"/?id=1&q=a+b" {
+		t.Errorf("Baseline request = %s %s", baseline.Request.Method, baseline.Request.URL)
+	}
+	wantQuery := []harNameValue{{Name: "id", Value: "1"}, {Name: "q", Value: "a b"}}
+	if fmt.Sprint(baseline.Request.QueryString) != fmt.Sprint(wantQuery) {
+		t.Errorf("Baseline query string = %v, want %v", baseline.Request.QueryString, wantQuery)
+	}
+	if fmt.Sprint(baseline.Request.Cookies) != fmt.Sprint([]harNameValue{{Name: "session", Value: "abc"}}) {
+		t.Errorf("Baseline cookies = %v", baseline.Request.Cookies)
+	}
+	response := baseline.Response
+	if response.Status != 200 || response.StatusText != "OK" || response.Content.Text != "Success" {
+		t.Errorf("Baseline response = %d %q %q", response.Status, response.StatusText, response.Content.Text)
+	}
+	if fmt.Sprint(response.Cookies) != fmt.Sprint([]harNameValue{{Name: "seen", Value: "1"}}) {
+		t.Errorf("Baseline response cookies = %v", response.Cookies)
+	}
+
+	// Candidates without the Authorization header are recorded with their
+	// 401 responses
+	unauthorized := 0
+	for _, entry := range entries[1:] {
+		if entry.Response.Status == http.StatusUnauthorized {
+			unauthorized++
+		}
+	}
+	if unauthorized == 0 {
+		t.Error("No candidate recorded with a 401 response")
+	}
+}
@@ -0,0 +1,73 @@
+package curlmin
+
+import (
+	"slices"
+	"time"
+)
+
+// HistoryEntry records the elements a run found to be required, so that
+// runs against the same endpoint can be compared over time (e.g. to notice a
+// query parameter that became required after a deploy)
+type HistoryEntry struct {
+	// ID identifies the endpoint, see CommandID
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	// Required lists the kept elements as "kind name", without values,
+	// since values like tokens change between runs anyway
+	Required []string `json:"required"`
+}
+
+// CommandID identifies the endpoint a curl command requests by its method,
+// host, and path, e.g. "GET example.com/api/test"
+func CommandID(curlCmd string) (string, error) {
+	curl, err := ParseCurlCommand(curlCmd)
+	if err != nil {
+		return "", err
+	}
+	req, err := curl.httpRequest()
+	if err != nil {
+		return "", err
+	}
+	return req.Method + " " + req.URL.Host + req.URL.Path, nil
+}
+
+// NewHistoryEntry summarizes the decisions of a run of curlCmd
+func NewHistoryEntry(curlCmd string, decisions []Decision, at time.Time) (HistoryEntry, error) {
+	id, err := CommandID(curlCmd)
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+
+	entry := HistoryEntry{ID: id, Time: at, Required: []string{}}
+	for _, d := range decisions {
+		if d.Removed {
+			continue
+		}
+		name := d.Name
+		if d.Kind == KindHeader {
+			name = headerElementName(d.Name)
+		}
+		element := d.Kind + " " + name
+		if !slices.Contains(entry.Required, element) {
+			entry.Required = append(entry.Required, element)
+		}
+	}
+	slices.Sort(entry.Required)
+	return entry, nil
+}
+
+// Changes lists the elements required now but not in prev (added), and those
+// required in prev but not anymore (removed)
+func (e HistoryEntry) Changes(prev HistoryEntry) (added, removed []string) {
+	for _, element := range e.Required {
+		if !slices.Contains(prev.Required, element) {
+			added = append(added, element)
+		}
+	}
+	for _, element := range prev.Required {
+		if !slices.Contains(e.Required, element) {
+			removed = append(removed, element)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,40 @@
+package curlmin
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHistoryEntry(t *testing.T) {
+	curlCmd := `curl -H 'authorization: Bearer xyz789' --oauth2-bearer abc -b 'session=abc123' 'http://example.com/api/test?auth_key=def456'`
+	if id, err := CommandID(curlCmd); err != nil || id != "GET example.com/api/test" {
+		t.Errorf("CommandID() = %q, %v, want %q", id, err, "GET example.com/api/test")
+	}
+
+	decisions := []Decision{
+		{Kind: KindHeader, Name: "authorization: Bearer xyz789"},
+		{Kind: KindHeader, Name: "--oauth2-bearer abc"},
+		{Kind: KindHeader, Name: "Accept: */*", Removed: true},
+		{Kind: KindCookie, Name: "session"},
+		{Kind: KindQueryParam, Name: "auth_key"},
+	}
+	entry, err := NewHistoryEntry(curlCmd, decisions, time.Now())
+	if err != nil {
+		t.Fatalf("NewHistoryEntry() failed: %v", err)
+	}
+	want := []string{"cookie session", "header --oauth2-bearer", "header Authorization", "query parameter auth_key"}
+	if !slices.Equal(entry.Required, want) {
+		t.Errorf("NewHistoryEntry() required %q, want %q", entry.Required, want)
+	}
+
+	// A parameter that became required, and a cookie that no longer is
+	prev := HistoryEntry{ID: entry.ID, Required: []string{"cookie session", "cookie csrf", "header --oauth2-bearer", "header Authorization"}}
+	added, removed := entry.Changes(prev)
+	if !slices.Equal(added, []string{"query parameter auth_key"}) || !slices.Equal(removed, []string{"cookie csrf"}) {
+		t.Errorf("Changes() = %q, %q", added, removed)
+	}
+	if added, removed := entry.Changes(entry); added != nil || removed != nil {
+		t.Errorf("Changes() against itself = %q, %q", added, removed)
+	}
+}
@@ -0,0 +1,77 @@
+package curlmin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// runPreRequestHook runs Options.PreRequestHook before a request and applies
+// its output to the command about to be executed.
+//
+// The hook is run with the configured shell's -c, with the command in the CURLMIN_COMMAND
+// environment variable. Each non-empty line it prints updates one element
+// that's still present in the command:
+//
+//	header Name: value    sets the value of the -H header Name
+//	param name=value      sets the value of the URL query parameter name
+//	cookie name=value     sets the value of the cookie name
+//
+// Elements that have already been removed are left removed, so the hook can
+// always print every value it knows about. A hook that exits non-zero or
+// prints an unrecognized line fails the request.
+func (m *Minimizer) runPreRequestHook(curlCmd string) (string, error) {
+	hook := exec.CommandContext(m.context(), m.shell(), "-c", m.options.PreRequestHook)
+	killGroupOnCancel(m.context(), hook)
+	hook.Env = append(os.Environ(), "CURLMIN_COMMAND="+curlCmd)
+	var stdout, stderr bytes.Buffer
+	hook.Stdout = &stdout
+	hook.Stderr = &stderr
+	if err := hook.Run(); err != nil {
+		return "", fmt.Errorf("pre-request hook failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	curl, err := ParseCurlCommand(curlCmd)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		kind, update, _ := strings.Cut(line, " ")
+		var name, value string
+		var ok bool
+		switch kind {
+		case "header":
+			name, value, ok = strings.Cut(update, ":")
+			if ok {
+				curl.SetHeaderValue(strings.TrimSpace(name), strings.TrimSpace(value))
+			}
+		case "param":
+			name, value, ok = strings.Cut(update, "=")
+			if ok {
+				curl.SetQueryParamValue(name, value)
+			}
+		case "cookie":
+			name, value, ok = strings.Cut(update, "=")
+			if ok {
+				curl.SetCookieValue(strings.TrimSpace(name), value)
+			}
+		}
+		if !ok {
+			return "", fmt.Errorf("pre-request hook printed an unrecognized line: %q", line)
+		}
+
+		if m.options.Verbose {
+			m.logf("Pre-request hook set %s %s\n", kind, strings.TrimSpace(name))
+		}
+	}
+
+	return curl.ToString()
+}
@@ -0,0 +1,21 @@
+package curlmin
+
+import (
+	"regexp"
+)
+
+// Patterns for the common sources of jitter in otherwise identical HTML pages
+var (
+	htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlScriptPattern  = regexp.MustCompile(`(?is)(<script\b[^>]*>).*?(</script\s*>)`)
+	htmlNoncePattern   = regexp.MustCompile(`(?i)\s+nonce\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+)
+
+// stripHTMLNoise removes comments, inline script contents, and nonce
+// attributes from an HTML body, which often differ between requests for the
+// same page (build IDs, CSP nonces, embedded timestamps)
+func stripHTMLNoise(body string) string {
+	body = htmlCommentPattern.ReplaceAllString(body, "")
+	body = htmlScriptPattern.ReplaceAllString(body, "$1$2")
+	return htmlNoncePattern.ReplaceAllString(body, "")
+}
@@ -0,0 +1,48 @@
+package curlmin
+
+import (
+	"testing"
+)
+
+func TestStripHTMLNoise(t *testing.T) {
+	page := func(nonce, buildID, token string) string {
+		return `<!DOCTYPE html>
+<html>
+<head>
+  <!-- build ` + buildID + ` -->
+  <meta charset="utf-8">
+  <link rel="stylesheet" href="/app.css" nonce="` + nonce + `">
+  <script nonce="` + nonce + `">
+    window.__CSRF__ = "` + token + `";
+  </script>
+  <script src="/app.js" nonce='` + nonce + `' defer></script>
+</head>
+<body>
+  <!--
+    multi-line comment ` + buildID + `
+  -->
+  <h1>Welcome back, alice</h1>
+</body>
+</html>`
+	}
+
+	a := page("r4nd0m", "2024.01.02-abc", "tok1")
+	b := page("0th3r", "2024.01.03-def", "tok2")
+	if a == b {
+		t.Fatal("test pages should differ before stripping")
+	}
+	if stripHTMLNoise(a) != stripHTMLNoise(b) {
+		t.Errorf("stripped pages differ:\n%s\n---\n%s", stripHTMLNoise(a), stripHTMLNoise(b))
+	}
+
+	// Visible content still counts
+	c := stripHTMLNoise(page("r4nd0m", "2024.01.02-abc", "tok1") + "<p>Logged out</p>")
+	if c == stripHTMLNoise(a) {
+		t.Error("stripping removed a real content difference")
+	}
+
+	want := `<script src="/app.js" defer></script>`
+	if got := stripHTMLNoise(`<script src="/app.js" nonce='abc' defer></script>`); got != want {
+		t.Errorf("stripHTMLNoise() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package curlmin
+
+import (
+	"maps"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+// isIrrelevantParam reports whether a query parameter matches one of the
+// IrrelevantParams patterns. Parameters that are always kept never are.
+func (m *Minimizer) isIrrelevantParam(name string) bool {
+	if m.keeps(KindQueryParam, name) {
+		return false
+	}
+	for _, pattern := range m.options.IrrelevantParams {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// stripIrrelevantParams removes the query parameters matching IrrelevantParams
+// without testing whether they're needed, and returns their names
+func (m *Minimizer) stripIrrelevantParams(curl *CurlCommand) []string {
+	if len(m.options.IrrelevantParams) == 0 {
+		return nil
+	}
+
+	var stripped []string
+	params, _ := curl.FindQueryParams()
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		if m.isIrrelevantParam(name) && curl.RemoveQueryParam(name) == nil {
+			stripped = append(stripped, name)
+		}
+	}
+
+	// Parameters given with --url-query
+	for _, i := range slices.Backward(curl.FindURLQueryArgs()) {
+		value := strings.TrimPrefix(curl.literalArg(i+1), "+")
+		name, _, _ := strings.Cut(value, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if m.isIrrelevantParam(name) {
+			curl.RemoveArg(i)
+			if !slices.Contains(stripped, name) {
+				stripped = append(stripped, name)
+			}
+		}
+	}
+
+	return stripped
+}
@@ -0,0 +1,33 @@
+package curlmin
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStripIrrelevantParams(t *testing.T) {
+	curl, err := ParseCurlCommand(`curl 'http://example.com/?id=1&utm_source=x&utm_medium=y&fbclid=z' --url-query 'utm_term=w' --url-query 'q=1'`)
+	if err != nil {
+		t.Fatalf("Failed to parse curl command: %v", err)
+	}
+
+	m := New(Options{IrrelevantParams: []string{"utm_*", "fbclid"}})
+	stripped := m.stripIrrelevantParams(curl)
+	if want := []string{"fbclid", "utm_medium", "utm_source", "utm_term"}; !slices.Equal(stripped, want) {
+		t.Errorf("stripIrrelevantParams() stripped %v, want %v", stripped, want)
+	}
+
+	cmd, err := curl.ToString()
+	if err != nil {
+		t.Fatalf("Failed to print curl command: %v", err)
+	}
+	if want := `curl 'http://example.com/?id=1' --url-query 'q=1'`; strings.TrimSpace(cmd) != want {
+		t.Errorf("after stripIrrelevantParams got %q, want %q", cmd, want)
+	}
+
+	// Invalid patterns are reported before any requests are made
+	if _, err := New(Options{IrrelevantParams: []string{"["}}).MinimizeCurlCommand(`curl http://example.com/`); err == nil {
+		t.Error("MinimizeCurlCommand() accepted an invalid pattern")
+	}
+}
@@ -0,0 +1,91 @@
+package curlmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/itchyny/gojq"
+)
+
+// compileJQ compiles JQExpression, if set
+func compileJQ(options Options) (*gojq.Code, error) {
+	if options.JQExpression == "" {
+		return nil, nil
+	}
+	query, err := gojq.Parse(options.JQExpression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq expression %q: %w", options.JQExpression, err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq expression %q: %w", options.JQExpression, err)
+	}
+	return code, nil
+}
+
+// jqOutput runs JQExpression on a JSON body and returns all the values it
+// produces
+func (m *Minimizer) jqOutput(body string) ([]any, error) {
+	var input any
+	if err := json.Unmarshal([]byte(body), &input); err != nil {
+		return nil, fmt.Errorf("body isn't JSON: %w", err)
+	}
+
+	var outputs []any
+	// Stop an expression that never ends along with the run
+	iter := m.jq.RunWithContext(m.context(), input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			return outputs, nil
+		}
+		if err, ok := v.(error); ok {
+			return nil, err
+		}
+		outputs = append(outputs, v)
+	}
+}
+
+// jqEqual reports whether JQExpression extracts the same values from both
+// bodies. A body that isn't JSON, or that the expression fails on, never
+// matches.
+func (m *Minimizer) jqEqual(resp1, resp2 Response) bool {
+	out1, err1 := m.jqOutput(resp1.Body)
+	out2, err2 := m.jqOutput(resp2.Body)
+	return err1 == nil && err2 == nil && reflect.DeepEqual(out1, out2)
+}
+
+// describeJQChange explains how a candidate response changed the values
+// JQExpression extracts, or returns "" if it didn't or the status changed
+// too
+func (m *Minimizer) describeJQChange(baseline, resp Response) string {
+	if m.options.JQExpression == "" || baseline.TimedOut != resp.TimedOut || baseline.StatusCode != resp.StatusCode {
+		return ""
+	}
+	before, _ := m.jqOutput(baseline.Body)
+	after, err := m.jqOutput(resp.Body)
+	if err != nil {
+		return fmt.Sprintf("removing breaks %s: %v", m.options.JQExpression, err)
+	}
+	if reflect.DeepEqual(before, after) {
+		return ""
+	}
+	return fmt.Sprintf("removing changes %s %s -> %s", m.options.JQExpression, jqString(before), jqString(after))
+}
+
+// jqString formats jq output values as JSON, one after the other like jq -c
+func jqString(values []any) string {
+	var s string
+	for i, v := range values {
+		if i > 0 {
+			s += " "
+		}
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			encoded = []byte(fmt.Sprint(v))
+		}
+		s += string(encoded)
+	}
+	return s
+}
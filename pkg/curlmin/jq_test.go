@@ -0,0 +1,52 @@
+package curlmin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJQExpression(t *testing.T) {
+	minimizer := New(Options{JQExpression: ".data.id"})
+	baseline := Response{StatusCode: 200, Body: `{"data":{"id":42},"requestId":"a1"}`}
+
+	if !minimizer.compareResponses(baseline, Response{StatusCode: 200, Body: `{"requestId":"b2","data":{"id":42.0}}`}) {
+		t.Error("compareResponses() = false for bodies with the same extracted value")
+	}
+	for _, body := range []string{`{"data":{"id":7},"requestId":"a1"}`, `{"error":"denied"}`, "Access denied"} {
+		if minimizer.compareResponses(baseline, Response{StatusCode: 200, Body: body}) {
+			t.Errorf("compareResponses() = true for %q", body)
+		}
+	}
+	if got, want := minimizer.describeJQChange(baseline, Response{StatusCode: 200, Body: `{"data":{"id":7}}`}), "removing changes .data.id 42 -> 7"; got != want {
+		t.Errorf("describeJQChange() = %q, want %q", got, want)
+	}
+
+	// The expression has to parse, and to work on the baseline
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "not json")
+	}))
+	defer server.Close()
+	curlCmd := fmt.Sprintf(`curl '%s/'`, server.URL)
+
+	if _, err := New(Options{JQExpression: ".data["}).MinimizeCurlCommand(curlCmd); err == nil || !strings.Contains(err.Error(), "invalid jq expression") {
+		t.Errorf("Error for invalid expression is %v, want invalid jq expression", err)
+	}
+	if _, err := New(Options{JQExpression: ".data.id"}).MinimizeCurlCommand(curlCmd); !errors.Is(err, ErrBaseline) {
+		t.Errorf("Error for a non-JSON baseline is %v, want ErrBaseline", err)
+	}
+
+	// An expression that never ends stops with the run
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	minimizer = New(Options{JQExpression: "last(repeat(1))"})
+	minimizer.ctx = ctx
+	if _, err := minimizer.jqOutput(`{}`); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("jqOutput() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,163 @@
+package curlmin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonEqual reports whether two bodies hold the same JSON value, ignoring
+// whitespace, key order, and how numbers are formatted (so 1, 1.0, and 1e0
+// are equal). Bodies that aren't valid JSON are compared byte for byte.
+func jsonEqual(body1, body2 string) bool {
+	v1, err1 := decodeJSON(body1)
+	v2, err2 := decodeJSON(body2)
+	if err1 != nil || err2 != nil {
+		return body1 == body2
+	}
+	return reflect.DeepEqual(v1, v2)
+}
+
+// decodeJSON decodes a single JSON value with all numbers normalized
+func decodeJSON(body string) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(body)))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	// Reject trailing data after the first value
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &json.SyntaxError{Offset: dec.InputOffset()}
+	}
+
+	return normalizeJSONNumbers(v), nil
+}
+
+// jsonNumber is a number's exact value in canonical form, kept distinct from
+// string so that 1 and "1" don't compare equal
+type jsonNumber string
+
+// normalizeJSONNumbers replaces every json.Number in v with its exact
+// rational value in canonical form, so that numerically equal numbers
+// compare equal regardless of precision (big integers stay exact)
+func normalizeJSONNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		r, ok := new(big.Rat).SetString(v.String())
+		if !ok {
+			return jsonNumber(v.String())
+		}
+		return jsonNumber(r.RatString())
+	case map[string]any:
+		for key, value := range v {
+			v[key] = normalizeJSONNumbers(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = normalizeJSONNumbers(value)
+		}
+	}
+	return v
+}
+
+// parseIgnoreJSONPath splits a path like .meta.requestId or .items[].ts into
+// its segments: object keys, array indices like "[0]", and "[]" for every
+// element of an array
+func parseIgnoreJSONPath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "[") {
+		return nil, fmt.Errorf("invalid JSON path %q: must start with . or [", path)
+	}
+
+	var segments []string
+	for rest := path; rest != ""; {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: empty key", path)
+			}
+			segments = append(segments, rest[1:end+1])
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: unclosed [", path)
+			}
+			if index := rest[1:end]; index != "" {
+				if _, err := strconv.Atoi(index); err != nil {
+					return nil, fmt.Errorf("invalid JSON path %q: bad index %q", path, index)
+				}
+			}
+			segments = append(segments, rest[:end+1])
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid JSON path %q", path)
+		}
+	}
+	return segments, nil
+}
+
+// pruneJSONPath removes the value at path (as split by parseIgnoreJSONPath)
+// from a decoded JSON value, if it's there
+func pruneJSONPath(v any, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	segment, last := path[0], len(path) == 1
+
+	switch v := v.(type) {
+	case map[string]any:
+		if strings.HasPrefix(segment, "[") {
+			return
+		}
+		if last {
+			delete(v, segment)
+		} else if child, ok := v[segment]; ok {
+			pruneJSONPath(child, path[1:])
+		}
+	case []any:
+		if !strings.HasPrefix(segment, "[") {
+			return
+		}
+		for i := range v {
+			if segment == "[]" || segment == fmt.Sprintf("[%d]", i) {
+				if last {
+					// Elements can't be removed without shifting the
+					// others, so blank them out instead
+					v[i] = nil
+				} else {
+					pruneJSONPath(v[i], path[1:])
+				}
+			}
+		}
+	}
+}
+
+// jsonEqualIgnoring is jsonEqual with the values at the given paths removed
+// from both bodies first
+func jsonEqualIgnoring(body1, body2 string, paths [][]string) bool {
+	if len(paths) == 0 {
+		return jsonEqual(body1, body2)
+	}
+	v1, err1 := decodeJSON(body1)
+	v2, err2 := decodeJSON(body2)
+	if err1 != nil || err2 != nil {
+		return body1 == body2
+	}
+	for _, path := range paths {
+		pruneJSONPath(v1, path)
+		pruneJSONPath(v2, path)
+	}
+	return reflect.DeepEqual(v1, v2)
+}
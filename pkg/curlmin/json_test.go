@@ -0,0 +1,73 @@
+package curlmin
+
+import (
+	"testing"
+)
+
+func TestJSONEqual(t *testing.T) {
+	tests := []struct {
+		name  string
+		body1 string
+		body2 string
+		want  bool
+	}{
+		{"identical", `{"a":1}`, `{"a":1}`, true},
+		{"whitespace and key order", `{"a":1,"b":[true,null]}`, "{\n  \"b\": [true, null],\n  \"a\": 1\n}", true},
+		{"trailing zero", `{"price":1.0}`, `{"price":1}`, true},
+		{"exponent", `[1e3, 2.5E-1]`, `[1000, 0.25]`, true},
+		{"large integers stay exact", `{"id":12345678901234567890}`, `{"id":12345678901234567891}`, false},
+		{"different numbers", `{"a":1}`, `{"a":2}`, false},
+		{"number versus string", `{"a":1}`, `{"a":"1"}`, false},
+		{"different keys", `{"a":1}`, `{"b":1}`, false},
+		{"not JSON, same bytes", `Success`, `Success`, true},
+		{"not JSON, different bytes", `Success`, `Unauthorized`, false},
+		{"trailing data", `{"a":1} x`, `{"a":1}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonEqual(tt.body1, tt.body2); got != tt.want {
+				t.Errorf("jsonEqual(%q, %q) = %v, want %v", tt.body1, tt.body2, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONEqualIgnoring(t *testing.T) {
+	tests := []struct {
+		name         string
+		body1, body2 string
+		paths        []string
+		want         bool
+	}{
+		{"top-level key", `{"id":1,"timestamp":100}`, `{"id":1,"timestamp":200}`, []string{".timestamp"}, true},
+		{"nested key", `{"id":1,"meta":{"requestId":"a","v":2}}`, `{"id":1,"meta":{"requestId":"b","v":2}}`, []string{".meta.requestId"}, true},
+		{"other fields still compared", `{"id":1,"meta":{"requestId":"a","v":2}}`, `{"id":1,"meta":{"requestId":"b","v":3}}`, []string{".meta.requestId"}, false},
+		{"every array element", `{"items":[{"id":1,"ts":1},{"id":2,"ts":2}]}`, `{"items":[{"id":1,"ts":3},{"id":2,"ts":4}]}`, []string{".items[].ts"}, true},
+		{"one array element", `{"items":[{"ts":1},{"ts":2}]}`, `{"items":[{"ts":3},{"ts":4}]}`, []string{".items[0].ts"}, false},
+		{"missing path", `{"id":1}`, `{"id":1}`, []string{".meta.requestId"}, true},
+		{"key gone in one body", `{"id":1,"timestamp":100}`, `{"id":1}`, []string{".timestamp"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths [][]string
+			for _, path := range tt.paths {
+				segments, err := parseIgnoreJSONPath(path)
+				if err != nil {
+					t.Fatalf("parseIgnoreJSONPath(%q) error = %v", path, err)
+				}
+				paths = append(paths, segments)
+			}
+			if got := jsonEqualIgnoring(tt.body1, tt.body2, paths); got != tt.want {
+				t.Errorf("jsonEqualIgnoring(%q, %q, %v) = %v, want %v", tt.body1, tt.body2, tt.paths, got, tt.want)
+			}
+		})
+	}
+
+	for _, path := range []string{"timestamp", ".a..b", ".a[x]", ".a[0"} {
+		if _, err := parseIgnoreJSONPath(path); err == nil {
+			t.Errorf("parseIgnoreJSONPath(%q) succeeded, want an error", path)
+		}
+	}
+}
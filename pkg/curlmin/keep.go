@@ -0,0 +1,82 @@
+package curlmin
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// listed reports whether name appears in a KeepHeaders, KeepCookies, or
+// KeepParams list, ignoring case
+func listed(list []string, name string) bool {
+	return slices.ContainsFunc(list, func(kept string) bool {
+		return strings.EqualFold(kept, name)
+	})
+}
+
+// compileNamePattern compiles a KeepPatterns or Only* pattern to match whole
+// names, ignoring case
+func compileNamePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`(?i)^(?:` + pattern + `)$`)
+}
+
+// keeps reports whether an element of a kind is always kept without testing
+func (m *Minimizer) keeps(kind, name string) bool {
+	return m.keepReason(kind, name) != ""
+}
+
+// keepReason returns why an element of a kind is kept without testing:
+// because it's listed in KeepHeaders, KeepCookies, or KeepParams or matches
+// one of the KeepPatterns, or because OnlyHeaders, OnlyCookies, or
+// OnlyParams leave it out. It returns "" for elements to test.
+func (m *Minimizer) keepReason(kind, name string) string {
+	matches := func(re *regexp.Regexp) bool {
+		return re.MatchString(name)
+	}
+	if only := m.only[kind]; len(only) > 0 && !slices.ContainsFunc(only, matches) {
+		return "not selected"
+	}
+
+	var list []string
+	switch kind {
+	case KindHeader:
+		list = m.options.KeepHeaders
+	case KindCookie:
+		list = m.options.KeepCookies
+	case KindQueryParam:
+		list = m.options.KeepParams
+	}
+	if listed(list, name) || slices.ContainsFunc(m.keep, matches) {
+		return "listed to keep"
+	}
+	return ""
+}
+
+// headerElementName returns the name of a header-pass element without its
+// value: the header name for "Name: value" headers and the flag for auth and
+// TLS flags (e.g. --oauth2-bearer)
+func headerElementName(headerName string) string {
+	if strings.HasPrefix(headerName, "-") {
+		flag, _, _ := strings.Cut(headerName, " ")
+		return flag
+	}
+	name, _, _ := strings.Cut(headerName, ":")
+	return http.CanonicalHeaderKey(strings.TrimSpace(name))
+}
+
+// preserve records an element kept without testing, for the reason given
+// by keepReason
+func (m *Minimizer) preserve(kind, name, reason string) {
+	if m.options.Verbose {
+		m.logf("%s preserved as %s: %s\n", strings.ToUpper(kind[:1])+kind[1:], reason, name)
+	}
+	for _, d := range m.decisions {
+		if d.Kind == kind && d.Name == name {
+			return
+		}
+	}
+	decision := Decision{Kind: kind, Name: name, Reason: reason + ", not tested"}
+	m.decisions = append(m.decisions, decision)
+	m.progressDecision(decision)
+}
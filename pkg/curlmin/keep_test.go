@@ -0,0 +1,92 @@
+package curlmin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestKeepLists(t *testing.T) {
+	// The server doesn't need anything
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'X-Api-Key: abc' -H 'Accept: */*' -H 'Cookie: sid=1; _ga=2' -b 'pref=dark; _gid=3' '%s/?auth_key=def456&token=xyz&utm_source=test'`, server.URL)
+
+	minimize := func(options Options) string {
+		options.MinimizeHeaders = true
+		options.MinimizeCookies = true
+		options.MinimizeParams = true
+		minimizedCmd, err := New(options).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command: %v", err)
+		}
+		return strings.TrimSpace(minimizedCmd)
+	}
+
+	// Nothing is kept by default, including auth_key
+	if got, want := minimize(Options{}), fmt.Sprintf(`curl '%s/'`, server.URL); got != want {
+		t.Errorf("Minimized command is %q, want %q", got, want)
+	}
+
+	got := minimize(Options{
+		KeepHeaders: []string{"x-api-key"},
+		KeepCookies: []string{"SID", "pref"},
+		KeepParams:  []string{"Token"},
+	})
+	want := fmt.Sprintf(`curl -H 'X-Api-Key: abc' -H 'Cookie: sid=1' -b 'pref=dark' '%s/?token=xyz'`, server.URL)
+	if got != want {
+		t.Errorf("Minimized command is %q, want %q", got, want)
+	}
+
+	// Patterns cover every kind of element, matching whole names
+	got = minimize(Options{KeepPatterns: []string{"x-api-.*", "_g.*", "auth"}})
+	want = fmt.Sprintf(`curl -H 'X-Api-Key: abc' -H 'Cookie: _ga=2' -b '_gid=3' '%s/'`, server.URL)
+	if got != want {
+		t.Errorf("Minimized command is %q, want %q", got, want)
+	}
+
+	if _, err := New(Options{KeepPatterns: []string{"("}}).MinimizeCurlCommand(curlCmd); err == nil {
+		t.Error("Expected an error for an invalid keep pattern")
+	}
+}
+
+func TestOnlyFilters(t *testing.T) {
+	// The server doesn't need anything
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'X-Trace-Id: 1' -H 'Accept: */*' -H 'Cookie: sid=1; _ga=2' '%s/?id=7&utm_source=test&utm_medium=email'`, server.URL)
+
+	min := New(Options{
+		MinimizeHeaders: true,
+		MinimizeCookies: true,
+		MinimizeParams:  true,
+		OnlyHeaders:     []string{"x-trace-.*"},
+		OnlyCookies:     []string{"_ga"},
+		OnlyParams:      []string{"utm_.*"},
+	})
+	minimizedCmd, err := min.MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+	want := fmt.Sprintf(`curl -H 'Accept: */*' -H 'Cookie: sid=1' '%s/?id=7'`, server.URL)
+	if got := strings.TrimSpace(minimizedCmd); got != want {
+		t.Errorf("Minimized command is %q, want %q", got, want)
+	}
+
+	if !slices.Contains(min.Decisions(), Decision{Kind: KindCookie, Name: "sid", Reason: "not selected, not tested"}) {
+		t.Errorf("Decisions %+v don't include sid as not selected", min.Decisions())
+	}
+
+	if _, err := New(Options{OnlyParams: []string{"["}}).MinimizeCurlCommand(curlCmd); err == nil {
+		t.Error("Expected an error for an invalid only pattern")
+	}
+}
@@ -0,0 +1,23 @@
+package curlmin
+
+import (
+	"fmt"
+	"os"
+)
+
+// Logger receives verbose output, one formatted message at a time. A
+// *log.Logger satisfies it. Printf may be called from several goroutines at
+// once when Options.Concurrency is above 1.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// logf writes verbose output to the Logger, or to stderr without one, so
+// that stdout is left to the caller
+func (m *Minimizer) logf(format string, args ...any) {
+	if m.options.Logger != nil {
+		m.options.Logger.Printf(format, args...)
+		return
+	}
+	fmt.Fprintf(os.Stderr, format, args...)
+}
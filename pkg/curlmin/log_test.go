@@ -0,0 +1,40 @@
+package curlmin
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	// The server needs the X-Api-Key header and nothing else
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	min := New(Options{
+		MinimizeHeaders: true,
+		Verbose:         true,
+		Logger:          log.New(&buf, "", 0),
+	})
+	curlCmd := fmt.Sprintf(`curl -H 'X-Api-Key: abc' -H 'Accept: */*' '%s/'`, server.URL)
+	if _, err := min.MinimizeCurlCommand(curlCmd); err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	for _, want := range []string{"Header needed: X-Api-Key: abc\n", "Header not needed: Accept: */*\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Log doesn't contain %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package curlmin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// compileMatch compiles MatchPattern when MatchIsRegex is set
+func compileMatch(options Options) (*regexp.Regexp, error) {
+	if options.MatchPattern == "" || !options.MatchIsRegex {
+		return nil, nil
+	}
+	re, err := regexp.Compile(options.MatchPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid match pattern %q: %w", options.MatchPattern, err)
+	}
+	return re, nil
+}
+
+// bodyMatches reports whether a body contains MatchPattern, or matches it
+// as a regular expression when MatchIsRegex is set
+func (m *Minimizer) bodyMatches(body string) bool {
+	if m.match != nil {
+		return m.match.MatchString(body)
+	}
+	return strings.Contains(body, m.options.MatchPattern)
+}
+
+// gainsForbidden reports whether a candidate's body matches ForbidRegex
+// while the baseline's doesn't
+func (m *Minimizer) gainsForbidden(baseline, resp Response) bool {
+	return m.forbid != nil && m.forbid.MatchString(resp.Body) && !m.forbid.MatchString(baseline.Body)
+}
+
+// describeMatchLoss explains why a candidate response no longer matches
+func (m *Minimizer) describeMatchLoss(baseline, resp Response) string {
+	if baseline.TimedOut != resp.TimedOut {
+		return describeDifference(baseline, resp)
+	}
+	return fmt.Sprintf("removing drops %q from the body", m.options.MatchPattern)
+}
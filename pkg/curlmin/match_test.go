@@ -0,0 +1,97 @@
+package curlmin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMatchPattern(t *testing.T) {
+	// Every response carries a fresh CSRF token, so bodies never match exactly
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		token := time.Now().UnixNano()
+		if r.Header.Get("Authorization") != "Bearer xyz" {
+			fmt.Fprintf(w, `<input name="csrf" value="%d"> Please log in`, token)
+			return
+		}
+		fmt.Fprintf(w, `<input name="csrf" value="%d"> Welcome back, user 42`, token)
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'Accept: */*' -H 'Authorization: Bearer xyz' -H 'X-Requested-With: XMLHttpRequest' '%s/'`, server.URL)
+	want := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz' '%s/'`, server.URL)
+
+	for _, options := range []Options{
+		{MatchPattern: "Welcome back"},
+		{MatchPattern: `user \d+`, MatchIsRegex: true},
+	} {
+		options.MinimizeHeaders = true
+		minimizedCmd, err := New(options).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command with %q: %v", options.MatchPattern, err)
+		}
+		if got := strings.TrimSpace(minimizedCmd); got != want {
+			t.Errorf("Minimized command with %q is %q, want %q", options.MatchPattern, got, want)
+		}
+	}
+
+	// An invalid regex fails before any request is sent
+	requests.Store(0)
+	_, err := New(Options{MatchPattern: "user [", MatchIsRegex: true}).MinimizeCurlCommand(curlCmd)
+	if err == nil || !strings.Contains(err.Error(), "invalid match pattern") {
+		t.Errorf("Error for invalid regex is %v, want invalid match pattern", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("Sent %d requests with an invalid regex, want 0", n)
+	}
+
+	// A marker missing from the baseline is a baseline error
+	_, err = New(Options{MatchPattern: "Goodbye"}).MinimizeCurlCommand(curlCmd)
+	if !errors.Is(err, ErrBaseline) {
+		t.Errorf("Error for a marker missing from the baseline is %v, want ErrBaseline", err)
+	}
+}
+
+func TestForbidRegex(t *testing.T) {
+	// Without the session header, the server answers with a denial page of the same size
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Session") != "abc" {
+			fmt.Fprint(w, "Access Denied!")
+			return
+		}
+		fmt.Fprint(w, "Hello, user 42")
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'Accept: */*' -H 'X-Session: abc' '%s/'`, server.URL)
+	for _, tc := range []struct {
+		forbid string
+		want   string
+	}{
+		{"", fmt.Sprintf(`curl '%s/'`, server.URL)},
+		{"(?i)access denied", fmt.Sprintf(`curl -H 'X-Session: abc' '%s/'`, server.URL)},
+	} {
+		minimizedCmd, err := New(Options{
+			MinimizeHeaders:  true,
+			CompareByteCount: true,
+			ForbidRegex:      tc.forbid,
+		}).MinimizeCurlCommand(curlCmd)
+		if err != nil {
+			t.Fatalf("Failed to minimize curl command with %q: %v", tc.forbid, err)
+		}
+		if got := strings.TrimSpace(minimizedCmd); got != tc.want {
+			t.Errorf("Minimized command with %q is %q, want %q", tc.forbid, got, tc.want)
+		}
+	}
+
+	if _, err := New(Options{ForbidRegex: "denied ["}).MinimizeCurlCommand(curlCmd); err == nil || !strings.Contains(err.Error(), "invalid forbidden pattern") {
+		t.Errorf("Error for invalid regex is %v, want invalid forbidden pattern", err)
+	}
+}
@@ -0,0 +1,406 @@
+package curlmin
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/expand"
+)
+
+// executeNative performs the request described by a curl command using
+// net/http instead of shelling out to the curl binary
+func (m *Minimizer) executeNative(curlCmd string) (Response, error) {
+	curl, err := ParseCurlCommand(curlCmd)
+	if err != nil {
+		return Response{}, err
+	}
+
+	req, err := curl.ToHTTPRequest()
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	// Log the request if verbose mode is enabled
+	if m.options.Verbose {
+		m.logf("Executing (native): %s %s\n", req.Method, req.URL.String())
+	}
+
+	// Record the Location of each redirect that's followed
+	var redirectChain []string
+	client := m.nativeClient(curl)
+	if client.CheckRedirect == nil {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			// Same limit as net/http's default policy
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			redirectChain = append(redirectChain, req.Response.Header.Get("Location"))
+			return nil
+		}
+	}
+	resp, err := client.Do(req.WithContext(m.context()))
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() && m.options.TimeoutComparison == TimeoutMatchesTimeout {
+			if m.options.Verbose {
+				m.logf("Request timed out\n")
+			}
+			return Response{TimedOut: true}, nil
+		}
+		return Response{}, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Record what was sent, mirroring curl's trace output
+	var sentHeaders []string
+	if m.options.TraceRequests {
+		sentHeaders = append(sentHeaders, fmt.Sprintf("%s %s %s", req.Method, req.URL.RequestURI(), req.Proto))
+		sentHeaders = append(sentHeaders, "Host: "+req.URL.Host)
+		for name, values := range req.Header {
+			for _, value := range values {
+				sentHeaders = append(sentHeaders, name+": "+value)
+			}
+		}
+	}
+
+	// A redirect that wasn't followed ends the chain
+	if location := resp.Header.Get("Location"); location != "" {
+		redirectChain = append(redirectChain, location)
+	}
+
+	// Render the header block the way curl's -D dump would
+	var rawHeaders strings.Builder
+	fmt.Fprintf(&rawHeaders, "%s %s\r\n", resp.Proto, resp.Status)
+	resp.Header.Write(&rawHeaders)
+	rawHeaders.WriteString("\r\n")
+
+	// Trailers declared but never sent are left without values
+	var trailer http.Header
+	for name, values := range resp.Trailer {
+		if len(values) > 0 {
+			if trailer == nil {
+				trailer = make(http.Header)
+			}
+			trailer[name] = values
+		}
+	}
+
+	return Response{
+		StatusCode:    resp.StatusCode,
+		Status:        resp.Status,
+		Body:          string(respBytes),
+		Header:        resp.Header,
+		Trailer:       trailer,
+		RawHeaders:    rawHeaders.String(),
+		SentHeaders:   sentHeaders,
+		RedirectChain: redirectChain,
+	}, nil
+}
+
+// nativeClient builds the HTTP client for a request, honoring the curl flags
+// that affect transport behavior (-k, -L, -m)
+func (m *Minimizer) nativeClient(curl *CurlCommand) *http.Client {
+	transport := m.options.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		// Don't follow redirects unless -L is given, like curl
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	for i := 1; i < len(curl.Command.Args); i++ {
+		switch curl.literalArg(i) {
+		case "-k", "--insecure":
+			// Only adjust TLS settings on the default transport; a custom
+			// Transport is responsible for its own TLS configuration
+			if m.options.Transport == nil {
+				insecure := http.DefaultTransport.(*http.Transport).Clone()
+				insecure.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+				client.Transport = insecure
+			}
+		case "-L", "--location":
+			client.CheckRedirect = nil
+		case "-m", "--max-time":
+			var seconds float64
+			if _, err := fmt.Sscanf(curl.literalArg(i+1), "%g", &seconds); err == nil {
+				client.Timeout = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	return client
+}
+
+// literalArg returns the argument at index with shell quoting resolved
+func (c *CurlCommand) literalArg(index int) string {
+	if index < 0 || index >= len(c.Command.Args) {
+		return ""
+	}
+
+	value, err := expand.Literal(nil, c.Command.Args[index])
+	if err != nil {
+		return c.argString(index)
+	}
+	return value
+}
+
+// nativeUnsupportedFlags are the flags that change what's sent in ways
+// ToHTTPRequest doesn't interpret
+var nativeUnsupportedFlags = map[string]string{
+	"-F":                "multipart form data",
+	"--form":            "multipart form data",
+	"--form-string":     "multipart form data",
+	"-T":                "uploads",
+	"--upload-file":     "uploads",
+	"-x":                "proxies",
+	"--proxy":           "proxies",
+	"--preproxy":        "proxies",
+	"--socks4":          "proxies",
+	"--socks4a":         "proxies",
+	"--socks5":          "proxies",
+	"--socks5-hostname": "proxies",
+	"--unix-socket":     "Unix sockets",
+	"--resolve":         "host overrides",
+	"--connect-to":      "host overrides",
+}
+
+// ToHTTPRequest converts the curl command into an equivalent *http.Request.
+// Only the flags that shape the request itself are interpreted: the method,
+// URL, headers, cookies, body, user agent, referer, and basic auth. It fails
+// for multipart forms, uploads, proxies, and cookie files, rather than
+// building a different request.
+func (c *CurlCommand) ToHTTPRequest() (*http.Request, error) {
+	for i := 1; i < len(c.Command.Args); i++ {
+		flag := c.literalArg(i)
+		if what, ok := nativeUnsupportedFlags[flag]; ok {
+			return nil, fmt.Errorf("%s (%s) aren't supported natively", what, flag)
+		}
+		// Values without '=' name a cookie file
+		if (flag == "-b" || flag == "--cookie") && !strings.Contains(c.literalArg(i+1), "=") {
+			return nil, fmt.Errorf("cookie files (%s %s) aren't supported natively", flag, c.literalArg(i+1))
+		}
+		if takesValue(flag) {
+			i++
+		}
+	}
+	return c.httpRequest()
+}
+
+// httpRequest builds the request ToHTTPRequest describes, skipping the flags
+// it doesn't interpret
+func (c *CurlCommand) httpRequest() (*http.Request, error) {
+	urlIndex, err := c.FindURLArg()
+	if err != nil {
+		return nil, err
+	}
+	rawURL := c.literalArg(urlIndex)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+
+	method := ""
+	header := make(http.Header)
+	var cookies, data, urlQuery []string
+	getData := false
+
+	for i := 1; i < len(c.Command.Args); i++ {
+		flag := c.literalArg(i)
+		value := c.literalArg(i + 1)
+
+		switch {
+		case flag == "-X" || flag == "--request":
+			method = value
+		case flag == "-H" || flag == "--header":
+			if name, val, ok := strings.Cut(value, ":"); ok {
+				header.Add(strings.TrimSpace(name), strings.TrimSpace(val))
+			}
+		case flag == "-b" || flag == "--cookie":
+			if strings.Contains(value, "=") {
+				cookies = append(cookies, value)
+			}
+		case flag == "-A" || flag == "--user-agent":
+			header.Set("User-Agent", value)
+		case flag == "-e" || flag == "--referer":
+			header.Set("Referer", value)
+		case flag == "-u" || flag == "--user":
+			header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(value)))
+		case flag == "--oauth2-bearer":
+			header.Set("Authorization", "Bearer "+value)
+		case dataFlags[flag]:
+			value, err := dataArgValue(flag, value)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, value)
+			if flag == "--json" {
+				header.Set("Content-Type", "application/json")
+				header.Set("Accept", "application/json")
+			}
+		case flag == "--url-query":
+			// A leading '+' means the value is already encoded
+			if encoded, ok := strings.CutPrefix(value, "+"); ok {
+				urlQuery = append(urlQuery, encoded)
+			} else {
+				encoded, err := encodeDataURLEncode(value)
+				if err != nil {
+					return nil, err
+				}
+				urlQuery = append(urlQuery, encoded)
+			}
+		case flag == "-G" || flag == "--get":
+			getData = true
+		case flag == "-I" || flag == "--head":
+			method = http.MethodHead
+		case flag == "--compressed":
+			// net/http's transport already asks for gzip and decodes it
+		}
+
+		if takesValue(flag) {
+			i++
+		}
+	}
+
+	// --url-query values are appended to the URL's query
+	if len(urlQuery) > 0 {
+		if strings.Contains(rawURL, "?") {
+			rawURL += "&" + strings.Join(urlQuery, "&")
+		} else {
+			rawURL += "?" + strings.Join(urlQuery, "&")
+		}
+	}
+
+	if len(cookies) > 0 {
+		header.Add("Cookie", strings.Join(cookies, "; "))
+	}
+
+	// Request bodies default to POST, or are appended to the query with -G
+	var body io.Reader
+	if len(data) > 0 {
+		joined := strings.Join(data, "&")
+		if getData {
+			if strings.Contains(rawURL, "?") {
+				rawURL += "&" + joined
+			} else {
+				rawURL += "?" + joined
+			}
+		} else {
+			body = strings.NewReader(joined)
+			if method == "" {
+				method = http.MethodPost
+			}
+			if header.Get("Content-Type") == "" {
+				header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+		}
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	req, err := http.NewRequest(method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+
+	// The Host header is carried on the request itself rather than in Header
+	if host := header.Get("Host"); host != "" {
+		req.Host = host
+		req.Header.Del("Host")
+	}
+
+	return req, nil
+}
+
+// dataArgValue returns the data a data flag sends, reading @file the way
+// curl does: -d strips line breaks from the file, while --data-binary and
+// --json send it as is, and --data-raw doesn't read files at all
+func dataArgValue(flag, value string) (string, error) {
+	switch flag {
+	case "--data-raw":
+		return value, nil
+	case "--data-urlencode":
+		return encodeDataURLEncode(value)
+	}
+
+	file, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		return value, nil
+	}
+	data, err := readDataFile(file)
+	if err != nil {
+		return "", err
+	}
+	if flag == "--data-binary" || flag == "--json" {
+		return data, nil
+	}
+	return strings.NewReplacer("\r", "", "\n", "").Replace(data), nil
+}
+
+// readDataFile reads a file named by a data flag's @file
+func readDataFile(name string) (string, error) {
+	if name == "-" {
+		return "", fmt.Errorf("reading request data from stdin (@-) isn't supported natively")
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read request data: %w", err)
+	}
+	return string(data), nil
+}
+
+// encodeDataURLEncode applies curl's --data-urlencode rules: "name=content"
+// encodes only the content, "name@file" the content of a file, and a bare
+// value is encoded in full
+func encodeDataURLEncode(value string) (string, error) {
+	at := strings.IndexAny(value, "=@")
+	if at < 0 {
+		return curlEscape(value), nil
+	}
+
+	name, content := value[:at], value[at+1:]
+	if value[at] == '@' {
+		data, err := readDataFile(content)
+		if err != nil {
+			return "", err
+		}
+		content = data
+	}
+	if name == "" {
+		return curlEscape(content), nil
+	}
+	return name + "=" + curlEscape(content), nil
+}
+
+// curlEscape percent-encodes a value like curl does, leaving only letters,
+// digits, and -._~ as they are (so a space is %20, not +)
+func curlEscape(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		if ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' || strings.IndexByte("-._~", ch) >= 0 {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
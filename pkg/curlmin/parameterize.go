@@ -0,0 +1,175 @@
+package curlmin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// EnvVar is an environment variable holding a secret taken out of a command
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+// Heuristics for spotting secrets by the name of the element carrying them
+var (
+	secretHeaderPattern = regexp.MustCompile(`(?i)^(authorization|proxy-authorization)$|token|secret|api-?key|auth|session|signature`)
+	secretCookiePattern = regexp.MustCompile(`(?i)sess|sid|token|auth|jwt|key|secret|pass|csrf|xsrf`)
+	secretParamPattern  = regexp.MustCompile(`(?i)key|token|secret|sig|auth|pass|session|code`)
+
+	// envVarNamePattern matches runs of characters not allowed in a variable name
+	envVarNamePattern = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// secretFlags maps auth flags whose value is a credential to the name of
+// the variable holding it (avoiding names like USER that are already taken)
+var secretFlags = map[string]string{
+	"-u":              "CURL_USER",
+	"--user":          "CURL_USER",
+	"--oauth2-bearer": "OAUTH2_BEARER",
+}
+
+// ParameterizeCurlCommand replaces secret-looking values in a command
+// (credentials in auth headers and flags, session-like cookies, and API
+// keys in query parameters) with ${VAR} placeholders, so the command can be
+// shared without leaking them. It returns the rewritten command and the
+// variables to export for it to run.
+func ParameterizeCurlCommand(curlCmd string) (string, []EnvVar, error) {
+	curl, err := ParseCurlCommand(curlCmd)
+	if err != nil {
+		return "", nil, err
+	}
+
+	p := &parameterizer{used: make(map[string]bool)}
+
+	// Headers, except cookies which are handled per cookie below
+	for _, i := range curl.FindHeaderArgs() {
+		name, value, ok := strings.Cut(curl.literalArg(i+1), ":")
+		if !ok || strings.EqualFold(strings.TrimSpace(name), "cookie") || !secretHeaderPattern.MatchString(strings.TrimSpace(name)) {
+			continue
+		}
+		curl.Command.Args[i+1] = p.word(name+": ", p.variable(name, strings.TrimSpace(value)), "")
+	}
+
+	// Credentials passed with auth flags
+	for _, i := range curl.FindAuthArgs() {
+		if name, ok := secretFlags[curl.argString(i)]; ok {
+			curl.Command.Args[i+1] = p.word("", p.variable(name, curl.literalArg(i+1)), "")
+		}
+	}
+
+	// Session-like cookies
+	for _, i := range curl.FindCookieArgs() {
+		arg := curl.literalArg(i + 1)
+		cookieStr := cookieValue(arg)
+		parts := []string{arg[:len(arg)-len(cookieStr)]}
+		changed := false
+		for j, cookie := range strings.Split(cookieStr, ";") {
+			if j > 0 {
+				parts = append(parts, ";")
+			}
+			name, value, ok := strings.Cut(cookie, "=")
+			if ok && !isCookieAttribute(cookie) && secretCookiePattern.MatchString(name) {
+				parts = append(parts, name+"=", p.variable(strings.TrimSpace(name), value))
+				changed = true
+			} else {
+				parts = append(parts, cookie)
+			}
+		}
+		if changed {
+			curl.Command.Args[i+1] = p.word(parts...)
+		}
+	}
+
+	// API keys and the like in the query string
+	if urlIndex, err := curl.FindURLArg(); err == nil {
+		base, rawQuery, ok := strings.Cut(curl.literalArg(urlIndex), "?")
+		if ok {
+			parts := []string{base, "?"}
+			changed := false
+			for j, pair := range strings.Split(rawQuery, "&") {
+				if j > 0 {
+					parts = append(parts, "&")
+				}
+				name, value, ok := strings.Cut(pair, "=")
+				if ok && value != "" && secretParamPattern.MatchString(name) {
+					parts = append(parts, name+"=", p.variable(name, value))
+					changed = true
+				} else {
+					parts = append(parts, pair)
+				}
+			}
+			if changed {
+				curl.Command.Args[urlIndex] = p.word(parts...)
+			}
+		}
+	}
+	for _, i := range curl.FindURLQueryArgs() {
+		name, value, ok := strings.Cut(curl.literalArg(i+1), "=")
+		if ok && value != "" && secretParamPattern.MatchString(name) {
+			curl.Command.Args[i+1] = p.word(name+"=", p.variable(name, value), "")
+		}
+	}
+
+	cmd, err := curl.ToString()
+	if err != nil {
+		return "", nil, err
+	}
+	return cmd, p.vars, nil
+}
+
+// parameterizer collects the variables created while parameterizing a command
+type parameterizer struct {
+	vars []EnvVar
+	used map[string]bool
+}
+
+// variable registers value under a variable name derived from name, and
+// returns a placeholder marker for word
+func (p *parameterizer) variable(name, value string) string {
+	// Reuse the variable if the same secret appears more than once
+	for _, v := range p.vars {
+		if v.Value == value {
+			return "\x00" + v.Name
+		}
+	}
+
+	varName := strings.ToUpper(envVarNamePattern.ReplaceAllString(name, "_"))
+	varName = strings.Trim(varName, "_")
+	if varName == "" || (varName[0] >= '0' && varName[0] <= '9') {
+		varName = "SECRET_" + varName
+	}
+	base := varName
+	for n := 2; p.used[varName]; n++ {
+		varName = fmt.Sprintf("%s_%d", base, n)
+	}
+	p.used[varName] = true
+
+	p.vars = append(p.vars, EnvVar{Name: varName, Value: value})
+	return "\x00" + varName
+}
+
+// word builds a double-quoted shell word from literal parts and placeholder
+// markers returned by variable
+func (p *parameterizer) word(parts ...string) *syntax.Word {
+	var b strings.Builder
+	b.WriteString(`"`)
+	for _, part := range parts {
+		if name, ok := strings.CutPrefix(part, "\x00"); ok {
+			b.WriteString("${" + name + "}")
+			continue
+		}
+		for _, r := range part {
+			if strings.ContainsRune("\\\"$`", r) {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteString(`"`)
+
+	return &syntax.Word{Parts: []syntax.WordPart{&syntax.Lit{Value: b.String()}}}
+}
@@ -0,0 +1,31 @@
+package curlmin
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParameterizeCurlCommand(t *testing.T) {
+	curlCmd := `curl -H 'Authorization: Bearer xyz789' -H 'Accept: */*' -u 'bob:pa$$' -b 'session=abc123; theme=dark' 'http://example.com/api?api_key=def456&page=2'`
+
+	got, vars, err := ParameterizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("ParameterizeCurlCommand() error: %v", err)
+	}
+
+	want := `curl -H "Authorization: ${AUTHORIZATION}" -H 'Accept: */*' -u "${CURL_USER}" -b "session=${SESSION}; theme=dark" "http://example.com/api?api_key=${API_KEY}&page=2"`
+	if strings.TrimSpace(got) != want {
+		t.Errorf("ParameterizeCurlCommand() =\n%s\nwant\n%s", got, want)
+	}
+
+	wantVars := []EnvVar{
+		{Name: "AUTHORIZATION", Value: "Bearer xyz789"},
+		{Name: "CURL_USER", Value: "bob:pa$$"},
+		{Name: "SESSION", Value: "abc123"},
+		{Name: "API_KEY", Value: "def456"},
+	}
+	if !reflect.DeepEqual(vars, wantVars) {
+		t.Errorf("ParameterizeCurlCommand() vars = %v, want %v", vars, wantVars)
+	}
+}
@@ -0,0 +1,99 @@
+package curlmin
+
+import (
+	"context"
+	"net/http"
+)
+
+// Candidate is an element of a command that a run would test removing
+type Candidate struct {
+	Kind string
+	Name string
+}
+
+// Plan describes what a run would do with a command, worked out without
+// sending any requests
+type Plan struct {
+	// Candidates are the elements the run would test, in the order it would
+	// first test them
+	Candidates []Candidate
+	// Untested are the elements the run would keep or remove without
+	// testing them (e.g. KeepHeaders or IrrelevantParams)
+	Untested []Decision
+	// RequestsIfRemovable and RequestsIfRequired are the number of requests
+	// the run would send, baseline included, if every candidate turned out
+	// removable or if every one turned out required. A real run depends on
+	// which ones do.
+	RequestsIfRemovable int
+	RequestsIfRequired  int
+}
+
+// Plan parses a command and works out which elements a run with the same
+// options would test and how many requests it would send, by running the
+// passes against responses that are never actually requested
+func (m *Minimizer) Plan(curlCmd string) (*Plan, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	plan := &Plan{}
+	seen := map[Candidate]bool{}
+	for _, removable := range []bool{true, false} {
+		sim := New(m.planOptions())
+		sim.assumeRemovable = &removable
+		if _, err := sim.MinimizeCurlCommand(curlCmd); err != nil {
+			return nil, err
+		}
+
+		requests := int(sim.requests.Load())
+		if removable {
+			plan.RequestsIfRemovable = requests
+		} else {
+			plan.RequestsIfRequired = requests
+		}
+
+		for _, d := range sim.decisions {
+			candidate := Candidate{Kind: d.Kind, Name: d.Name}
+			if seen[candidate] {
+				continue
+			}
+			seen[candidate] = true
+			// Only elements decided without a test have a reason when
+			// every test passes
+			if removable && d.Reason != "" {
+				plan.Untested = append(plan.Untested, d)
+				continue
+			}
+			plan.Candidates = append(plan.Candidates, candidate)
+		}
+	}
+	return plan, nil
+}
+
+// planOptions returns the options for a simulated run: the same passes and
+// strategy, with every request answered by a canned response
+func (m *Minimizer) planOptions() Options {
+	o := m.options
+	o.Executor = ExecutorFunc(func(ctx context.Context, curlCmd string) (Response, error) {
+		return Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{}}, nil
+	})
+	o.Native = false
+	o.Verbose = false
+	o.TraceRequests = false
+	o.ReportDir, o.HARFile = "", ""
+	o.OnProgress = nil
+	o.RequestDelay, o.RequestJitter, o.MaxDuration = 0, 0, 0
+
+	// A hook would run commands, and turns off the response cache
+	if o.PreRequestHook != "" {
+		o.PreRequestHook = ""
+		o.ResponseCache = false
+	}
+
+	// The canned response can't satisfy checks on the baseline's content
+	if o.MatchPattern != "" {
+		o.AutoCompare = false
+	}
+	o.MatchPattern, o.MatchIsRegex, o.JQExpression = "", false, ""
+	return o
+}
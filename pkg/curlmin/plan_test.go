@@ -0,0 +1,60 @@
+package curlmin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	// Nothing listens here, so any request sent would fail the baseline
+	curlCmd := `curl -H 'Accept: */*' -H 'Authorization: Bearer xyz' -H 'Cookie: session=abc; theme=dark' 'http://127.0.0.1:1/api?id=1&utm_source=x'`
+
+	minimizer := New(Options{
+		MinimizeHeaders:    true,
+		MinimizeCookies:    true,
+		MinimizeParams:     true,
+		CompareBodyContent: true,
+		KeepParams:         []string{"id"},
+	})
+	plan, err := minimizer.Plan(curlCmd)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	want := []Candidate{
+		{Kind: KindHeader, Name: "Accept: */*"},
+		{Kind: KindHeader, Name: "Authorization: Bearer xyz"},
+		{Kind: KindCookie, Name: "session"},
+		{Kind: KindCookie, Name: "theme"},
+		{Kind: KindQueryParam, Name: "utm_source"},
+	}
+	if !reflect.DeepEqual(plan.Candidates, want) {
+		t.Errorf("Candidates = %v, want %v", plan.Candidates, want)
+	}
+	if len(plan.Untested) != 1 || plan.Untested[0].Name != "id" {
+		t.Errorf("Untested = %v, want only the kept param id", plan.Untested)
+	}
+
+	// The baseline, both headers, and the param, plus the whole Cookie
+	// header, then each cookie in it when the header is required
+	if plan.RequestsIfRemovable != 5 || plan.RequestsIfRequired != 7 {
+		t.Errorf("Requests = %d if removable, %d if required, want 5 and 7", plan.RequestsIfRemovable, plan.RequestsIfRequired)
+	}
+
+	// Testing groups first sends fewer requests when everything goes
+	ddmin := New(Options{
+		MinimizeHeaders:    true,
+		CompareBodyContent: true,
+		Strategy:           StrategyDDMin,
+	})
+	plan, err = ddmin.Plan(`curl -H 'A: 1' -H 'B: 2' -H 'C: 3' -H 'D: 4' http://127.0.0.1:1/`)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Candidates) != 4 {
+		t.Errorf("Candidates = %v, want all 4 headers", plan.Candidates)
+	}
+	if plan.RequestsIfRemovable >= plan.RequestsIfRequired {
+		t.Errorf("Requests = %d if removable, %d if required, want fewer when removable", plan.RequestsIfRemovable, plan.RequestsIfRequired)
+	}
+}
@@ -0,0 +1,313 @@
+package curlmin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// psToken is a token of PowerShell source
+type psToken struct {
+	text string
+	// quoted is set for string literals, whose text has escapes resolved
+	quoted bool
+}
+
+// psEndOfStatement is the token for a line break or semicolon
+var psEndOfStatement = psToken{text: ";"}
+
+// psPunctuation are the characters that are tokens of their own
+const psPunctuation = "(){}@=;,"
+
+// psSwitches are the Invoke-WebRequest and Invoke-RestMethod parameters
+// that don't take a value
+var psSwitches = map[string]bool{
+	"-usebasicparsing":                true,
+	"-skipcertificatecheck":           true,
+	"-skipheadervalidation":           true,
+	"-skiphttperrorcheck":             true,
+	"-disablekeepalive":               true,
+	"-allowunencryptedauthentication": true,
+	"-allowinsecureredirect":          true,
+	"-passthru":                       true,
+	"-resume":                         true,
+}
+
+// psPseudoHeaders are HTTP/2 pseudo-headers that some browsers copy as
+// ordinary headers
+var psPseudoHeaders = map[string]bool{
+	"authority": true,
+	"method":    true,
+	"path":      true,
+	"scheme":    true,
+}
+
+// tokenizePowerShell splits PowerShell source into words, string literals,
+// and punctuation, with line breaks as end-of-statement tokens. Backtick
+// line continuations are joined, and strings are unquoted: backtick
+// escapes and doubled quotes in double-quoted strings, and doubled quotes
+// in single-quoted ones.
+func tokenizePowerShell(script string) ([]psToken, error) {
+	var tokens []psToken
+	runes := []rune(strings.ReplaceAll(script, "\r\n", "\n"))
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '`' && i+1 < len(runes) && runes[i+1] == '\n':
+			i += 2
+		case r == '\n':
+			tokens = append(tokens, psEndOfStatement)
+			i++
+		case r == ' ' || r == '\t':
+			i++
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '"' || r == '\'':
+			var b strings.Builder
+			closed := false
+			for i++; i < len(runes); i++ {
+				c := runes[i]
+				if c == r && i+1 < len(runes) && runes[i+1] == r {
+					b.WriteRune(r)
+					i++
+				} else if c == r {
+					i++
+					closed = true
+					break
+				} else if c == '`' && r == '"' && i+1 < len(runes) {
+					i++
+					b.WriteString(psEscape(runes[i]))
+				} else {
+					b.WriteRune(c)
+				}
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, psToken{text: b.String(), quoted: true})
+		case strings.ContainsRune(psPunctuation, r):
+			tokens = append(tokens, psToken{text: string(r)})
+			i++
+		default:
+			start := i
+			for i < len(runes) && !strings.ContainsRune(psPunctuation+" \t\n\"'`", runes[i]) {
+				i++
+			}
+			if i == start {
+				// A backtick outside a string escapes the next character
+				i++
+				if i < len(runes) {
+					tokens = append(tokens, psToken{text: string(runes[i])})
+					i++
+				}
+				continue
+			}
+			tokens = append(tokens, psToken{text: string(runes[start:i])})
+		}
+	}
+	return tokens, nil
+}
+
+// psEscape resolves the backtick escape for a character in a double-quoted
+// string
+func psEscape(r rune) string {
+	switch r {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '0':
+		return "\x00"
+	case 'a':
+		return "\a"
+	case 'b':
+		return "\b"
+	case 'f':
+		return "\f"
+	case 'v':
+		return "\v"
+	case 'e':
+		return "\x1b"
+	default:
+		return string(r)
+	}
+}
+
+// psStatements splits tokens into statements at line breaks and semicolons
+// that aren't inside parentheses or braces
+func psStatements(tokens []psToken) [][]psToken {
+	var statements [][]psToken
+	var current []psToken
+	depth := 0
+	for _, token := range tokens {
+		if !token.quoted {
+			switch token.text {
+			case "(", "{":
+				depth++
+			case ")", "}":
+				depth--
+			}
+		}
+		if token == psEndOfStatement && depth <= 0 {
+			if len(current) > 0 {
+				statements = append(statements, current)
+			}
+			current = nil
+			continue
+		}
+		current = append(current, token)
+	}
+	if len(current) > 0 {
+		statements = append(statements, current)
+	}
+	return statements
+}
+
+// PowerShellToCurl converts an Invoke-WebRequest or Invoke-RestMethod
+// command, as copied from a browser's developer tools with "Copy as
+// PowerShell", into a curl command. The user agent and cookies the copy sets
+// on a $session web session carry over, as do the URI, method, headers
+// (keeping their order), content type, body, user agent, and
+// -SkipCertificateCheck.
+func PowerShellToCurl(script string) (string, error) {
+	tokens, err := tokenizePowerShell(script)
+	if err != nil {
+		return "", err
+	}
+
+	var userAgent string
+	var cookies []string
+	var invoke []psToken
+	for _, statement := range psStatements(tokens) {
+		first := strings.ToLower(statement[0].text)
+		switch {
+		case first == "$session.useragent" && len(statement) >= 3 && statement[2].quoted:
+			userAgent = statement[2].text
+		case first == "$session.cookies.add":
+			// The first two strings are the name and value of a
+			// System.Net.Cookie
+			var values []string
+			for _, token := range statement {
+				if token.quoted {
+					values = append(values, token.text)
+				}
+			}
+			if len(values) >= 2 {
+				cookies = append(cookies, values[0]+"="+values[1])
+			}
+		case first == "invoke-webrequest", first == "invoke-restmethod", first == "iwr", first == "irm":
+			invoke = statement
+		}
+	}
+	if invoke == nil {
+		return "", fmt.Errorf("no Invoke-WebRequest or Invoke-RestMethod command found")
+	}
+
+	var target, method, contentType string
+	var headers, flags []string
+	var body *string
+	for i := 1; i < len(invoke); i++ {
+		token := invoke[i]
+		param := strings.ToLower(token.text)
+		if token.quoted || !strings.HasPrefix(param, "-") {
+			// The URI can also be given by position
+			if target == "" {
+				target = token.text
+			}
+			continue
+		}
+		if psSwitches[param] {
+			if param == "-skipcertificatecheck" {
+				flags = append(flags, "-k")
+			}
+			continue
+		}
+		if i+1 >= len(invoke) {
+			return "", fmt.Errorf("missing value for %s", token.text)
+		}
+
+		i++
+		value := invoke[i].text
+		switch param {
+		case "-uri":
+			target = value
+		case "-method":
+			method = value
+		case "-contenttype":
+			contentType = value
+		case "-useragent":
+			userAgent = value
+		case "-headers":
+			var entries []string
+			entries, i, err = psHashtable(invoke, i)
+			if err != nil {
+				return "", err
+			}
+			for j := 0; j < len(entries); j += 2 {
+				if !psPseudoHeaders[strings.ToLower(entries[j])] {
+					headers = append(headers, entries[j]+": "+entries[j+1])
+				}
+			}
+		case "-body":
+			// A body given as an expression, like
+			// ([System.Text.Encoding]::UTF8.GetBytes("...")), is taken from
+			// its string
+			if value == "(" {
+				for depth := 1; depth > 0 && i+1 < len(invoke); {
+					i++
+					switch {
+					case invoke[i].quoted && body == nil:
+						value = invoke[i].text
+						body = &value
+					case invoke[i].text == "(":
+						depth++
+					case invoke[i].text == ")":
+						depth--
+					}
+				}
+			} else {
+				body = &value
+			}
+		}
+	}
+	if target == "" {
+		return "", fmt.Errorf("no URI found")
+	}
+
+	if contentType != "" {
+		headers = append(headers, "Content-Type: "+contentType)
+	}
+	if userAgent != "" {
+		headers = append(headers, "User-Agent: "+userAgent)
+	}
+	if len(cookies) > 0 {
+		flags = append(flags, "-b", strings.Join(cookies, "; "))
+	}
+	return buildCurlCommand(method, target, flags, headers, body)
+}
+
+// psHashtable reads the hashtable starting at tokens[i] ("@", "{", ...),
+// returning its keys and values in turn and the index of its closing brace
+func psHashtable(tokens []psToken, i int) ([]string, int, error) {
+	if i+1 >= len(tokens) || tokens[i].text != "@" || tokens[i+1].text != "{" {
+		return nil, i, fmt.Errorf("expected a hashtable")
+	}
+
+	var entries []string
+	for i += 2; i < len(tokens); i++ {
+		switch {
+		case tokens[i].text == "}" && !tokens[i].quoted:
+			return entries, i, nil
+		case tokens[i] == psEndOfStatement:
+		case i+2 < len(tokens) && tokens[i+1].text == "=" && !tokens[i+1].quoted:
+			entries = append(entries, tokens[i].text, tokens[i+2].text)
+			i += 2
+		default:
+			return nil, i, fmt.Errorf("invalid hashtable entry %q", tokens[i].text)
+		}
+	}
+	return nil, i, fmt.Errorf("unterminated hashtable")
+}
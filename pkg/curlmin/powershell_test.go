@@ -0,0 +1,69 @@
+package curlmin
+
+import "testing"
+
+func TestPowerShellToCurl(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "copied from chrome",
+			script: "$session = New-Object Microsoft.PowerShell.Commands.WebRequestSession\r\n" +
+				"$session.UserAgent = \"Mozilla/5.0\"\r\n" +
+				"$session.Cookies.Add((New-Object System.Net.Cookie(\"sid\", \"abc\", \"/\", \"example.com\")))\r\n" +
+				"$session.Cookies.Add((New-Object System.Net.Cookie(\"theme\", \"dark\", \"/\", \"example.com\")))\r\n" +
+				"Invoke-WebRequest -UseBasicParsing -Uri \"https://example.com/api/items?page=2\" `\r\n" +
+				"-WebSession $session `\r\n" +
+				"-Headers @{\r\n" +
+				"\"authority\"=\"example.com\"\r\n" +
+				"  \"method\"=\"GET\"\r\n" +
+				"  \"accept\"=\"application/json\"\r\n" +
+				"  \"x-api-key\"=\"abc\"\r\n" +
+				"}",
+			want: `curl -b 'sid=abc; theme=dark' -H 'accept: application/json' -H 'x-api-key: abc' -H 'User-Agent: Mozilla/5.0' 'https://example.com/api/items?page=2'`,
+		},
+		{
+			name: "post with a body",
+			script: "Invoke-WebRequest -UseBasicParsing -Uri \"https://example.com/login\" `\n" +
+				"-Method \"POST\" `\n" +
+				"-ContentType \"application/json\" `\n" +
+				"-Body \"{`\"user`\":`\"o'k`\", `\"cost`\":`\"`$5`\"}\"",
+			want: `curl -H 'Content-Type: application/json' --data-raw '{"user":"o'\''k", "cost":"$5"}' 'https://example.com/login'`,
+		},
+		{
+			name:   "body as bytes",
+			script: `Invoke-WebRequest -Uri "https://example.com/" -Method "PUT" -Body ([System.Text.Encoding]::UTF8.GetBytes("a=1"))`,
+			want:   `curl -X PUT --data-raw 'a=1' 'https://example.com/'`,
+		},
+		{
+			name:   "positional uri and single quotes",
+			script: `irm 'https://example.com/it''s' -Method Delete -SkipCertificateCheck -TimeoutSec 5`,
+			want:   `curl -k -X DELETE 'https://example.com/it'\''s'`,
+		},
+		{
+			name:    "not powershell",
+			script:  `curl https://example.com/`,
+			wantErr: true,
+		},
+		{
+			name:    "unterminated string",
+			script:  `Invoke-WebRequest -Uri "https://example.com/`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PowerShellToCurl(tt.script)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PowerShellToCurl() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("PowerShellToCurl() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package curlmin
+
+import (
+	"context"
+	"os/exec"
+)
+
+// killGroupOnCancel leaves cmd's default cancellation, which only kills the
+// shell itself
+func killGroupOnCancel(ctx context.Context, cmd *exec.Cmd) {}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package curlmin
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// killGroupOnCancel runs cmd in its own process group and makes canceling
+// ctx kill the whole group, so a curl the shell forked instead of exec'ing
+// doesn't outlive it. A context that can't be canceled leaves cmd in the
+// terminal's process group, where Ctrl-C reaches it.
+func killGroupOnCancel(ctx context.Context, cmd *exec.Cmd) {
+	if ctx.Done() == nil {
+		return
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
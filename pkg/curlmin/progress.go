@@ -0,0 +1,49 @@
+package curlmin
+
+// Progress event types
+const (
+	ProgressPassStarted     = "pass started"
+	ProgressCandidateTested = "candidate tested"
+	ProgressElementRemoved  = "element removed"
+	ProgressElementKept     = "element kept"
+)
+
+// ProgressEvent reports a step of a run to Options.OnProgress
+type ProgressEvent struct {
+	// Type is one of the Progress* event types
+	Type string
+	// Pass is the pass that started, e.g. PassHeaders
+	Pass string
+	// Command is the candidate command that was tested, and Removable
+	// whether its response matched the baseline
+	Command   string
+	Removable bool
+	// Decision is the decision about an element that was removed or kept.
+	// Passes retest kept elements, so an element can be reported again.
+	Decision Decision
+	// Requests is how many requests the run has sent so far
+	Requests int
+}
+
+// progress reports an event to OnProgress, if it's set
+func (m *Minimizer) progress(event ProgressEvent) {
+	if m.options.OnProgress == nil {
+		return
+	}
+	event.Requests = int(m.requests.Load())
+	m.options.OnProgress(event)
+}
+
+// startPass reports the start of a pass
+func (m *Minimizer) startPass(pass string) {
+	m.progress(ProgressEvent{Type: ProgressPassStarted, Pass: pass})
+}
+
+// progressDecision reports a decision about an element
+func (m *Minimizer) progressDecision(decision Decision) {
+	event := ProgressEvent{Type: ProgressElementKept, Decision: decision}
+	if decision.Removed {
+		event.Type = ProgressElementRemoved
+	}
+	m.progress(event)
+}
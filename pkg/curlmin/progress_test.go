@@ -0,0 +1,66 @@
+package curlmin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOnProgress(t *testing.T) {
+	// The server needs the X-Api-Key header and nothing else
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	var events []ProgressEvent
+	min := New(Options{
+		MinimizeHeaders: true,
+		MinimizeParams:  true,
+		KeepParams:      []string{"id"},
+		OnProgress: func(event ProgressEvent) {
+			events = append(events, event)
+		},
+	})
+	curlCmd := fmt.Sprintf(`curl -H 'X-Api-Key: abc' -H 'Accept: */*' '%s/?id=1'`, server.URL)
+	if _, err := min.MinimizeCurlCommand(curlCmd); err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	var got []string
+	for _, event := range events {
+		switch event.Type {
+		case ProgressPassStarted:
+			got = append(got, event.Type+" "+event.Pass)
+		case ProgressCandidateTested:
+			got = append(got, fmt.Sprintf("%s removable=%t", event.Type, event.Removable))
+		default:
+			got = append(got, event.Type+" "+event.Decision.Name)
+		}
+	}
+	want := []string{
+		"pass started headers",
+		"candidate tested removable=false",
+		"element kept X-Api-Key: abc",
+		"candidate tested removable=true",
+		"element removed Accept: */*",
+		// The pass goes round again until nothing more can be removed
+		"candidate tested removable=false",
+		"element kept X-Api-Key: abc",
+		"pass started params",
+		"element kept id",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Events are\n%q\nwant\n%q", got, want)
+	}
+
+	// The baseline and every candidate have been sent by the last event
+	if last := events[len(events)-1]; last.Requests != 4 {
+		t.Errorf("Last event reports %d requests, want 4", last.Requests)
+	}
+}
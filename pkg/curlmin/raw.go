@@ -0,0 +1,156 @@
+package curlmin
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// rawPlainArg matches arguments (like curl and its flags) that don't need quoting
+var rawPlainArg = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+
+// rawSkippedHeaders are headers of a raw request that curl sets itself
+var rawSkippedHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"transfer-encoding": true,
+}
+
+// RawRequestToCurl converts a raw HTTP/1.x request, as captured by an
+// intercepting proxy like Burp, ZAP, or mitmproxy, into a curl command.
+// Headers keep their order, and the body is sent with --data-raw, so one
+// starting with @ isn't taken for a file to read.
+// Since an origin-form request line doesn't carry a scheme, scheme says
+// which one to use with the Host header ("https" if empty).
+func RawRequestToCurl(raw []byte, scheme string) (string, error) {
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	reader := bufio.NewReader(bytes.NewReader(raw))
+	readLine := func() (string, error) {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	// Skip any blank lines before the request line
+	var requestLine string
+	for requestLine == "" {
+		line, err := readLine()
+		if err != nil {
+			return "", fmt.Errorf("no request line found")
+		}
+		requestLine = strings.TrimSpace(line)
+	}
+	method, target, ok := strings.Cut(requestLine, " ")
+	if !ok {
+		return "", fmt.Errorf("invalid request line %q", requestLine)
+	}
+	target, _, _ = strings.Cut(strings.TrimSpace(target), " ")
+
+	var host string
+	var headers []string
+	chunked := false
+	contentLength := -1
+	for {
+		line, err := readLine()
+		if err != nil || line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return "", fmt.Errorf("invalid header line %q", line)
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		switch strings.ToLower(name) {
+		case "host":
+			host = value
+		case "transfer-encoding":
+			chunked = strings.EqualFold(value, "chunked")
+		case "content-length":
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				contentLength = n
+			}
+		}
+		if !rawSkippedHeaders[strings.ToLower(name)] {
+			headers = append(headers, name+": "+value)
+		}
+	}
+
+	var body []byte
+	var err error
+	if chunked {
+		body, err = io.ReadAll(httputil.NewChunkedReader(reader))
+	} else {
+		body, err = io.ReadAll(reader)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read request body: %w", err)
+	}
+	// Drop anything past Content-Length, like the newline editors add
+	if !chunked && contentLength >= 0 && contentLength < len(body) {
+		body = body[:contentLength]
+	}
+	if bytes.IndexByte(body, 0) >= 0 {
+		return "", fmt.Errorf("request bodies with NUL bytes can't be passed to curl")
+	}
+
+	// Build the URL from the request target, which is either absolute (for
+	// requests captured on their way to a proxy) or a path on Host
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid request target %q: %w", target, err)
+	}
+	if !targetURL.IsAbs() {
+		if host == "" {
+			return "", fmt.Errorf("request has no Host header")
+		}
+		target = (&url.URL{Scheme: scheme, Host: host}).String() + target
+	}
+
+	args := []string{"curl"}
+	switch {
+	case method == "HEAD":
+		args = append(args, "-I")
+	case method == "GET" && len(body) == 0, method == "POST" && len(body) > 0:
+	default:
+		args = append(args, "-X", method)
+	}
+	for _, header := range headers {
+		args = append(args, "-H", header)
+	}
+	if len(body) > 0 {
+		args = append(args, "--data-raw", string(body))
+	}
+	args = append(args, target)
+	return shellCommand(args)
+}
+
+// shellCommand prints args as a shell command, quoting the ones that need it
+func shellCommand(args []string) (string, error) {
+	call := &syntax.CallExpr{}
+	for _, arg := range args {
+		if rawPlainArg.MatchString(arg) {
+			call.Args = append(call.Args, &syntax.Word{Parts: []syntax.WordPart{&syntax.Lit{Value: arg}}})
+			continue
+		}
+		call.Args = append(call.Args, quotedWord(arg))
+	}
+
+	var buf bytes.Buffer
+	if err := syntax.NewPrinter().Print(&buf, call); err != nil {
+		return "", fmt.Errorf("failed to print command: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,64 @@
+package curlmin
+
+import (
+	"testing"
+)
+
+func TestRawRequestToCurl(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		scheme string
+		want   string
+	}{
+		{
+			name:   "GET with CRLF line endings",
+			raw:    "GET /api/test?id=1 HTTP/1.1\r\nHost: example.com\r\nAuthorization: Bearer xyz\r\nCookie: session=abc; _ga=1\r\n\r\n",
+			scheme: "",
+			want:   `curl -H 'Authorization: Bearer xyz' -H 'Cookie: session=abc; _ga=1' 'https://example.com/api/test?id=1'`,
+		},
+		{
+			name:   "POST body cut at Content-Length",
+			raw:    "POST /login HTTP/1.1\nHost: localhost:8080\nContent-Type: application/json\nContent-Length: 14\n\n{\"user\":\"o'k\"}\n",
+			scheme: "http",
+			want:   `curl -H 'Content-Type: application/json' --data-raw '{"user":"o'\''k"}' 'http://localhost:8080/login'`,
+		},
+		{
+			name:   "absolute target",
+			raw:    "DELETE http://api.example.com/items/1 HTTP/1.1\r\nHost: api.example.com\r\n\r\n",
+			scheme: "https",
+			want:   `curl -X DELETE 'http://api.example.com/items/1'`,
+		},
+		{
+			name:   "chunked body",
+			raw:    "PUT /items HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n2\r\nde\r\n0\r\n\r\n",
+			scheme: "https",
+			want:   `curl -X PUT --data-raw abcde 'https://example.com/items'`,
+		},
+		{
+			name:   "body starting with @",
+			raw:    "POST /notes HTTP/1.1\r\nHost: example.com\r\nContent-Length: 11\r\n\r\n@/etc/hosts",
+			scheme: "https",
+			want:   `curl --data-raw '@/etc/hosts' 'https://example.com/notes'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RawRequestToCurl([]byte(tt.raw), tt.scheme)
+			if err != nil {
+				t.Fatalf("RawRequestToCurl() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RawRequestToCurl() = %q, want %q", got, tt.want)
+			}
+			if _, err := ParseCurlCommand(got); err != nil {
+				t.Errorf("Converted command doesn't parse: %v", err)
+			}
+		})
+	}
+
+	if _, err := RawRequestToCurl([]byte("GET / HTTP/1.1\r\n\r\n"), ""); err == nil {
+		t.Errorf("Expected an error for a request without a Host header")
+	}
+}
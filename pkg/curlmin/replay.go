@@ -0,0 +1,125 @@
+package curlmin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// HARReplay is an Executor that answers requests with the responses
+// recorded for them in a HAR file (like one written with HARFile), so a
+// minimization can be repeated without a server. A request matches an
+// entry with the same method, URL, headers, and body; when several
+// entries match, they're used in the order they were recorded, the last
+// one repeating.
+type HARReplay struct {
+	mu      sync.Mutex
+	entries map[string][]harEntry
+	// served counts the responses already used for each request
+	served map[string]int
+}
+
+// NewHARReplay reads the entries of a HAR file for replay
+func NewHARReplay(data []byte) (*HARReplay, error) {
+	var har harLog
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("failed to parse HAR: %w", err)
+	}
+
+	replay := &HARReplay{
+		entries: make(map[string][]harEntry),
+		served:  make(map[string]int),
+	}
+	for _, entry := range har.Log.Entries {
+		key := harRequestKey(entry.Request)
+		replay.entries[key] = append(replay.entries[key], entry)
+	}
+	return replay, nil
+}
+
+// Execute returns the recorded response to the request curlCmd sends
+func (r *HARReplay) Execute(ctx context.Context, curlCmd string) (Response, error) {
+	request := harRequestFor(curlCmd)
+	key := harRequestKey(request)
+
+	r.mu.Lock()
+	entries := r.entries[key]
+	if len(entries) == 0 {
+		r.mu.Unlock()
+		return Response{}, fmt.Errorf("no recorded response for %s %s", request.Method, request.URL)
+	}
+	entry := entries[min(r.served[key], len(entries)-1)]
+	r.served[key]++
+	r.mu.Unlock()
+
+	return entry.Response.toResponse()
+}
+
+// harRequestKey identifies a request by its method, URL, headers, and body
+func harRequestKey(request harRequest) string {
+	headers := make([]string, 0, len(request.Headers))
+	for _, header := range request.Headers {
+		headers = append(headers, http.CanonicalHeaderKey(header.Name)+": "+header.Value)
+	}
+	slices.Sort(headers)
+
+	var body string
+	if request.PostData != nil {
+		body = request.PostData.Text
+	}
+	return strings.Join([]string{request.Method, request.URL, strings.Join(headers, "\n"), body}, "\n\n")
+}
+
+// toResponse turns a recorded response back into the one it was recorded
+// from, or the error the request failed with
+func (r harResponse) toResponse() (Response, error) {
+	if r.Status == 0 {
+		if r.Comment == "timed out" {
+			return Response{TimedOut: true}, nil
+		}
+		return Response{}, fmt.Errorf("recorded request failed: %s", r.Comment)
+	}
+
+	body := r.Content.Text
+	if r.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return Response{}, fmt.Errorf("failed to decode recorded body: %w", err)
+		}
+		body = string(decoded)
+	}
+
+	header := make(http.Header)
+	for _, h := range r.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	var trailer http.Header
+	for _, t := range r.Trailers {
+		if trailer == nil {
+			trailer = make(http.Header)
+		}
+		trailer.Add(t.Name, t.Value)
+	}
+
+	// Render the header block the way curl's -D dump would
+	status := strings.TrimSpace(fmt.Sprintf("%d %s", r.Status, r.StatusText))
+	var rawHeaders strings.Builder
+	fmt.Fprintf(&rawHeaders, "%s %s\r\n", r.HTTPVersion, status)
+	header.Write(&rawHeaders)
+	rawHeaders.WriteString("\r\n")
+
+	return Response{
+		StatusCode:    r.Status,
+		Status:        status,
+		Body:          body,
+		Header:        header,
+		Trailer:       trailer,
+		RawHeaders:    rawHeaders.String(),
+		RedirectChain: r.RedirectChain,
+	}, nil
+}
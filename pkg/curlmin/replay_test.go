@@ -0,0 +1,75 @@
+package curlmin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHARReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xyz" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("id") != "1" {
+			fmt.Fprint(w, "Missing id")
+			return
+		}
+		w.Write([]byte("Success \xff"))
+	}))
+
+	harFile := filepath.Join(t.TempDir(), "traffic.har")
+	curlCmd := fmt.Sprintf(`curl -H 'Authorization: Bearer xyz' -H 'X-A: 1' -b 'session=abc' -d 'a=1' '%s/?id=1&utm=x'`, server.URL)
+	options := Options{
+		MinimizeHeaders:    true,
+		MinimizeCookies:    true,
+		MinimizeParams:     true,
+		CompareBodyContent: true,
+		CompareStatusCode:  true,
+	}
+	recordOptions := options
+	recordOptions.HARFile = harFile
+	want, err := New(recordOptions).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	// Replay the same run with the server gone
+	server.Close()
+	data, err := os.ReadFile(harFile)
+	if err != nil {
+		t.Fatalf("Failed to read HAR file: %v", err)
+	}
+	replay, err := NewHARReplay(data)
+	if err != nil {
+		t.Fatalf("NewHARReplay() error = %v", err)
+	}
+	replayOptions := options
+	replayOptions.Executor = replay
+	got, err := New(replayOptions).MinimizeCurlCommand(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command from the recording: %v", err)
+	}
+	if got != want {
+		t.Errorf("Replayed minimization = %q, want %q", got, want)
+	}
+
+	// Binary bodies survive the round trip
+	resp, err := replay.Execute(context.Background(), curlCmd)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if resp.StatusCode != 200 || resp.Body != "Success \xff" {
+		t.Errorf("Execute() = %d %q, want 200 %q", resp.StatusCode, resp.Body, "Success \xff")
+	}
+
+	// A request that was never recorded fails
+	if _, err := replay.Execute(context.Background(), `curl -H 'X-New: 1' `+server.URL); err == nil {
+		t.Error("Execute() of an unrecorded request didn't fail")
+	}
+}
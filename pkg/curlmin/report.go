@@ -0,0 +1,82 @@
+package curlmin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeReport saves a command and its response to dir as <name>-command.sh,
+// <name>-headers.txt, and <name>-body.txt
+func writeReport(dir, name, curlCmd string, resp Response) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	files := map[string]string{
+		name + "-command.sh":  curlCmd,
+		name + "-headers.txt": resp.RawHeaders,
+		name + "-body.txt":    resp.Body,
+	}
+
+	for file, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(contents), 0o644); err != nil {
+			return fmt.Errorf("failed to write report file %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+// Report summarizes a minimization: the names of the elements removed and
+// kept in each category, and how many requests it took
+type Report struct {
+	RemovedHeaders    []string `json:"removed_headers"`
+	KeptHeaders       []string `json:"kept_headers"`
+	RemovedCookies    []string `json:"removed_cookies"`
+	KeptCookies       []string `json:"kept_cookies"`
+	RemovedParams     []string `json:"removed_params"`
+	KeptParams        []string `json:"kept_params"`
+	RemovedBodyFields []string `json:"removed_body_fields,omitempty"`
+	KeptBodyFields    []string `json:"kept_body_fields,omitempty"`
+	// Cookies are the names of the cookies the minimized command sends,
+	// with copies sent more than once collapsed
+	Cookies []string `json:"cookies,omitempty"`
+	// Requests is the number of requests sent, including the baseline
+	Requests int `json:"requests"`
+	// Partial is set when the run stopped early at its MaxRequests or
+	// MaxDuration budget
+	Partial bool `json:"partial"`
+}
+
+// newReport sorts decisions into a Report by kind, naming headers without
+// their values (e.g. "Authorization") and --url-query params by name
+func newReport(decisions []Decision, requests int) *Report {
+	report := &Report{Requests: requests}
+	for _, d := range decisions {
+		var removed, kept *[]string
+		name := d.Name
+		switch d.Kind {
+		case KindHeader:
+			removed, kept = &report.RemovedHeaders, &report.KeptHeaders
+			name = headerElementName(name)
+		case KindCookie:
+			removed, kept = &report.RemovedCookies, &report.KeptCookies
+		case KindQueryParam:
+			removed, kept = &report.RemovedParams, &report.KeptParams
+			name, _, _ = strings.Cut(strings.TrimPrefix(name, "+"), "=")
+		case KindBodyField:
+			removed, kept = &report.RemovedBodyFields, &report.KeptBodyFields
+		default:
+			continue
+		}
+
+		if d.Removed {
+			*removed = append(*removed, name)
+		} else {
+			*kept = append(*kept, name)
+		}
+	}
+	return report
+}
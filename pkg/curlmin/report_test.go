@@ -0,0 +1,55 @@
+package curlmin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMinimizeCurlCommandWithReport(t *testing.T) {
+	// The server needs the auth header, the session cookie, and the id param
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		cookie, err := r.Cookie("session")
+		if r.Header.Get("Authorization") != "Bearer xyz" || err != nil || cookie.Value != "abc" || r.URL.Query().Get("id") == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	curlCmd := fmt.Sprintf(`curl -H 'Accept: */*' -H 'Authorization: Bearer xyz' -H 'Cookie: _ga=1; session=abc' --url-query 'page=1' '%s/?id=42'`, server.URL)
+
+	_, report, err := New(Options{
+		MinimizeHeaders: true,
+		MinimizeCookies: true,
+		MinimizeParams:  true,
+	}).MinimizeCurlCommandWithReport(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		got, want []string
+	}{
+		{"removed headers", report.RemovedHeaders, []string{"Accept"}},
+		{"kept headers", report.KeptHeaders, []string{"Authorization"}},
+		{"removed cookies", report.RemovedCookies, []string{"_ga"}},
+		{"kept cookies", report.KeptCookies, []string{"session"}},
+		{"removed params", report.RemovedParams, []string{"page"}},
+		{"kept params", report.KeptParams, []string{"id"}},
+	} {
+		if !slices.Equal(tc.got, tc.want) {
+			t.Errorf("Report %s are %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+	if got, want := report.Requests, int(requests.Load()); got != want {
+		t.Errorf("Report counts %d requests, server got %d", got, want)
+	}
+}
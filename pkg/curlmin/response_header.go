@@ -0,0 +1,35 @@
+package curlmin
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// headersMatch reports whether two responses have the same values for each
+// of the headers named in Options.CompareHeaders (a header missing from
+// both matches)
+func (m *Minimizer) headersMatch(resp1, resp2 Response) bool {
+	for _, name := range m.options.CompareHeaders {
+		if !slices.Equal(resp1.Header.Values(name), resp2.Header.Values(name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// describeHeaderChange explains which compared header a candidate response
+// changed, or returns "" if none did or the status changed too (which says
+// more about why)
+func (m *Minimizer) describeHeaderChange(baseline, resp Response) string {
+	if baseline.TimedOut != resp.TimedOut || baseline.StatusCode != resp.StatusCode {
+		return ""
+	}
+	for _, name := range m.options.CompareHeaders {
+		before, after := baseline.Header.Values(name), resp.Header.Values(name)
+		if !slices.Equal(before, after) {
+			return fmt.Sprintf("removing changes header %s %q -> %q", name, strings.Join(before, ", "), strings.Join(after, ", "))
+		}
+	}
+	return ""
+}
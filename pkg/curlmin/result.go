@@ -0,0 +1,52 @@
+package curlmin
+
+import (
+	"context"
+	"time"
+)
+
+// MinimizeResult is everything about a successful run: the minimized
+// command, how much was removed, and what it took
+type MinimizeResult struct {
+	// Command is the minimized command
+	Command string
+	// RemovedHeaders, RemovedCookies, and RemovedParams count the elements
+	// of each kind that were removed
+	RemovedHeaders int
+	RemovedCookies int
+	RemovedParams  int
+	// Decisions are the decisions about each element, as returned by
+	// Decisions
+	Decisions []Decision
+	// Requests is the number of requests sent, including the baseline
+	Requests int
+	// Elapsed is how long the run took
+	Elapsed time.Duration
+	// Partial is set when the run stopped early at its MaxRequests or
+	// MaxDuration budget
+	Partial bool
+}
+
+// Minimize is like MinimizeCurlCommand, but returns a MinimizeResult
+func (m *Minimizer) Minimize(curlCmd string) (*MinimizeResult, error) {
+	return m.MinimizeContext(context.Background(), curlCmd)
+}
+
+// MinimizeContext is Minimize with a context, like MinimizeCurlCommandContext
+func (m *Minimizer) MinimizeContext(ctx context.Context, curlCmd string) (*MinimizeResult, error) {
+	start := time.Now()
+	minimizedCmd, report, err := m.MinimizeCurlCommandWithReportContext(ctx, curlCmd)
+	if err != nil {
+		return nil, err
+	}
+	return &MinimizeResult{
+		Command:        minimizedCmd,
+		RemovedHeaders: len(report.RemovedHeaders),
+		RemovedCookies: len(report.RemovedCookies),
+		RemovedParams:  len(report.RemovedParams),
+		Decisions:      m.Decisions(),
+		Requests:       report.Requests,
+		Elapsed:        time.Since(start),
+		Partial:        report.Partial,
+	}, nil
+}
@@ -0,0 +1,45 @@
+package curlmin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMinimizeResult(t *testing.T) {
+	// The server needs the sid cookie and nothing else
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("sid"); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "Success")
+	}))
+	defer server.Close()
+
+	min := New(Options{MinimizeHeaders: true, MinimizeCookies: true, MinimizeParams: true})
+	curlCmd := fmt.Sprintf(`curl -H 'Accept: */*' -H 'X-Trace: 1' -b 'sid=1; _ga=2' '%s/?utm_source=test'`, server.URL)
+	result, err := min.Minimize(curlCmd)
+	if err != nil {
+		t.Fatalf("Failed to minimize curl command: %v", err)
+	}
+
+	if want := fmt.Sprintf(`curl -b 'sid=1' '%s/'`, server.URL); strings.TrimSpace(result.Command) != want {
+		t.Errorf("Command is %q, want %q", result.Command, want)
+	}
+	if result.RemovedHeaders != 2 || result.RemovedCookies != 1 || result.RemovedParams != 1 {
+		t.Errorf("Removed %d headers, %d cookies, and %d params, want 2, 1, and 1", result.RemovedHeaders, result.RemovedCookies, result.RemovedParams)
+	}
+	if len(result.Decisions) != 5 {
+		t.Errorf("Got %d decisions, want 5: %+v", len(result.Decisions), result.Decisions)
+	}
+	if result.Requests < 5 || result.Elapsed <= 0 || result.Partial {
+		t.Errorf("Result reports %d requests in %v (partial %t), want at least 5 in some time", result.Requests, result.Elapsed, result.Partial)
+	}
+
+	if _, err := New(Options{KeepPatterns: []string{"("}}).Minimize(curlCmd); err == nil {
+		t.Error("Expected an error for invalid options")
+	}
+}
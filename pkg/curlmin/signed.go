@@ -0,0 +1,163 @@
+package curlmin
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignedURLFamily describes a family of query parameters that together form
+// a signed URL. The parameters are interdependent, so removing any of them
+// invalidates the signature.
+type SignedURLFamily struct {
+	Name string
+	// Signature is the parameter whose presence identifies the family
+	Signature string
+	// Params are the other parameters covered by the signature
+	Params []string
+}
+
+// DefaultSignedURLFamilies are the signed URL schemes recognized when
+// Options.SignedURLFamilies is nil
+var DefaultSignedURLFamilies = []SignedURLFamily{
+	{
+		Name:      "AWS SigV4",
+		Signature: "X-Amz-Signature",
+		Params:    []string{"X-Amz-Algorithm", "X-Amz-Credential", "X-Amz-Date", "X-Amz-Expires", "X-Amz-SignedHeaders", "X-Amz-Security-Token"},
+	},
+	{
+		Name:      "AWS SigV2",
+		Signature: "Signature",
+		Params:    []string{"AWSAccessKeyId", "Expires", "x-amz-security-token"},
+	},
+	{
+		Name:      "CloudFront",
+		Signature: "Signature",
+		Params:    []string{"Expires", "Policy", "Key-Pair-Id"},
+	},
+	{
+		Name:      "Google Cloud Storage",
+		Signature: "X-Goog-Signature",
+		Params:    []string{"X-Goog-Algorithm", "X-Goog-Credential", "X-Goog-Date", "X-Goog-Expires", "X-Goog-SignedHeaders"},
+	},
+	{
+		Name:      "Azure SAS",
+		Signature: "sig",
+		Params:    []string{"sv", "ss", "srt", "sp", "se", "st", "spr", "sr", "si", "skoid", "sktid", "skt", "ske", "sks", "skv"},
+	},
+}
+
+// signedURLExpiryWarning is how close to expiry a signed URL must be to warn
+const signedURLExpiryWarning = 5 * time.Minute
+
+// signedParams returns the query parameters belonging to a recognized
+// signed URL family, mapped to the family's name
+func (m *Minimizer) signedParams(query url.Values) map[string]string {
+	families := m.options.SignedURLFamilies
+	if families == nil {
+		families = DefaultSignedURLFamilies
+	}
+
+	signed := make(map[string]string)
+	for _, family := range families {
+		if !hasParam(query, family.Signature) {
+			continue
+		}
+		for param := range query {
+			if strings.EqualFold(param, family.Signature) {
+				signed[param] = family.Name
+			}
+			for _, name := range family.Params {
+				if strings.EqualFold(param, name) {
+					signed[param] = family.Name
+				}
+			}
+		}
+	}
+	return signed
+}
+
+// hasParam reports whether query contains name, ignoring case
+func hasParam(query url.Values, name string) bool {
+	_, ok := getParam(query, name)
+	return ok
+}
+
+// getParam returns the first value of name in query, ignoring case
+func getParam(query url.Values, name string) (string, bool) {
+	for param, values := range query {
+		if strings.EqualFold(param, name) && len(values) > 0 {
+			return values[0], true
+		}
+	}
+	return "", false
+}
+
+// signedURLExpiry returns when a signed URL's signature expires, if it says
+func signedURLExpiry(query url.Values) (time.Time, bool) {
+	// AWS SigV4 and GCS: a signing time plus a lifetime in seconds
+	for _, prefix := range []string{"X-Amz-", "X-Goog-"} {
+		date, ok1 := getParam(query, prefix+"Date")
+		expires, ok2 := getParam(query, prefix+"Expires")
+		if !ok1 || !ok2 {
+			continue
+		}
+		signedAt, err := time.Parse("20060102T150405Z", date)
+		seconds, err2 := strconv.Atoi(expires)
+		if err == nil && err2 == nil {
+			return signedAt.Add(time.Duration(seconds) * time.Second), true
+		}
+	}
+
+	// AWS SigV2 and CloudFront: a Unix timestamp
+	if expires, ok := getParam(query, "Expires"); ok {
+		if seconds, err := strconv.ParseInt(expires, 10, 64); err == nil {
+			return time.Unix(seconds, 0), true
+		}
+	}
+
+	// Azure SAS: an ISO 8601 time or date
+	if hasParam(query, "sig") {
+		if expires, ok := getParam(query, "se"); ok {
+			for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z", "2006-01-02"} {
+				if t, err := time.Parse(layout, expires); err == nil {
+					return t, true
+				}
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// checkSignedURL warns when the command's URL is signed and expired or about
+// to expire, since every request would then fail regardless of what's removed
+func (m *Minimizer) checkSignedURL(curl *CurlCommand) {
+	urlIndex, err := curl.FindURLArg()
+	if err != nil {
+		return
+	}
+	parsedURL, err := url.Parse(curl.argString(urlIndex))
+	if err != nil {
+		return
+	}
+	query := parsedURL.Query()
+	if len(m.signedParams(query)) == 0 {
+		return
+	}
+
+	expiry, ok := signedURLExpiry(query)
+	if !ok {
+		return
+	}
+
+	remaining := time.Until(expiry)
+	switch {
+	case remaining <= 0:
+		m.warn(fmt.Sprintf("signed URL expired at %s", expiry.Format(time.RFC3339)))
+	case remaining < signedURLExpiryWarning:
+		m.warn(fmt.Sprintf("signed URL expires at %s (in %s) and may expire mid-run", expiry.Format(time.RFC3339), remaining.Round(time.Second)))
+	}
+}
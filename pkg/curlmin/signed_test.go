@@ -0,0 +1,67 @@
+package curlmin
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSignedURLs(t *testing.T) {
+	tests := []struct {
+		name       string
+		rawQuery   string
+		wantSigned []string
+		wantExpiry time.Time
+	}{
+		{
+			name:       "AWS SigV4",
+			rawQuery:   "X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Date=20240102T030405Z&X-Amz-Expires=3600&X-Amz-Signature=abc&utm_source=x",
+			wantSigned: []string{"X-Amz-Algorithm", "X-Amz-Date", "X-Amz-Expires", "X-Amz-Signature"},
+			wantExpiry: time.Date(2024, 1, 2, 4, 4, 5, 0, time.UTC),
+		},
+		{
+			name:       "CloudFront",
+			rawQuery:   "Expires=1704164645&Signature=abc&Key-Pair-Id=K1&page=2",
+			wantSigned: []string{"Expires", "Signature", "Key-Pair-Id"},
+			wantExpiry: time.Unix(1704164645, 0),
+		},
+		{
+			name:       "Azure SAS",
+			rawQuery:   "sv=2022-11-02&se=2024-01-02T03:04:05Z&sp=r&sig=abc",
+			wantSigned: []string{"sv", "se", "sp", "sig"},
+			wantExpiry: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		{
+			name:     "expiry without a signature",
+			rawQuery: "Expires=1704164645&page=2",
+		},
+	}
+
+	m := New(Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.rawQuery)
+			if err != nil {
+				t.Fatalf("Failed to parse query: %v", err)
+			}
+
+			signed := m.signedParams(query)
+			if len(signed) != len(tt.wantSigned) {
+				t.Errorf("signedParams() = %v, want %v", signed, tt.wantSigned)
+			}
+			for _, param := range tt.wantSigned {
+				if _, ok := signed[param]; !ok {
+					t.Errorf("signedParams() missing %s", param)
+				}
+			}
+
+			if len(signed) == 0 {
+				return
+			}
+			expiry, ok := signedURLExpiry(query)
+			if !ok || !expiry.Equal(tt.wantExpiry) {
+				t.Errorf("signedURLExpiry() = %v, %v, want %v", expiry, ok, tt.wantExpiry)
+			}
+		})
+	}
+}
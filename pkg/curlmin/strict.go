@@ -0,0 +1,109 @@
+package curlmin
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// unsupportedFlags are the curl flags whose effect curlmin can't model,
+// either because they make curl send more than the one request being
+// minimized or because they conflict with how responses are captured
+var unsupportedFlags = map[string]string{
+	"-:":                "--next (multiple requests)",
+	"--next":            "--next (multiple requests)",
+	"-K":                "config file (-K)",
+	"--config":          "config file (--config)",
+	"-Z":                "parallel transfers (-Z)",
+	"--parallel":        "parallel transfers (--parallel)",
+	"-o":                "output file (-o)",
+	"--output":          "output file (--output)",
+	"-O":                "output file (-O)",
+	"--remote-name":     "output file (--remote-name)",
+	"--remote-name-all": "output file (--remote-name-all)",
+	"-D":                "header dump (-D)",
+	"--dump-header":     "header dump (--dump-header)",
+	"-i":                "headers in output (-i)",
+	"--include":         "headers in output (--include)",
+}
+
+// UnsupportedConstructs lists the shell and curl constructs in a command
+// that curlmin can't fully model, such as pipelines, redirections, --next,
+// and config files. Minimizing a command that uses any of them may give a
+// wrong result.
+func UnsupportedConstructs(curlCmd string) ([]string, error) {
+	curlCmd = strings.TrimSpace(curlCmd)
+	if !strings.HasPrefix(curlCmd, "curl ") {
+		curlCmd = "curl " + curlCmd
+	}
+
+	prog, err := syntax.NewParser().Parse(strings.NewReader(curlCmd), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse shell command: %w", err)
+	}
+
+	var found []string
+	add := func(construct string) {
+		if !slices.Contains(found, construct) {
+			found = append(found, construct)
+		}
+	}
+
+	if len(prog.Stmts) > 1 {
+		add("multiple commands")
+	}
+
+	syntax.Walk(prog, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.Stmt:
+			for _, redir := range n.Redirs {
+				add(fmt.Sprintf("redirection (%s)", redir.Op))
+			}
+			if n.Background {
+				add("background command (&)")
+			}
+			if n.Negated {
+				add("negated command (!)")
+			}
+		case *syntax.BinaryCmd:
+			switch n.Op {
+			case syntax.Pipe, syntax.PipeAll:
+				add(fmt.Sprintf("pipeline (%s)", n.Op))
+			default:
+				add(fmt.Sprintf("command list (%s)", n.Op))
+			}
+		case *syntax.CallExpr:
+			if len(n.Assigns) > 0 {
+				add("variable assignment")
+			}
+			// Check the flags of any curl command, skipping flag values
+			curl := &CurlCommand{Program: prog, Command: n}
+			if len(n.Args) > 0 && strings.Contains(strings.ToLower(curl.argString(0)), "curl") {
+				for i := 1; i < len(n.Args); i++ {
+					flag := curl.argString(i)
+					if flag == "--" {
+						break
+					}
+					if construct, ok := unsupportedFlags[flag]; ok {
+						add(construct)
+					}
+					if strings.HasPrefix(flag, "-") && takesValue(flag) {
+						i++
+					}
+				}
+			}
+		case *syntax.CmdSubst:
+			add("command substitution")
+		case *syntax.ProcSubst:
+			add("process substitution")
+		case *syntax.Subshell, *syntax.Block, *syntax.IfClause, *syntax.WhileClause,
+			*syntax.ForClause, *syntax.CaseClause, *syntax.FuncDecl:
+			add("compound command")
+		}
+		return true
+	})
+
+	return found, nil
+}
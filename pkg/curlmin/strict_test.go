@@ -0,0 +1,39 @@
+package curlmin
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestUnsupportedConstructs(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want []string
+	}{
+		{`curl -H 'Accept: */*' -sSL 'http://example.com/'`, nil},
+		{`curl -d '-o' 'http://example.com/'`, nil},
+		{`curl 'http://example.com/' | jq .`, []string{"pipeline (|)"}},
+		{`curl 'http://example.com/' > out.html`, []string{"redirection (>)"}},
+		{`curl 'http://example.com/' --next 'http://example.com/2'`, []string{"--next (multiple requests)"}},
+		{`curl -K opts.txt -o out.html 'http://example.com/'`, []string{"config file (-K)", "output file (-o)"}},
+		{`curl -H "Authorization: $(cat token)" 'http://example.com/' && echo done`, []string{"command list (&&)", "command substitution"}},
+		{`curl 'http://example.com/'; TOKEN=x curl 'http://example.com/'`, []string{"multiple commands", "variable assignment"}},
+	}
+
+	for _, tt := range tests {
+		got, err := UnsupportedConstructs(tt.cmd)
+		if err != nil {
+			t.Fatalf("UnsupportedConstructs(%q) failed: %v", tt.cmd, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("UnsupportedConstructs(%q) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+
+	// Strict mode refuses before any requests are made
+	_, err := New(Options{Strict: true}).MinimizeCurlCommand(`curl 'http://example.com/' | jq .`)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("MinimizeCurlCommand() in strict mode returned %v, want ErrUnsupported", err)
+	}
+}